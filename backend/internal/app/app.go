@@ -14,6 +14,8 @@ import (
 	"avidlearner/internal/models"
 	"avidlearner/internal/routes"
 	"avidlearner/internal/lessons"
+	"avidlearner/internal/sandbox"
+	"avidlearner/internal/store"
 )
 
 func Run(ctx context.Context) error {
@@ -33,10 +35,35 @@ func run(ctx context.Context, cfg config.Config) error {
 		log.Printf("Warning: failed to load secret knowledge lessons: %v", err)
 	}
 
-	localLessons := buildFetcherLessons(loaded, secretLessons)
+	markdownLessons, err := lessons.LoadMarkdownDir(cfg.LessonsDir)
+	if err != nil {
+		log.Printf("Warning: skipped invalid Markdown lessons in %s:\n%v", cfg.LessonsDir, err)
+	}
+	markdownLessons, collisions := markdownIDCollisions(loaded, markdownLessons)
+	if collisions != nil {
+		log.Printf("Warning: skipped Markdown lessons in %s:\n%v", cfg.LessonsDir, collisions)
+	}
+	if len(markdownLessons) > 0 {
+		log.Printf("Loaded %d Markdown lessons from %s", len(markdownLessons), cfg.LessonsDir)
+	}
+
+	localLessons := buildFetcherLessons(loaded, secretLessons, markdownLessons)
 	lessonFetcher := lessons.NewFetcher(localLessons, cfg.LessonFetchTTL)
+	sourceConfigs, err := lessons.LoadSourceConfig(cfg.LessonSourcesFile)
+	if err != nil {
+		return fmt.Errorf("load lesson sources from %s: %w", cfg.LessonSourcesFile, err)
+	}
+	if err := lessonFetcher.SetSources(sourceConfigs); err != nil {
+		return fmt.Errorf("configure lesson sources from %s: %w", cfg.LessonSourcesFile, err)
+	}
 	lessonFetcher.StartBackgroundRefresh(ctx, cfg.LessonFetchTTL)
 
+	taxonomy, err := lessons.LoadTaxonomy(cfg.CategoriesFile)
+	if err != nil {
+		return fmt.Errorf("load categories from %s: %w", cfg.CategoriesFile, err)
+	}
+	routes.SetTaxonomy(taxonomy)
+
 	allLessons := lessonFetcher.GetLessons(ctx)
 	routes.UpdateLessonMap(allLessons)
 	log.Printf("Loaded %d lessons total (%d local + external)", len(allLessons), len(loaded))
@@ -49,22 +76,99 @@ func run(ctx context.Context, cfg config.Config) error {
 	}
 	routes.SetProChallenges(challenges, byID)
 
-	if err := routes.LoadLeaderboard(cfg.LeaderboardFile); err != nil {
-		log.Printf("Warning: failed to load leaderboard from %s: %v (starting fresh)", cfg.LeaderboardFile, err)
-		routes.SetLeaderboard([]models.LeaderboardEntry{})
+	trackList, err := routes.LoadTracks(cfg.TracksFile)
+	if err != nil {
+		return fmt.Errorf("load tracks from %s: %w", cfg.TracksFile, err)
 	}
+	routes.SetTracks(trackList)
 
-	startLeaderboardSaver(ctx, cfg.LeaderboardFile, cfg.LeaderboardSaveEvery)
+	reloader := newContentReloader(cfg, lessonFetcher, loaded, secretLessons, markdownLessons, map[string]int{
+		contentProChallenges: len(challenges),
+		contentCategories:    taxonomy.Len(),
+		contentTracks:        len(trackList),
+	})
+	if collisions != nil {
+		routes.ReportContentError(contentMarkdownLessons, cfg.LessonsDir, collisions)
+	}
+	startContentReloader(ctx, reloader, cfg.ContentReloadEvery)
 
-	if err := routes.LoadUsers(cfg.UsersFile); err != nil {
-		log.Printf("Warning: failed to load users from %s: %v (starting fresh)", cfg.UsersFile, err)
+	dataStore, err := openStore(cfg)
+	if err != nil {
+		return fmt.Errorf("open %s store: %w", cfg.StoreDriver, err)
+	}
+	defer func() {
+		if err := dataStore.Close(); err != nil {
+			log.Printf("Error closing store: %v", err)
+		}
+	}()
+	routes.SetStore(dataStore)
+	defer func() {
+		if err := routes.FlushStore(); err != nil {
+			log.Printf("Error flushing store on shutdown: %v", err)
+		}
+	}()
+
+	sessionOpts := routes.SessionOptions{
+		IdleTTL:     cfg.SessionIdleTTL,
+		AbsoluteTTL: cfg.SessionMaxAge,
+		MaxSessions: cfg.MaxSessions,
+		Persist:     cfg.PersistSessions,
+	}
+	if err := routes.ConfigureSessions(sessionOpts, dataStore); err != nil {
+		log.Printf("Warning: failed to restore sessions: %v (starting without persisted sessions)", err)
+		sessionOpts.Persist = false
+		_ = routes.ConfigureSessions(sessionOpts, nil)
+	}
+	startSessionReaper(ctx, cfg.SessionReapEvery)
+
+	if err := routes.LoadLeaderboard(); err != nil {
+		if errors.Is(err, store.ErrCorrupt) {
+			return fmt.Errorf("load leaderboard: %w (set RECOVER_CORRUPT_DATA=true to restore from backup)", err)
+		}
+		log.Printf("Warning: failed to load leaderboard from %s store: %v (starting fresh)", cfg.StoreDriver, err)
+		routes.SetLeaderboard([]models.LeaderboardEntry{})
+	}
+
+	// Never start with an empty user set on a load error: the next flush
+	// would replace every account on disk.
+	if err := routes.LoadUsers(); err != nil {
+		if errors.Is(err, store.ErrCorrupt) {
+			return fmt.Errorf("load users: %w (set RECOVER_CORRUPT_DATA=true to restore from backup)", err)
+		}
+		return fmt.Errorf("load users: %w", err)
+	}
+	if migrated, unresolved := routes.MigrateLessonRefs(); migrated > 0 {
+		log.Printf("Migrated lesson references to IDs for %d users (%d unmatched titles kept)", migrated, unresolved)
+		if err := routes.FlushStore(); err != nil {
+			return fmt.Errorf("save migrated users: %w", err)
+		}
 	}
 
+	routes.SetAdmins(cfg.AdminUsers)
 	if err := routes.SetAuthConfig(cfg.AuthSecret, cfg.AuthTokenTTL); err != nil {
 		return fmt.Errorf("auth config: %w", err)
 	}
 
-	startUsersSaver(ctx, cfg.UsersFile, cfg.UsersSaveEvery)
+	runner, err := sandbox.New(sandbox.Mode(cfg.SandboxMode))
+	if err != nil {
+		return fmt.Errorf("sandbox: %w", err)
+	}
+	switch iso := runner.Isolation(); {
+	case iso.Namespaces:
+		log.Printf("Pro challenge sandbox: %s", iso)
+	case runner.Available():
+		log.Printf("Warning: pro challenge sandbox has no namespace isolation (%s); SANDBOX_MODE=permissive runs submissions with resource limits only", iso.Reason)
+	default:
+		log.Printf("Warning: pro challenge submissions are disabled: no namespace isolation (%s); set SANDBOX_MODE=permissive on a development host", iso.Reason)
+	}
+	routes.SetSandbox(runner)
+	routes.StartGrading(ctx, routes.GradingOptions{
+		Workers:    cfg.GradingWorkers,
+		QueueDepth: cfg.GradingQueueDepth,
+		CacheSize:  cfg.GradingCacheBytes,
+	})
+
+	startStoreFlusher(ctx, cfg.StoreFlushEvery)
 
 	routes.RegisterAPIHandler()
 
@@ -131,15 +235,15 @@ func startLessonMapRefresh(ctx context.Context, fetcher *lessons.Fetcher, delay,
 	}()
 }
 
-func startLeaderboardSaver(ctx context.Context, path string, every time.Duration) {
+func startStoreFlusher(ctx context.Context, every time.Duration) {
 	go func() {
 		ticker := time.NewTicker(every)
 		defer ticker.Stop()
 		for {
 			select {
 			case <-ticker.C:
-				if err := routes.SaveLeaderboard(path); err != nil {
-					log.Printf("Error saving leaderboard: %v", err)
+				if err := routes.FlushStore(); err != nil {
+					log.Printf("Error flushing store: %v", err)
 				}
 			case <-ctx.Done():
 				return
@@ -148,15 +252,15 @@ func startLeaderboardSaver(ctx context.Context, path string, every time.Duration
 	}()
 }
 
-func startUsersSaver(ctx context.Context, path string, every time.Duration) {
+func startSessionReaper(ctx context.Context, every time.Duration) {
 	go func() {
 		ticker := time.NewTicker(every)
 		defer ticker.Stop()
 		for {
 			select {
 			case <-ticker.C:
-				if err := routes.SaveUsers(path); err != nil {
-					log.Printf("Error saving users: %v", err)
+				if n := routes.ReapSessions(); n > 0 {
+					log.Printf("Evicted %d expired sessions", n)
 				}
 			case <-ctx.Done():
 				return
@@ -165,6 +269,65 @@ func startUsersSaver(ctx context.Context, path string, every time.Duration) {
 	}()
 }
 
+// openStore opens the configured store. A new SQLite database is seeded from
+// the JSON files so switching drivers keeps existing accounts. The database
+// counts as new while it holds neither users nor leaderboard entries; users
+// alone would let the leaderboard be imported again on every start of a
+// server nobody has signed up to yet.
+func openStore(cfg config.Config) (store.Store, error) {
+	sessionsFile := ""
+	if cfg.PersistSessions {
+		sessionsFile = cfg.SessionsFile
+	}
+	s, err := store.Open(store.Options{
+		Driver:          cfg.StoreDriver,
+		UsersFile:       cfg.UsersFile,
+		LeaderboardFile: cfg.LeaderboardFile,
+		SessionsFile:    sessionsFile,
+		LedgerFile:      cfg.LedgerFile,
+		AttemptsFile:    cfg.QuizAttemptsFile,
+		SQLitePath:      cfg.SQLitePath,
+		RecoverCorrupt:  cfg.RecoverCorruptData,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if cfg.StoreDriver != store.DriverSQLite {
+		return s, nil
+	}
+
+	existing, err := s.Users()
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	board, err := s.Leaderboard()
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	if len(existing) > 0 || len(board) > 0 {
+		return s, nil
+	}
+	legacy, err := store.Open(store.Options{
+		Driver:          store.DriverJSON,
+		UsersFile:       cfg.UsersFile,
+		LeaderboardFile: cfg.LeaderboardFile,
+		LedgerFile:      cfg.LedgerFile,
+		AttemptsFile:    cfg.QuizAttemptsFile,
+	})
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	defer legacy.Close()
+	if err := store.Import(s, legacy); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("import json data: %w", err)
+	}
+	return s, nil
+}
+
 func loadSecretLessons(path string) ([]models.Lesson, error) {
 	if _, err := os.Stat(path); err != nil {
 		if os.IsNotExist(err) {
@@ -182,22 +345,62 @@ func loadSecretLessons(path string) ([]models.Lesson, error) {
 	return secretLessons, nil
 }
 
-func buildFetcherLessons(coreLessons []models.Lesson, secretLessons []models.Lesson) []lessons.Lesson {
-	localLessons := make([]lessons.Lesson, 0, len(coreLessons)+len(secretLessons))
+// buildFetcherLessons merges the file-based lessons. Markdown lessons are
+// local lessons too, so their IDs must not collide with lessons.json ones;
+// markdownIDCollisions catches that when they are loaded.
+func buildFetcherLessons(coreLessons, secretLessons, markdownLessons []models.Lesson) []lessons.Lesson {
+	localLessons := make([]lessons.Lesson, 0, len(coreLessons)+len(secretLessons)+len(markdownLessons))
 	localLessons = appendFetcherLessons(localLessons, coreLessons, "local")
-	return appendFetcherLessons(localLessons, secretLessons, "secret-knowledge")
+	localLessons = appendFetcherLessons(localLessons, secretLessons, "secret-knowledge")
+	return appendFetcherLessons(localLessons, markdownLessons, "local")
+}
+
+// fileLessonID is the ID a lesson from a lesson file gets: derived from its
+// id key when it has one and from its title otherwise.
+func fileLessonID(lesson models.Lesson, source string) string {
+	key := lesson.ID
+	if key == "" {
+		key = lesson.Title
+	}
+	return lessons.NewID(source, key)
+}
+
+// markdownIDCollisions returns markdown without the lessons whose ID is
+// already used by a lessons.json lesson, and an error naming them. The lesson
+// map keeps only the first lesson with an ID, so such a lesson would
+// otherwise vanish without a word.
+func markdownIDCollisions(core, markdown []models.Lesson) ([]models.Lesson, error) {
+	byID := make(map[string]string, len(core))
+	for _, l := range core {
+		byID[fileLessonID(l, "local")] = l.Title
+	}
+	var kept []models.Lesson
+	var errs []error
+	for _, l := range markdown {
+		id := fileLessonID(l, "local")
+		if title, dup := byID[id]; dup {
+			errs = append(errs, fmt.Errorf("markdown lesson %q: ID %s is already used by lessons.json lesson %q", l.Title, id, title))
+			continue
+		}
+		kept = append(kept, l)
+	}
+	return kept, errors.Join(errs...)
 }
 
 func appendFetcherLessons(dst []lessons.Lesson, src []models.Lesson, source string) []lessons.Lesson {
 	for _, lesson := range src {
 		dst = append(dst, lessons.Lesson{
-			Title:    lesson.Title,
-			Category: lesson.Category,
-			Text:     lesson.Text,
-			Explain:  lesson.Explain,
-			UseCases: lesson.UseCases,
-			Tips:     lesson.Tips,
-			Source:   source,
+			ID:         fileLessonID(lesson, source),
+			Title:      lesson.Title,
+			Category:   lesson.Category,
+			Text:       lesson.Text,
+			Explain:    lesson.Explain,
+			UseCases:   lesson.UseCases,
+			Tips:       lesson.Tips,
+			Source:     source,
+			Difficulty: lesson.Difficulty,
+			Tags:       lesson.Tags,
+			Questions:  lesson.Questions,
 		})
 	}
 	return dst
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+
+	"avidlearner/internal/config"
+	"avidlearner/internal/models"
+	"avidlearner/internal/store"
+)
+
+func TestOpenStoreImportsJSONDataOnce(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.Config{
+		StoreDriver:      store.DriverSQLite,
+		SQLitePath:       filepath.Join(dir, "avidlearner.db"),
+		UsersFile:        filepath.Join(dir, "users.json"),
+		LeaderboardFile:  filepath.Join(dir, "leaderboard.json"),
+		LedgerFile:       filepath.Join(dir, "ledger.jsonl"),
+		QuizAttemptsFile: filepath.Join(dir, "quiz_attempts.jsonl"),
+	}
+	legacy, err := store.Open(store.Options{Driver: store.DriverJSON, LeaderboardFile: cfg.LeaderboardFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := legacy.AddLeaderboardEntry(models.LeaderboardEntry{Name: "Ada", Score: 7, Mode: "quiz"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := legacy.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nobody has signed up, so the users table stays empty across restarts.
+	for start := 1; start <= 3; start++ {
+		s, err := openStore(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		board, err := s.Leaderboard()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if len(board) != 1 {
+			t.Fatalf("start %d: expected the leaderboard imported once, got %d entries", start, len(board))
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"avidlearner/internal/config"
+	"avidlearner/internal/lessons"
+	"avidlearner/internal/models"
+	"avidlearner/internal/routes"
+)
+
+// Names of the hot-reloaded content files, as shown on /api/admin/content.
+const (
+	contentLessons         = "lessons"
+	contentSecretLessons   = "secret_knowledge_lessons"
+	contentMarkdownLessons = "markdown_lessons"
+	contentProChallenges   = "pro_challenges"
+	contentCategories      = "categories"
+	contentTracks          = "tracks"
+)
+
+// watchedContent is one file (or directory) polled for changes. stamp is a
+// cheap size/mtime signature checked on every poll; version is the content
+// hash, compared only when the stamp moves.
+type watchedContent struct {
+	name    string
+	path    string
+	dir     bool
+	stamp   string
+	version string
+}
+
+// contentReloader polls the lesson and challenge files and swaps in new
+// versions. A file that fails to parse or validate keeps its previous
+// version live and the error is reported on the admin status endpoint.
+type contentReloader struct {
+	fetcher *lessons.Fetcher
+	files   []*watchedContent
+
+	// last good version of each lesson file, merged on every swap
+	core, secret, markdown []models.Lesson
+}
+
+// newContentReloader starts from the lessons already loaded; items holds the
+// item counts of the other content files by name, for the status endpoint.
+func newContentReloader(cfg config.Config, fetcher *lessons.Fetcher, core, secret, markdown []models.Lesson, items map[string]int) *contentReloader {
+	r := &contentReloader{
+		fetcher:  fetcher,
+		core:     core,
+		secret:   secret,
+		markdown: markdown,
+		files: []*watchedContent{
+			{name: contentLessons, path: cfg.LessonsFile},
+			{name: contentSecretLessons, path: cfg.SecretLessonsFile},
+			{name: contentMarkdownLessons, path: cfg.LessonsDir, dir: true},
+			{name: contentProChallenges, path: cfg.ProChallengesFile},
+			{name: contentCategories, path: cfg.CategoriesFile},
+			{name: contentTracks, path: cfg.TracksFile},
+		},
+	}
+	counts := map[string]int{
+		contentLessons:         len(core),
+		contentSecretLessons:   len(secret),
+		contentMarkdownLessons: len(markdown),
+	}
+	for name, n := range items {
+		counts[name] = n
+	}
+	for _, f := range r.files {
+		f.stamp, _ = contentStamp(f)
+		f.version, _ = contentVersion(f)
+		routes.ReportContentLoaded(f.name, f.path, f.version, counts[f.name])
+	}
+	return r
+}
+
+func startContentReloader(ctx context.Context, r *contentReloader, every time.Duration) {
+	go func() {
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.poll(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// poll reloads every file whose content changed since the last poll. A new
+// taxonomy rebuilds the lesson map so lessons are re-filed.
+func (r *contentReloader) poll(ctx context.Context) {
+	lessonsChanged, mapChanged := false, false
+	for _, f := range r.files {
+		stamp, err := contentStamp(f)
+		if err != nil {
+			// Report a vanished file once; the previous version stays live.
+			if f.stamp != "" {
+				f.stamp = ""
+				r.reject(f, err)
+			}
+			continue
+		}
+		if stamp == f.stamp {
+			continue
+		}
+		f.stamp = stamp
+		version, err := contentVersion(f)
+		if err != nil {
+			r.reject(f, err)
+			continue
+		}
+		if version == f.version {
+			continue
+		}
+		items, err := r.reload(f)
+		if err != nil {
+			r.reject(f, err)
+			continue
+		}
+		f.version = version
+		routes.ReportContentLoaded(f.name, f.path, version, items)
+		log.Printf("Reloaded %s from %s (%d items, version %s)", f.name, f.path, items, version)
+		switch f.name {
+		case contentProChallenges, contentTracks:
+		case contentCategories:
+			mapChanged = true
+		default:
+			lessonsChanged = true
+		}
+	}
+	if lessonsChanged {
+		r.fetcher.SetLocalLessons(buildFetcherLessons(r.core, r.secret, r.markdown))
+	}
+	if lessonsChanged || mapChanged {
+		routes.UpdateLessonMap(r.fetcher.GetLessons(ctx))
+	}
+	routes.ReportContentChecked(time.Now())
+}
+
+func (r *contentReloader) reject(f *watchedContent, err error) {
+	log.Printf("Warning: keeping previous %s, reload of %s failed: %v", f.name, f.path, err)
+	routes.ReportContentError(f.name, f.path, err)
+}
+
+// reload parses and validates f. Nothing is swapped unless it is valid.
+func (r *contentReloader) reload(f *watchedContent) (int, error) {
+	switch f.name {
+	case contentProChallenges:
+		list, byID, err := routes.LoadProChallenges(f.path)
+		if err != nil {
+			return 0, err
+		}
+		if err := validateProChallenges(list); err != nil {
+			return 0, err
+		}
+		routes.SetProChallenges(list, byID)
+		return len(list), nil
+	case contentTracks:
+		list, err := routes.LoadTracks(f.path)
+		if err != nil {
+			return 0, err
+		}
+		routes.SetTracks(list)
+		return len(list), nil
+	case contentCategories:
+		taxonomy, err := lessons.LoadTaxonomy(f.path)
+		if err != nil {
+			return 0, err
+		}
+		routes.SetTaxonomy(taxonomy)
+		return taxonomy.Len(), nil
+	case contentMarkdownLessons:
+		list, err := lessons.LoadMarkdownDir(f.path)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := markdownIDCollisions(r.core, list); err != nil {
+			return 0, err
+		}
+		r.markdown = list
+		return len(list), nil
+	default:
+		list, err := routes.LoadLessons(f.path)
+		if err != nil {
+			return 0, err
+		}
+		if err := validateLessons(list); err != nil {
+			return 0, err
+		}
+		if f.name == contentLessons {
+			if _, err := markdownIDCollisions(list, r.markdown); err != nil {
+				return 0, err
+			}
+			r.core = list
+		} else {
+			r.secret = list
+		}
+		return len(list), nil
+	}
+}
+
+func validateLessons(list []models.Lesson) error {
+	if len(list) == 0 {
+		return errors.New("file has no lessons")
+	}
+	ids := map[string]int{}
+	for i, l := range list {
+		switch {
+		case strings.TrimSpace(l.Title) == "":
+			return fmt.Errorf("lesson %d: title is required", i)
+		case strings.TrimSpace(l.Category) == "":
+			return fmt.Errorf("lesson %d (%s): category is required", i, l.Title)
+		case strings.TrimSpace(l.Text) == "":
+			return fmt.Errorf("lesson %d (%s): text is required", i, l.Title)
+		}
+		if err := lessons.ValidateQuestions(l.Questions); err != nil {
+			return fmt.Errorf("lesson %d (%s): %w", i, l.Title, err)
+		}
+		if l.ID == "" {
+			continue
+		}
+		if first, dup := ids[l.ID]; dup {
+			return fmt.Errorf("lesson %d (%s): id %q already used by lesson %d", i, l.Title, l.ID, first)
+		}
+		ids[l.ID] = i
+	}
+	return nil
+}
+
+func validateProChallenges(list []models.ProChallenge) error {
+	if len(list) == 0 {
+		return errors.New("file has no challenges")
+	}
+	ids := map[string]int{}
+	for i, ch := range list {
+		if strings.TrimSpace(ch.ID) == "" {
+			return fmt.Errorf("challenge %d: id is required", i)
+		}
+		if strings.TrimSpace(ch.Title) == "" {
+			return fmt.Errorf("challenge %s: title is required", ch.ID)
+		}
+		if first, dup := ids[ch.ID]; dup {
+			return fmt.Errorf("challenge %d: id %q already used by challenge %d", i, ch.ID, first)
+		}
+		ids[ch.ID] = i
+	}
+	return nil
+}
+
+// contentStamp is a size/mtime signature of a file, or of every Markdown
+// file in a directory.
+func contentStamp(f *watchedContent) (string, error) {
+	if !f.dir {
+		info, err := os.Stat(f.path)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d/%d", info.Size(), info.ModTime().UnixNano()), nil
+	}
+	var b strings.Builder
+	err := walkMarkdown(f.path, func(path string, info fs.FileInfo) error {
+		fmt.Fprintf(&b, "%s:%d/%d;", path, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	return b.String(), err
+}
+
+// contentVersion hashes the content of a file or Markdown directory.
+func contentVersion(f *watchedContent) (string, error) {
+	h := sha256.New()
+	if !f.dir {
+		b, err := os.ReadFile(f.path)
+		if err != nil {
+			return "", err
+		}
+		h.Write(b)
+	} else {
+		err := walkMarkdown(f.path, func(path string, _ fs.FileInfo) error {
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			h.Write([]byte(path))
+			h.Write([]byte{0})
+			h.Write(b)
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)[:6]), nil
+}
+
+// walkMarkdown visits the .md files under dir in a stable order. A missing
+// dir has no files.
+func walkMarkdown(dir string, fn func(path string, info fs.FileInfo) error) error {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".md") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if err := fn(path, info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"avidlearner/internal/config"
+	"avidlearner/internal/lessons"
+	"avidlearner/internal/routes"
+)
+
+func contentStatus(t *testing.T, name string) routes.ContentStatus {
+	t.Helper()
+	files, _ := routes.ContentStatuses()
+	for _, st := range files {
+		if st.Name == name {
+			return st
+		}
+	}
+	t.Fatalf("no status for %s", name)
+	return routes.ContentStatus{}
+}
+
+func TestContentReloaderSwapsValidEditsOnly(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.Config{
+		LessonsFile:       filepath.Join(dir, "lessons.json"),
+		SecretLessonsFile: filepath.Join(dir, "secret.json"),
+		LessonsDir:        filepath.Join(dir, "lessons"),
+		ProChallengesFile: filepath.Join(dir, "pro_challenges.json"),
+	}
+	write := func(path, body string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(cfg.LessonsFile, `[{"title":"Caching","category":"performance","text":"Cache hot reads."}]`)
+	write(cfg.ProChallengesFile, `[{"id":"lru","title":"LRU Cache"}]`)
+
+	core, err := routes.LoadLessons(cfg.LessonsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetcher := lessons.NewFetcher(buildFetcherLessons(core, nil, nil), time.Hour)
+	if err := fetcher.SetSources(nil); err != nil {
+		t.Fatal(err)
+	}
+	r := newContentReloader(cfg, fetcher, core, nil, nil, map[string]int{contentProChallenges: 1})
+	ctx := context.Background()
+	titles := func() map[string]bool {
+		got := map[string]bool{}
+		for _, l := range fetcher.GetLessons(ctx) {
+			got[l.Title] = true
+		}
+		return got
+	}
+
+	// A valid edit is swapped in.
+	write(cfg.LessonsFile, `[{"title":"Caching, fixed","category":"performance","text":"Cache hot reads."}]`)
+	write(cfg.ProChallengesFile, `[{"id":"lru","title":"LRU Cache"},{"id":"ring","title":"Ring Buffer"}]`)
+	r.poll(ctx)
+	if got := titles(); !got["Caching, fixed"] || got["Caching"] {
+		t.Fatalf("expected edited lesson to be live, got %v", got)
+	}
+	pro := contentStatus(t, contentProChallenges)
+	if pro.Items != 2 || pro.Error != "" {
+		t.Fatalf("expected reloaded challenges, got %+v", pro)
+	}
+	version := contentStatus(t, contentLessons).Version
+
+	// Broken JSON and a lesson without a category are rejected.
+	for _, body := range []string{`[{"title":`, `[{"title":"No category","text":"x"}]`} {
+		write(cfg.LessonsFile, body)
+		r.poll(ctx)
+		st := contentStatus(t, contentLessons)
+		if st.Error == "" || st.ErrorAt == nil || st.Version != version {
+			t.Fatalf("expected rejected reload to keep version %s, got %+v", version, st)
+		}
+		if got := titles(); !got["Caching, fixed"] {
+			t.Fatalf("previous lessons should stay live, got %v", got)
+		}
+	}
+
+	// Markdown lessons are picked up from the lessons directory.
+	if err := os.MkdirAll(cfg.LessonsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write(filepath.Join(cfg.LessonsDir, "outbox.md"), "---\ntitle: Outbox\ncategory: system-design\n---\nWrite events with the data.\n")
+	r.poll(ctx)
+	if got := titles(); !got["Outbox"] || !got["Caching, fixed"] {
+		t.Fatalf("expected Markdown lesson alongside the last good lessons, got %v", got)
+	}
+
+	// A Markdown lesson taking a lessons.json lesson's ID is refused rather
+	// than silently shadowed, and so is a lessons.json edit taking the ID of a
+	// Markdown lesson.
+	write(filepath.Join(cfg.LessonsDir, "caching.md"), "---\ntitle: Caching, Fixed\ncategory: performance\n---\nAnother take.\n")
+	r.poll(ctx)
+	if st := contentStatus(t, contentMarkdownLessons); !strings.Contains(st.Error, "already used by lessons.json") {
+		t.Fatalf("expected the ID collision reported, got %+v", st)
+	}
+	if err := os.Remove(filepath.Join(cfg.LessonsDir, "caching.md")); err != nil {
+		t.Fatal(err)
+	}
+	r.poll(ctx)
+	write(cfg.LessonsFile, `[{"title":"Caching, fixed","category":"performance","text":"Cache hot reads."},{"id":"outbox","title":"Relay","category":"system-design","text":"x"}]`)
+	r.poll(ctx)
+	if st := contentStatus(t, contentLessons); !strings.Contains(st.Error, `markdown lesson "Outbox"`) {
+		t.Fatalf("expected the ID collision reported, got %+v", st)
+	}
+	if got := titles(); got["Relay"] || !got["Outbox"] {
+		t.Fatalf("the colliding edit should not be live, got %v", got)
+	}
+}
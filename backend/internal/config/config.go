@@ -4,57 +4,113 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 )
 
 const (
-	defaultLessonFetchTTL          = 6 * time.Hour
-	defaultLessonMapRefreshDelay   = 15 * time.Second
-	defaultLessonMapRefreshEvery   = 10 * time.Minute
-	defaultLeaderboardSaveInterval = 5 * time.Minute
-	defaultUsersSaveInterval       = 5 * time.Minute
-	defaultAuthTokenTTL            = 7 * 24 * time.Hour
-	defaultShutdownTimeout         = 10 * time.Second
+	defaultLessonFetchTTL        = 6 * time.Hour
+	defaultLessonMapRefreshDelay = 15 * time.Second
+	defaultLessonMapRefreshEvery = 10 * time.Minute
+	defaultStoreFlushInterval    = 5 * time.Minute
+	defaultSessionIdleTTL        = 7 * 24 * time.Hour
+	defaultSessionMaxAge         = 30 * 24 * time.Hour
+	defaultSessionReapInterval   = 5 * time.Minute
+	defaultMaxSessions           = 50000
+	defaultAuthTokenTTL          = 7 * 24 * time.Hour
+	defaultShutdownTimeout       = 10 * time.Second
+	defaultContentReloadEvery    = 5 * time.Second
+	defaultGradingWorkers        = 2
+	defaultGradingQueueDepth     = 20
+	defaultGradingCacheMB        = 1024
 )
 
 type Config struct {
 	LessonsFile           string
+	LessonsDir            string
 	SecretLessonsFile     string
+	LessonSourcesFile     string
+	CategoriesFile        string
 	ProChallengesFile     string
+	TracksFile            string
 	LeaderboardFile       string
 	UsersFile             string
+	SessionsFile          string
+	LedgerFile            string
+	QuizAttemptsFile      string
+	StoreDriver           string
+	SQLitePath            string
+	RecoverCorruptData    bool
 	Port                  string
 	LessonFetchTTL        time.Duration
 	LessonMapRefreshDelay time.Duration
 	LessonMapRefreshEvery time.Duration
-	LeaderboardSaveEvery  time.Duration
-	UsersSaveEvery        time.Duration
+	StoreFlushEvery       time.Duration
+	SessionIdleTTL        time.Duration
+	SessionMaxAge         time.Duration
+	SessionReapEvery      time.Duration
+	MaxSessions           int
+	PersistSessions       bool
 	AuthSecret            string
 	AuthTokenTTL          time.Duration
 	ShutdownTimeout       time.Duration
+	ContentReloadEvery    time.Duration
+	AdminUsers            []string
+	SandboxMode           string
+	GradingWorkers        int
+	GradingQueueDepth     int
+	GradingCacheBytes     int64
 }
 
 func Load() Config {
 	cfg := Config{
 		LessonsFile:           envOrDefault("LESSONS_FILE", filepath.Join("..", "data", "lessons.json")),
+		LessonsDir:            envOrDefault("LESSONS_DIR", filepath.Join("..", "data", "lessons")),
 		SecretLessonsFile:     filepath.Join("..", "data", "secret_knowledge_lessons.json"),
+		CategoriesFile:        envOrDefault("CATEGORIES_FILE", filepath.Join("..", "data", "categories.json")),
+		LessonSourcesFile:     envOrDefault("LESSON_SOURCES_FILE", filepath.Join("..", "data", "lesson_sources.json")),
 		ProChallengesFile:     envOrDefault("PRO_CHALLENGES_FILE", filepath.Join("..", "data", "pro_challenges.json")),
+		TracksFile:            envOrDefault("TRACKS_FILE", filepath.Join("..", "data", "tracks.json")),
 		LeaderboardFile:       envOrDefault("LEADERBOARD_FILE", filepath.Join("..", "data", "leaderboard.json")),
 		UsersFile:             envOrDefault("USERS_FILE", filepath.Join("..", "data", "users.json")),
+		SessionsFile:          envOrDefault("SESSIONS_FILE", filepath.Join("..", "data", "sessions.json")),
+		LedgerFile:            envOrDefault("LEDGER_FILE", filepath.Join("..", "data", "ledger.jsonl")),
+		QuizAttemptsFile:      envOrDefault("QUIZ_ATTEMPTS_FILE", filepath.Join("..", "data", "quiz_attempts.jsonl")),
+		StoreDriver:           envOrDefault("STORE_DRIVER", "json"),
+		SQLitePath:            envOrDefault("SQLITE_PATH", filepath.Join("..", "data", "avidlearner.db")),
+		RecoverCorruptData:    envBool("RECOVER_CORRUPT_DATA"),
 		Port:                  envOrDefault("PORT", "8081"),
 		LessonFetchTTL:        defaultLessonFetchTTL,
 		LessonMapRefreshDelay: defaultLessonMapRefreshDelay,
 		LessonMapRefreshEvery: defaultLessonMapRefreshEvery,
-		LeaderboardSaveEvery:  defaultLeaderboardSaveInterval,
-		UsersSaveEvery:        defaultUsersSaveInterval,
+		StoreFlushEvery:       defaultStoreFlushInterval,
+		SessionIdleTTL:        envHoursOrDefault("SESSION_IDLE_TTL_HOURS", defaultSessionIdleTTL),
+		SessionMaxAge:         envHoursOrDefault("SESSION_MAX_AGE_HOURS", defaultSessionMaxAge),
+		SessionReapEvery:      defaultSessionReapInterval,
+		MaxSessions:           envIntOrDefault("MAX_SESSIONS", defaultMaxSessions),
+		PersistSessions:       envBool("PERSIST_SESSIONS"),
 		AuthSecret:            envOrDefault("JWT_SECRET", "dev-secret-change-me"),
 		AuthTokenTTL:          envHoursOrDefault("JWT_TTL_HOURS", defaultAuthTokenTTL),
 		ShutdownTimeout:       defaultShutdownTimeout,
+		ContentReloadEvery:    envSecondsOrDefault("CONTENT_RELOAD_SECONDS", defaultContentReloadEvery),
+		AdminUsers:            envList("ADMIN_USERS"),
+		SandboxMode:           envOrDefault("SANDBOX_MODE", "strict"),
+		GradingWorkers:        envIntOrDefault("GRADING_WORKERS", defaultGradingWorkers),
+		GradingQueueDepth:     envIntOrDefault("GRADING_QUEUE_DEPTH", defaultGradingQueueDepth),
+		GradingCacheBytes:     int64(envIntOrDefault("GRADING_CACHE_MB", defaultGradingCacheMB)) << 20,
 	}
 
+	cfg.LessonsDir = resolveFileFallback(cfg.LessonsDir, filepath.Join("data", "lessons"))
+	cfg.CategoriesFile = resolveFileFallback(cfg.CategoriesFile, filepath.Join("data", "categories.json"))
+	cfg.LessonSourcesFile = resolveFileFallback(cfg.LessonSourcesFile, filepath.Join("data", "lesson_sources.json"))
 	cfg.ProChallengesFile = resolveFileFallback(cfg.ProChallengesFile, filepath.Join("data", "pro_challenges.json"))
+	cfg.TracksFile = resolveFileFallback(cfg.TracksFile, filepath.Join("data", "tracks.json"))
 	cfg.LeaderboardFile = resolveDirFallback(cfg.LeaderboardFile, filepath.Join("data", "leaderboard.json"))
 	cfg.UsersFile = resolveDirFallback(cfg.UsersFile, filepath.Join("data", "users.json"))
+	cfg.SessionsFile = resolveDirFallback(cfg.SessionsFile, filepath.Join("data", "sessions.json"))
+	cfg.LedgerFile = resolveDirFallback(cfg.LedgerFile, filepath.Join("data", "ledger.jsonl"))
+	cfg.QuizAttemptsFile = resolveDirFallback(cfg.QuizAttemptsFile, filepath.Join("data", "quiz_attempts.jsonl"))
+	cfg.SQLitePath = resolveDirFallback(cfg.SQLitePath, filepath.Join("data", "avidlearner.db"))
 
 	return cfg
 }
@@ -66,6 +122,39 @@ func envOrDefault(key, fallback string) string {
 	return fallback
 }
 
+func envBool(key string) bool {
+	enabled, err := strconv.ParseBool(os.Getenv(key))
+	return err == nil && enabled
+}
+
+func envIntOrDefault(key string, fallback int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			return n
+		}
+	}
+	return fallback
+}
+
+func envSecondsOrDefault(key string, fallback time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return fallback
+}
+
+func envList(key string) []string {
+	var out []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
 func envHoursOrDefault(key string, fallback time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if hours, err := strconv.Atoi(value); err == nil && hours > 0 {
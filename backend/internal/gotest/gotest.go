@@ -0,0 +1,303 @@
+// Package gotest reads the results of grading a submission: the go test
+// -json event stream of its test run, turned into a tree of tests with their
+// subtests, durations and log output, and the compiler errors of a build
+// that failed.
+package gotest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"avidlearner/internal/models"
+)
+
+// event is one line of go test -json (cmd/test2json) output.
+type event struct {
+	Action     string
+	Test       string
+	Elapsed    float64
+	Output     string
+	OutputType string // "frame" marks go test's own lines on newer toolchains
+}
+
+type node struct {
+	name    string
+	status  string
+	elapsed float64
+	output  strings.Builder
+	subs    []*node
+}
+
+// Converter reads go test -json events written to it and builds a Report.
+// The text of the output events is copied to the live writer as it arrives,
+// so watchers see what go test -v would print. Lines that are not events,
+// such as test2json's own errors, are kept as output.
+//
+// The output and test names a Converter holds are bounded: once they would
+// go over its limit, nothing more is kept and the Report is marked
+// truncated. A line longer than the limit is dropped without being held.
+type Converter struct {
+	live      io.Writer
+	max       int
+	held      int  // bytes of output and test names kept
+	skip      bool // dropping the rest of an overlong line
+	truncated bool
+	buf       []byte
+	tests     map[string]*node
+	top       []*node
+	output    strings.Builder
+	result    string
+}
+
+// NewConverter returns a Converter copying output to live, which may be nil,
+// and keeping at most max bytes for the Report; max <= 0 means no limit.
+func NewConverter(live io.Writer, max int) *Converter {
+	return &Converter{live: live, max: max, tests: map[string]*node{}}
+}
+
+func (c *Converter) Write(p []byte) (int, error) {
+	c.buf = append(c.buf, p...)
+	for {
+		i := bytes.IndexByte(c.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if c.skip {
+			c.skip = false
+		} else {
+			c.line(c.buf[:i])
+		}
+		c.buf = c.buf[i+1:]
+	}
+	if c.max > 0 && len(c.buf) > c.max {
+		c.buf = c.buf[:0]
+		c.skip = true
+		c.truncated = true
+	}
+	return len(p), nil
+}
+
+// hold reports whether n more bytes fit under the limit, counting them when
+// they do. After the first that do not, nothing is held, so the output is
+// cut off at one place rather than left with gaps.
+func (c *Converter) hold(n int) bool {
+	if c.truncated || c.max > 0 && c.held+n > c.max {
+		c.truncated = true
+		return false
+	}
+	c.held += n
+	return true
+}
+
+func (c *Converter) line(b []byte) {
+	var e event
+	if err := json.Unmarshal(b, &e); err != nil || e.Action == "" {
+		c.text(nil, string(b)+"\n", false)
+		return
+	}
+	if e.Test == "" {
+		switch e.Action {
+		case "output":
+			c.text(nil, e.Output, false)
+		case "pass", "fail", "skip":
+			c.result = e.Action
+		}
+		return
+	}
+	n := c.node(e.Test)
+	if n == nil {
+		// A test first seen past the limit is not kept, but its output is
+		// still copied to live.
+		if e.Action == "output" {
+			c.text(nil, e.Output, false)
+		}
+		return
+	}
+	switch e.Action {
+	case "output":
+		c.text(n, e.Output, e.OutputType == "frame" || e.OutputType == "" && framing(e.Output))
+	case "pass", "fail", "skip":
+		n.status = e.Action
+		n.elapsed = e.Elapsed
+	}
+}
+
+// text records output, and for n its log lines: everything but the
+// "=== RUN" and "--- PASS" frame lines go test prints around them.
+func (c *Converter) text(n *node, s string, frame bool) {
+	if c.live != nil {
+		_, _ = io.WriteString(c.live, s)
+	}
+	logged := n != nil && !frame
+	size := len(s)
+	if logged {
+		size *= 2
+	}
+	if !c.hold(size) {
+		return
+	}
+	c.output.WriteString(s)
+	if logged {
+		n.output.WriteString(s)
+	}
+}
+
+// framing recognizes frame lines from toolchains that do not mark them.
+func framing(s string) bool {
+	s = strings.TrimLeft(s, " ")
+	for _, prefix := range []string{"=== RUN", "=== PAUSE", "=== CONT", "=== NAME", "--- PASS:", "--- FAIL:", "--- SKIP:"} {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// node returns the test named name, adding it under its parent: the longest
+// known prefix ending before a slash, since subtest names may hold slashes
+// themselves. It returns nil for a new test past the limit.
+func (c *Converter) node(name string) *node {
+	if n, ok := c.tests[name]; ok {
+		return n
+	}
+	if !c.hold(len(name)) {
+		return nil
+	}
+	n := &node{name: name}
+	c.tests[name] = n
+	for i := strings.LastIndexByte(name, '/'); i > 0; i = strings.LastIndexByte(name[:i], '/') {
+		if parent, ok := c.tests[name[:i]]; ok {
+			parent.subs = append(parent.subs, n)
+			return n
+		}
+	}
+	c.top = append(c.top, n)
+	return n
+}
+
+// Report is one test run.
+type Report struct {
+	Tests  []models.TestCase // top-level tests, in the order they started
+	Output string            // all output, as go test -v prints it
+	// Result is the package's result, pass or fail, or empty when the run
+	// ended without one, as when the binary was killed.
+	Result string
+	// Truncated is set when the run printed more than the Converter keeps:
+	// Output ends with a note saying so, and tests first seen after the
+	// limit are missing from Tests.
+	Truncated bool
+}
+
+// Report ends the event stream and returns what it held.
+func (c *Converter) Report() Report {
+	if len(c.buf) > 0 && !c.skip {
+		c.line(c.buf)
+	}
+	c.buf = nil
+	r := Report{Output: c.output.String(), Result: c.result, Truncated: c.truncated}
+	if r.Truncated {
+		r.Output += "\n... output truncated\n"
+	}
+	for _, n := range c.top {
+		r.Tests = append(r.Tests, n.testCase())
+	}
+	return r
+}
+
+func (n *node) testCase() models.TestCase {
+	tc := models.TestCase{
+		Name:    n.name,
+		Status:  n.status,
+		Elapsed: n.elapsed,
+		Output:  strings.TrimRight(n.output.String(), " \n"),
+	}
+	if tc.Status == "" {
+		tc.Status = "fail"
+	}
+	for _, sub := range n.subs {
+		tc.Subtests = append(tc.Subtests, sub.testCase())
+	}
+	return tc
+}
+
+// Total counts the tests and subtests without subtests of their own, so a
+// table test counts once per case rather than once more for itself.
+func (r Report) Total() int {
+	return countLeaves(r.Tests)
+}
+
+func countLeaves(tests []models.TestCase) int {
+	n := 0
+	for _, tc := range tests {
+		if len(tc.Subtests) == 0 {
+			n++
+		} else {
+			n += countLeaves(tc.Subtests)
+		}
+	}
+	return n
+}
+
+// Failures lists the tests a failure started in: failed tests none of whose
+// subtests failed. A parent failing only because a subtest did is left out.
+func (r Report) Failures() []models.TestFailure {
+	var failures []models.TestFailure
+	var walk func([]models.TestCase) bool
+	walk = func(tests []models.TestCase) bool {
+		failed := false
+		for _, tc := range tests {
+			if tc.Status != "fail" {
+				continue
+			}
+			failed = true
+			if walk(tc.Subtests) {
+				continue
+			}
+			out := tc.Output
+			if out == "" {
+				out = "--- FAIL: " + tc.Name
+			}
+			failures = append(failures, models.TestFailure{Name: tc.Name, Output: out})
+		}
+		return failed
+	}
+	walk(r.Tests)
+	return failures
+}
+
+// diagnosticLine matches a compiler error: file.go:line[:column]: message.
+var diagnosticLine = regexp.MustCompile(`^(\S+\.go):(\d+)(?::(\d+))?: (.+)$`)
+
+// Diagnostics reads the compiler errors from the output of a failed go
+// build or go test -c run in dir. Paths are made relative to dir, so an
+// error in the submission is reported against challenge.go; indented
+// continuation lines are folded into the error above them.
+func Diagnostics(stderr, dir string) []models.BuildDiagnostic {
+	var diags []models.BuildDiagnostic
+	for _, line := range strings.Split(stderr, "\n") {
+		if m := diagnosticLine.FindStringSubmatch(line); m != nil {
+			file := m[1]
+			if rel, err := filepath.Rel(dir, file); err == nil && filepath.IsAbs(file) && !strings.HasPrefix(rel, "..") {
+				file = rel
+			}
+			lineNo, _ := strconv.Atoi(m[2])
+			col, _ := strconv.Atoi(m[3])
+			diags = append(diags, models.BuildDiagnostic{
+				File:    strings.TrimPrefix(file, "./"),
+				Line:    lineNo,
+				Column:  col,
+				Message: m[4],
+			})
+			continue
+		}
+		if strings.HasPrefix(line, "\t") && len(diags) > 0 {
+			diags[len(diags)-1].Message += "\n" + strings.TrimSpace(line)
+		}
+	}
+	return diags
+}
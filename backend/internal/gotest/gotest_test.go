@@ -0,0 +1,155 @@
+package gotest
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"avidlearner/internal/models"
+)
+
+// events is go test -json output for a table test with a failing case and a
+// case whose name holds a slash, a test that logs and a skipped test.
+const events = `{"Action":"start","Package":"example.com/protmp"}
+{"Action":"run","Package":"example.com/protmp","Test":"TestTable"}
+{"Action":"output","Package":"example.com/protmp","Test":"TestTable","Output":"=== RUN   TestTable\n","OutputType":"frame"}
+{"Action":"run","Package":"example.com/protmp","Test":"TestTable/ok"}
+{"Action":"output","Package":"example.com/protmp","Test":"TestTable/ok","Output":"=== RUN   TestTable/ok\n","OutputType":"frame"}
+{"Action":"output","Package":"example.com/protmp","Test":"TestTable/ok","Output":"--- PASS: TestTable/ok (0.00s)\n","OutputType":"frame"}
+{"Action":"pass","Package":"example.com/protmp","Test":"TestTable/ok","Elapsed":0}
+{"Action":"run","Package":"example.com/protmp","Test":"TestTable/bad"}
+{"Action":"output","Package":"example.com/protmp","Test":"TestTable/bad","Output":"=== RUN   TestTable/bad\n","OutputType":"frame"}
+{"Action":"output","Package":"example.com/protmp","Test":"TestTable/bad","Output":"    challenge_test.go:9: got \"bad\"\n","OutputType":"error"}
+{"Action":"output","Package":"example.com/protmp","Test":"TestTable/bad","Output":"--- FAIL: TestTable/bad (0.01s)\n","OutputType":"frame"}
+{"Action":"fail","Package":"example.com/protmp","Test":"TestTable/bad","Elapsed":0.01}
+{"Action":"run","Package":"example.com/protmp","Test":"TestTable/a/b"}
+{"Action":"output","Package":"example.com/protmp","Test":"TestTable/a/b","Output":"=== RUN   TestTable/a/b\n","OutputType":"frame"}
+{"Action":"pass","Package":"example.com/protmp","Test":"TestTable/a/b","Elapsed":0}
+{"Action":"output","Package":"example.com/protmp","Test":"TestTable","Output":"--- FAIL: TestTable (0.01s)\n","OutputType":"frame"}
+{"Action":"fail","Package":"example.com/protmp","Test":"TestTable","Elapsed":0.01}
+{"Action":"run","Package":"example.com/protmp","Test":"TestLog"}
+{"Action":"output","Package":"example.com/protmp","Test":"TestLog","Output":"=== RUN   TestLog\n"}
+{"Action":"output","Package":"example.com/protmp","Test":"TestLog","Output":"    challenge_test.go:15: hello\n"}
+{"Action":"output","Package":"example.com/protmp","Test":"TestLog","Output":"--- PASS: TestLog (0.25s)\n"}
+{"Action":"pass","Package":"example.com/protmp","Test":"TestLog","Elapsed":0.25}
+{"Action":"run","Package":"example.com/protmp","Test":"TestSkip"}
+{"Action":"skip","Package":"example.com/protmp","Test":"TestSkip","Elapsed":0}
+{"Action":"output","Package":"example.com/protmp","Output":"FAIL\n","OutputType":"frame"}
+{"Action":"fail","Package":"example.com/protmp","Elapsed":0.3}
+`
+
+func convert(t *testing.T, input string, live io.Writer) Report {
+	t.Helper()
+	c := NewConverter(live, 0)
+	// Write in small pieces: events arrive split across reads.
+	for len(input) > 0 {
+		n := min(7, len(input))
+		if _, err := c.Write([]byte(input[:n])); err != nil {
+			t.Fatal(err)
+		}
+		input = input[n:]
+	}
+	return c.Report()
+}
+
+func TestConverterBuildsTestTree(t *testing.T) {
+	var live strings.Builder
+	r := convert(t, events, &live)
+
+	want := []models.TestCase{
+		{Name: "TestTable", Status: "fail", Elapsed: 0.01, Subtests: []models.TestCase{
+			{Name: "TestTable/ok", Status: "pass"},
+			{Name: "TestTable/bad", Status: "fail", Elapsed: 0.01, Output: `    challenge_test.go:9: got "bad"`},
+			{Name: "TestTable/a/b", Status: "pass"},
+		}},
+		{Name: "TestLog", Status: "pass", Elapsed: 0.25, Output: "    challenge_test.go:15: hello"},
+		{Name: "TestSkip", Status: "skip"},
+	}
+	if !reflect.DeepEqual(r.Tests, want) {
+		t.Fatalf("unexpected tree:\n got %+v\nwant %+v", r.Tests, want)
+	}
+	if r.Result != "fail" || r.Total() != 5 {
+		t.Fatalf("expected a failed package of 5 tests, got %q with %d", r.Result, r.Total())
+	}
+	failures := r.Failures()
+	if len(failures) != 1 || failures[0].Name != "TestTable/bad" || !strings.Contains(failures[0].Output, `got "bad"`) {
+		t.Fatalf("expected only the failing case, got %+v", failures)
+	}
+	if !strings.Contains(r.Output, "=== RUN   TestTable/bad\n") || r.Output != live.String() {
+		t.Fatalf("expected the text output, copied live, got %q", r.Output)
+	}
+}
+
+func TestConverterKilledRun(t *testing.T) {
+	input := `{"Action":"run","Test":"TestSpin"}
+{"Action":"output","Test":"TestSpin","Output":"=== RUN   TestSpin\n"}
+test2json: signal: killed`
+	r := convert(t, input, nil)
+	if r.Result != "" || len(r.Tests) != 1 || r.Tests[0].Status != "fail" {
+		t.Fatalf("expected an unfinished test to count as failed, got %+v", r)
+	}
+	if !strings.HasSuffix(r.Output, "test2json: signal: killed\n") {
+		t.Fatalf("expected lines that are not events kept as output, got %q", r.Output)
+	}
+	if f := r.Failures(); len(f) != 1 || f[0].Output != "--- FAIL: TestSpin" {
+		t.Fatalf("unexpected failures %+v", f)
+	}
+}
+
+func TestConverterKeepsAtMostMax(t *testing.T) {
+	var b strings.Builder
+	b.WriteString(`{"Action":"run","Test":"TestFlood"}` + "\n")
+	for range 1000 {
+		b.WriteString(`{"Action":"output","Test":"TestFlood","Output":"spam spam spam spam\n"}` + "\n")
+	}
+	// A test started after the limit, and a line with no end in sight.
+	b.WriteString(`{"Action":"run","Test":"TestLate"}` + "\n")
+	b.WriteString(strings.Repeat("x", 4096) + "\n")
+	b.WriteString(`{"Action":"fail","Test":"TestFlood","Elapsed":1}` + "\n")
+	b.WriteString(`{"Action":"fail","Elapsed":1}` + "\n")
+	b.WriteString(strings.Repeat("y", 4096))
+
+	const max = 1024
+	var live strings.Builder
+	c := NewConverter(&live, max)
+	if _, err := c.Write([]byte(b.String())); err != nil {
+		t.Fatal(err)
+	}
+	r := c.Report()
+	if !r.Truncated || !strings.HasSuffix(r.Output, "... output truncated\n") {
+		t.Fatalf("expected a truncated report, got %q", r.Output)
+	}
+	held := len(r.Output)
+	for _, tc := range r.Tests {
+		held += len(tc.Name) + len(tc.Output)
+	}
+	if held > max+len("\n... output truncated\n") {
+		t.Fatalf("kept %d bytes, more than %d", held, max)
+	}
+	if len(r.Tests) != 1 || r.Tests[0].Status != "fail" || r.Result != "fail" {
+		t.Fatalf("expected results after the limit still recorded, got %+v (%q)", r.Tests, r.Result)
+	}
+	if strings.Count(live.String(), "spam spam spam spam\n") != 1000 {
+		t.Fatalf("expected all event output copied live")
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	stderr := `# example.com/protmp [example.com/protmp.test]
+./challenge.go:2:23: cannot use "x" (untyped string constant) as int value in return statement
+/tmp/avid-pro-1/challenge.go:3:9: undefined: undefinedThing
+./challenge_test.go:12: too many arguments in call to Normalize
+	have (string, int)
+	want (string)
+`
+	got := Diagnostics(stderr, "/tmp/avid-pro-1")
+	want := []models.BuildDiagnostic{
+		{File: "challenge.go", Line: 2, Column: 23, Message: `cannot use "x" (untyped string constant) as int value in return statement`},
+		{File: "challenge.go", Line: 3, Column: 9, Message: "undefined: undefinedThing"},
+		{File: "challenge_test.go", Line: 12, Message: "too many arguments in call to Normalize\nhave (string, int)\nwant (string)"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected diagnostics:\n got %+v\nwant %+v", got, want)
+	}
+}
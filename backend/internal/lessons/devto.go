@@ -0,0 +1,156 @@
+package lessons
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	devToArticlesURL = "https://dev.to/api/articles"
+	devToTagDelay    = 500 * time.Millisecond
+)
+
+// devToSource pulls top articles for a set of Dev.to tags.
+type devToSource struct {
+	name    string
+	baseURL string
+	tags    []string
+	perTag  int
+	client  *http.Client
+}
+
+func newDevToSource(cfg SourceConfig, client *http.Client) (Source, error) {
+	if len(cfg.Tags) == 0 {
+		return nil, errors.New("at least one tag is required")
+	}
+	base := cfg.URL
+	if base == "" {
+		base = devToArticlesURL
+	}
+	perTag := cfg.PerTag
+	if perTag <= 0 {
+		perTag = 10
+	}
+	return &devToSource{name: cfg.Name, baseURL: base, tags: cfg.Tags, perTag: perTag, client: client}, nil
+}
+
+func (s *devToSource) Name() string { return s.name }
+
+// Fetch requests each tag in turn and returns the articles as one JSON array.
+// A failing tag is logged and skipped.
+func (s *devToSource) Fetch(ctx context.Context) ([]byte, error) {
+	var all []DevToArticle
+	for i, tag := range s.tags {
+		if i > 0 {
+			// Rate limiting - be nice to Dev.to
+			select {
+			case <-time.After(devToTagDelay):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		u := fmt.Sprintf("%s?tag=%s&per_page=%d&top=7", s.baseURL, url.QueryEscape(tag), s.perTag)
+		body, err := fetchRaw(ctx, s.client, u, "dev.to")
+		if err != nil {
+			log.Printf("Error fetching Dev.to tag %s: %v", tag, err)
+			continue
+		}
+		var articles []DevToArticle
+		if err := json.Unmarshal(body, &articles); err != nil {
+			log.Printf("Error decoding Dev.to tag %s: %v", tag, err)
+			continue
+		}
+		all = append(all, articles...)
+	}
+	return json.Marshal(all)
+}
+
+func (s *devToSource) Parse(raw []byte) ([]Lesson, error) {
+	var articles []DevToArticle
+	if err := json.Unmarshal(raw, &articles); err != nil {
+		return nil, err
+	}
+	lessons := make([]Lesson, 0, len(articles))
+	for _, article := range articles {
+		key := article.URL
+		if key == "" {
+			key = article.Title
+		}
+		lessons = append(lessons, Lesson{
+			ID:       NewID(s.name, key),
+			Title:    article.Title,
+			Category: categorizeDevToArticle(article.Tags),
+			Text:     truncate(article.Description, 200),
+			Explain:  fmt.Sprintf("Read more at: %s", article.URL),
+			UseCases: extractUseCases(article.Description),
+			Tips:     []string{"Check the full article for details", "Consider practical applications"},
+			Source:   s.name,
+		})
+	}
+	return lessons, nil
+}
+
+// DevToArticle represents a Dev.to article response
+type DevToArticle struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	URL         string   `json:"url"`
+	Tags        []string `json:"tags"`
+}
+
+// categorizeDevToArticle determines category from tags
+func categorizeDevToArticle(tags []string) string {
+	for _, tag := range tags {
+		switch strings.ToLower(tag) {
+		case "architecture", "systemdesign", "microservices":
+			return "system-design"
+		case "database", "sql", "nosql":
+			return "databases"
+		case "api", "rest", "graphql":
+			return "apis"
+		case "cloud", "aws", "azure", "kubernetes":
+			return "cloud"
+		case "security":
+			return "security"
+		}
+	}
+	return "general"
+}
+
+// extractUseCases tries to find use cases in description
+func extractUseCases(description string) []string {
+	// Simple extraction - look for bullet points or numbered lists
+	useCases := []string{}
+	lines := strings.Split(description, "\n")
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "-") || strings.HasPrefix(trimmed, "*") || strings.HasPrefix(trimmed, "•") {
+			useCase := strings.TrimSpace(strings.TrimLeft(trimmed, "-*•"))
+			if len(useCase) > 0 && len(useCase) < 100 {
+				useCases = append(useCases, useCase)
+			}
+		}
+	}
+
+	if len(useCases) == 0 {
+		useCases = []string{"General software engineering", "System architecture"}
+	}
+
+	return useCases
+}
+
+// truncate cuts string to maxLen with ellipsis
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen-3] + "..."
+}
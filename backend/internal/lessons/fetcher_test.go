@@ -2,6 +2,7 @@ package lessons
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 )
@@ -161,7 +162,7 @@ This is a navigation section that should be skipped.
 How to contribute to this project.
 `
 
-	lessons := parseGitHubMarkdown(markdown)
+	lessons := parseGitHubMarkdown(markdown, "github")
 
 	// Should extract Database Sharding and Load Balancer, skip Index and Contributing
 	if len(lessons) < 2 {
@@ -226,3 +227,19 @@ func TestCacheRefresh(t *testing.T) {
 		t.Errorf("Expected at least %d lessons after refresh, got %d", initialCount, len(lessons2))
 	}
 }
+
+func TestNewID(t *testing.T) {
+	id := NewID("local", "Circuit Breaker")
+	if id != NewID("local", "  circuit   BREAKER ") {
+		t.Error("expected IDs to ignore case and whitespace")
+	}
+	if id == NewID("devto", "Circuit Breaker") {
+		t.Error("expected different sources to yield different IDs")
+	}
+	if NewID("", "Circuit Breaker") != id {
+		t.Error("expected empty source to default to local")
+	}
+	if !strings.HasPrefix(id, "local-") {
+		t.Errorf("expected ID prefixed with its source, got %q", id)
+	}
+}
@@ -0,0 +1,307 @@
+package lessons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"avidlearner/internal/httpx"
+)
+
+// githubMarkdownSource turns the "## " sections of a markdown README (by
+// default the System Design Primer) into lessons.
+type githubMarkdownSource struct {
+	name   string
+	url    string
+	client *http.Client
+}
+
+func newGitHubMarkdownSource(cfg SourceConfig, client *http.Client) (Source, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("url is required")
+	}
+	return &githubMarkdownSource{name: cfg.Name, url: cfg.URL, client: client}, nil
+}
+
+func (s *githubMarkdownSource) Name() string { return s.name }
+
+func (s *githubMarkdownSource) Fetch(ctx context.Context) ([]byte, error) {
+	return fetchRaw(ctx, s.client, s.url, "github")
+}
+
+func (s *githubMarkdownSource) Parse(raw []byte) ([]Lesson, error) {
+	return parseGitHubMarkdown(string(raw), s.name), nil
+}
+
+// secretKnowledgeSource parses the tool lists of the-book-of-secret-knowledge.
+type secretKnowledgeSource struct {
+	name   string
+	url    string
+	client *http.Client
+}
+
+func newSecretKnowledgeSource(cfg SourceConfig, client *http.Client) (Source, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("url is required")
+	}
+	return &secretKnowledgeSource{name: cfg.Name, url: cfg.URL, client: client}, nil
+}
+
+func (s *secretKnowledgeSource) Name() string { return s.name }
+
+func (s *secretKnowledgeSource) Fetch(ctx context.Context) ([]byte, error) {
+	return fetchRaw(ctx, s.client, s.url, "github")
+}
+
+func (s *secretKnowledgeSource) Parse(raw []byte) ([]Lesson, error) {
+	return parseSecretKnowledgeMarkdown(string(raw), s.name), nil
+}
+
+// fetchRaw GETs url with retries and returns the body.
+func fetchRaw(ctx context.Context, client *http.Client, url, host string) ([]byte, error) {
+	resp, err := httpx.DoWithRetry(ctx, client, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", url, nil)
+	}, func(status int, _ []byte) error {
+		return fmt.Errorf("%s returned status %d", host, status)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// parseGitHubMarkdown extracts lessons from markdown content
+func parseGitHubMarkdown(markdown, source string) []Lesson {
+	var lessons []Lesson
+
+	// Find sections with ## headers
+	sectionRegex := regexp.MustCompile(`(?m)^##\s+(.+)$`)
+	matches := sectionRegex.FindAllStringSubmatchIndex(markdown, -1)
+
+	for i, match := range matches {
+		titleStart, titleEnd := match[2], match[3]
+		title := strings.TrimSpace(markdown[titleStart:titleEnd])
+
+		// Skip navigation sections
+		lowerTitle := strings.ToLower(title)
+		if strings.Contains(lowerTitle, "index") ||
+			strings.Contains(lowerTitle, "contribut") ||
+			strings.Contains(lowerTitle, "credit") ||
+			strings.Contains(lowerTitle, "license") {
+			continue
+		}
+
+		// Get content until next section
+		contentStart := match[1]
+		contentEnd := len(markdown)
+		if i+1 < len(matches) {
+			contentEnd = matches[i+1][0]
+		}
+
+		content := strings.TrimSpace(markdown[contentStart:contentEnd])
+
+		// Extract first paragraph as summary
+		paragraphs := strings.Split(content, "\n\n")
+		summary := ""
+		explain := ""
+
+		for _, p := range paragraphs {
+			cleaned := strings.TrimSpace(p)
+			if cleaned != "" && !strings.HasPrefix(cleaned, "#") && !strings.HasPrefix(cleaned, "<") {
+				if summary == "" {
+					summary = cleaned
+					if len(summary) > 200 {
+						summary = summary[:197] + "..."
+					}
+				} else if explain == "" {
+					explain = cleaned
+					if len(explain) > 300 {
+						explain = explain[:297] + "..."
+					}
+					break
+				}
+			}
+		}
+
+		if summary == "" {
+			continue
+		}
+
+		lessons = append(lessons, Lesson{
+			ID:       NewID(source, title),
+			Title:    title,
+			Category: "system-design",
+			Text:     summary,
+			Explain:  explain,
+			UseCases: []string{"Distributed systems", "Scalable architectures"},
+			Tips:     []string{"Review trade-offs", "Consider CAP theorem"},
+			Source:   source,
+		})
+	}
+
+	return lessons
+}
+
+// parseSecretKnowledgeMarkdown extracts curated lessons from the-book-of-secret-knowledge
+func parseSecretKnowledgeMarkdown(markdown, source string) []Lesson {
+	var lessons []Lesson
+
+	// Categories we want to extract from the book
+	categoryMap := map[string]struct {
+		keywords []string
+		category string
+		tips     []string
+	}{
+		"CLI Tools": {
+			keywords: []string{"command", "terminal", "shell", "cli"},
+			category: "devops",
+			tips:     []string{"Practice in a safe environment", "Read man pages", "Use --help flag"},
+		},
+		"Web Tools": {
+			keywords: []string{"browser", "security", "ssl", "http"},
+			category: "security",
+			tips:     []string{"Bookmark useful tools", "Understand HTTPS/TLS", "Check multiple sources"},
+		},
+		"Security": {
+			keywords: []string{"penetration", "vulnerability", "encryption"},
+			category: "security",
+			tips:     []string{"Stay ethical", "Get permission before testing", "Keep tools updated"},
+		},
+		"System Diagnostics": {
+			keywords: []string{"debug", "monitor", "performance", "troubleshoot"},
+			category: "devops",
+			tips:     []string{"Monitor proactively", "Establish baselines", "Use multiple metrics"},
+		},
+		"Network": {
+			keywords: []string{"network", "dns", "http", "tcp"},
+			category: "networking",
+			tips:     []string{"Understand OSI model", "Use tcpdump/wireshark", "Check DNS first"},
+		},
+		"Databases": {
+			keywords: []string{"database", "sql", "nosql", "query"},
+			category: "databases",
+			tips:     []string{"Index wisely", "EXPLAIN queries", "Monitor slow queries"},
+		},
+	}
+
+	// Find main sections (####)
+	sectionRegex := regexp.MustCompile(`(?m)^####\s+(.+?)(?:\s+&nbsp;)?\s*\[`)
+	matches := sectionRegex.FindAllStringSubmatchIndex(markdown, -1)
+
+	for i, match := range matches {
+		titleStart, titleEnd := match[2], match[3]
+		title := strings.TrimSpace(markdown[titleStart:titleEnd])
+
+		// Get content until next section
+		contentStart := match[1]
+		contentEnd := len(markdown)
+		if i+1 < len(matches) {
+			contentEnd = matches[i+1][0]
+		}
+
+		content := markdown[contentStart:contentEnd]
+
+		// Extract tools/resources from the section
+		toolRegex := regexp.MustCompile(`<a href="([^"]+)"><b>([^<]+)</b></a>\s*-\s*([^<\n]+)`)
+		toolMatches := toolRegex.FindAllStringSubmatch(content, 15) // Limit to 15 per section
+
+		// Determine category for this section
+		categoryInfo := struct {
+			keywords []string
+			category string
+			tips     []string
+		}{
+			keywords: []string{},
+			category: "general",
+			tips:     []string{"Research before using", "Check documentation", "Start with basics"},
+		}
+
+		for key, info := range categoryMap {
+			if strings.Contains(title, key) {
+				categoryInfo = info
+				break
+			}
+		}
+
+		// Create lessons from tools
+		for idx, toolMatch := range toolMatches {
+			if idx >= 10 { // Max 10 lessons per section to avoid overwhelming
+				break
+			}
+
+			url := toolMatch[1]
+			name := toolMatch[2]
+			description := strings.TrimSpace(toolMatch[3])
+
+			// Clean description
+			if len(description) > 150 {
+				description = description[:147] + "..."
+			}
+
+			// Create use cases based on description
+			useCases := extractUseCasesFromDescription(description)
+			if len(useCases) == 0 {
+				useCases = []string{
+					fmt.Sprintf("Learn %s", categoryInfo.category),
+					"Improve technical skills",
+				}
+			}
+
+			lesson := Lesson{
+				ID:       NewID(source, name),
+				Title:    name,
+				Category: categoryInfo.category,
+				Text:     description,
+				Explain:  fmt.Sprintf("From The Book of Secret Knowledge: %s. Learn more at %s", title, url),
+				UseCases: useCases,
+				Tips:     categoryInfo.tips,
+				Source:   source,
+			}
+
+			lessons = append(lessons, lesson)
+		}
+	}
+
+	log.Printf("Parsed %d lessons from Book of Secret Knowledge", len(lessons))
+	return lessons
+}
+
+// extractUseCasesFromDescription attempts to extract use cases from description text
+func extractUseCasesFromDescription(description string) []string {
+	var useCases []string
+	lowerDesc := strings.ToLower(description)
+
+	// Common patterns
+	patterns := map[string][]string{
+		"security":    {"Security testing", "Vulnerability assessment", "Penetration testing"},
+		"monitor":     {"System monitoring", "Performance tracking", "Resource management"},
+		"debug":       {"Debugging", "Troubleshooting", "Error analysis"},
+		"test":        {"Testing", "Quality assurance", "Validation"},
+		"network":     {"Network analysis", "Traffic monitoring", "Connectivity troubleshooting"},
+		"database":    {"Database management", "Query optimization", "Data analysis"},
+		"deployment":  {"CI/CD", "Deployment automation", "Release management"},
+		"container":   {"Container orchestration", "Microservices", "Cloud native apps"},
+		"performance": {"Performance optimization", "Benchmarking", "Load testing"},
+		"encrypt":     {"Data encryption", "Secure communication", "Privacy protection"},
+	}
+
+	for keyword, cases := range patterns {
+		if strings.Contains(lowerDesc, keyword) {
+			useCases = append(useCases, cases...)
+			break
+		}
+	}
+
+	// Limit to 3 use cases
+	if len(useCases) > 3 {
+		useCases = useCases[:3]
+	}
+
+	return useCases
+}
@@ -0,0 +1,20 @@
+package lessons
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// NewID derives a stable lesson ID from the lesson's source and a canonical
+// key: an explicit id from the lesson file, the article URL for Dev.to, or
+// the title otherwise. Keys are compared case- and whitespace-insensitively,
+// so the same lesson always maps to the same ID across restarts.
+func NewID(source, key string) string {
+	if source == "" {
+		source = "local"
+	}
+	canonical := strings.Join(strings.Fields(strings.ToLower(key)), " ")
+	sum := sha256.Sum256([]byte(source + "\x00" + canonical))
+	return source + "-" + hex.EncodeToString(sum[:6])
+}
@@ -0,0 +1,308 @@
+package lessons
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"avidlearner/internal/models"
+)
+
+// Difficulty levels accepted in lesson front matter.
+var difficulties = map[string]bool{"beginner": true, "intermediate": true, "advanced": true}
+
+// FileError is a problem with one lesson file, positioned at a line.
+type FileError struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e FileError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+// FileErrors collects every problem found while loading a lesson directory.
+type FileErrors []FileError
+
+func (errs FileErrors) Error() string {
+	lines := make([]string, len(errs))
+	for i, e := range errs {
+		lines[i] = e.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// frontMatter is the YAML header of a Markdown lesson.
+type frontMatter struct {
+	ID         string            `yaml:"id"`
+	Title      string            `yaml:"title"`
+	Category   string            `yaml:"category"`
+	Difficulty string            `yaml:"difficulty"`
+	Tags       []string          `yaml:"tags"`
+	UseCases   []string          `yaml:"useCases"`
+	Tips       []string          `yaml:"tips"`
+	Questions  []models.Question `yaml:"questions"`
+}
+
+// LoadMarkdownDir reads every .md file under dir as a lesson. A file starts
+// with YAML front matter between "---" lines; the first paragraph of the body
+// becomes the lesson text and the rest its explanation:
+//
+//	---
+//	title: Outbox Pattern
+//	category: system-design
+//	difficulty: intermediate
+//	tags: [messaging, consistency]
+//	useCases:
+//	  - Publishing events after a DB write
+//	tips:
+//	  - Poll or tail the outbox table
+//	questions:
+//	  - type: true-false
+//	    prompt: The relay can publish an event twice.
+//	    options:
+//	      - {text: "True", correct: true, explain: Consumers must dedupe.}
+//	      - {text: "False"}
+//	---
+//	The outbox pattern writes events in the same transaction as the data.
+//
+//	A relay later publishes them to the broker...
+//
+// Invalid files are skipped and reported in the returned FileErrors; lessons
+// from the valid files are still returned. A missing dir yields no lessons.
+func LoadMarkdownDir(dir string) ([]models.Lesson, error) {
+	list, err := LoadMarkdownLessons(dir)
+	out := make([]models.Lesson, 0, len(list))
+	for _, ml := range list {
+		out = append(out, ml.Lesson)
+	}
+	if len(out) == 0 {
+		out = nil
+	}
+	return out, err
+}
+
+// MarkdownLesson is a lesson with the file it came from and the line of its
+// title, for tools that report positions.
+type MarkdownLesson struct {
+	models.Lesson
+	File string
+	Line int
+}
+
+// LoadMarkdownLessons is LoadMarkdownDir keeping each lesson's position.
+func LoadMarkdownLessons(dir string) ([]MarkdownLesson, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".md") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var (
+		out     []MarkdownLesson
+		errs    FileErrors
+		byTitle = map[string]string{}
+	)
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		lesson, titleLine, fileErrs := parseMarkdownLesson(path, b)
+		if len(fileErrs) > 0 {
+			errs = append(errs, fileErrs...)
+			continue
+		}
+		key := strings.ToLower(lesson.Title)
+		if first, dup := byTitle[key]; dup {
+			errs = append(errs, FileError{path, titleLine, fmt.Sprintf("duplicate title %q (first defined in %s)", lesson.Title, first)})
+			continue
+		}
+		byTitle[key] = path
+		out = append(out, MarkdownLesson{Lesson: lesson, File: path, Line: titleLine})
+	}
+	if len(errs) > 0 {
+		return out, errs
+	}
+	return out, nil
+}
+
+// parseMarkdownLesson parses and validates one file. It returns the line of
+// the title key so callers can point at it.
+func parseMarkdownLesson(path string, b []byte) (models.Lesson, int, FileErrors) {
+	fail := func(line int, format string, args ...any) (models.Lesson, int, FileErrors) {
+		return models.Lesson{}, 0, FileErrors{{path, line, fmt.Sprintf(format, args...)}}
+	}
+
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+	lines := strings.Split(strings.ReplaceAll(string(b), "\r\n", "\n"), "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return fail(1, "missing front matter: file must start with ---")
+	}
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return fail(1, "front matter is not closed with ---")
+	}
+
+	// Front matter starts on line 2, so YAML line n is file line n+1.
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(strings.Join(lines[1:end], "\n")), &doc); err != nil {
+		return fail(yamlErrorLine(err)+1, "invalid front matter: %s", yamlErrorMessage(err))
+	}
+	if len(doc.Content) == 0 {
+		return fail(2, "front matter is empty")
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fail(root.Line+1, "front matter must be a mapping of fields")
+	}
+
+	var (
+		fm        frontMatter
+		errs      FileErrors
+		keyLines  = map[string]int{}
+		fieldsPtr = map[string]any{
+			"id":         &fm.ID,
+			"title":      &fm.Title,
+			"category":   &fm.Category,
+			"difficulty": &fm.Difficulty,
+			"tags":       &fm.Tags,
+			"useCases":   &fm.UseCases,
+			"tips":       &fm.Tips,
+			"questions":  &fm.Questions,
+		}
+	)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		k, v := root.Content[i], root.Content[i+1]
+		line := k.Line + 1
+		target, known := fieldsPtr[k.Value]
+		switch {
+		case !known:
+			errs = append(errs, FileError{path, line, fmt.Sprintf("unknown field %q", k.Value)})
+			continue
+		case keyLines[k.Value] != 0:
+			errs = append(errs, FileError{path, line, fmt.Sprintf("duplicate field %q (first on line %d)", k.Value, keyLines[k.Value])})
+			continue
+		}
+		keyLines[k.Value] = line
+		if err := v.Decode(target); err != nil {
+			errs = append(errs, FileError{path, v.Line + 1, fmt.Sprintf("%s: %s", k.Value, yamlErrorMessage(err))})
+		}
+	}
+
+	fieldLine := func(name string) int {
+		if l := keyLines[name]; l != 0 {
+			return l
+		}
+		return 1
+	}
+	fm.Title = strings.TrimSpace(fm.Title)
+	fm.Category = strings.TrimSpace(fm.Category)
+	if fm.Title == "" {
+		errs = append(errs, FileError{path, fieldLine("title"), "title is required"})
+	}
+	if fm.Category == "" {
+		errs = append(errs, FileError{path, fieldLine("category"), "category is required"})
+	}
+	fm.Difficulty = strings.ToLower(strings.TrimSpace(fm.Difficulty))
+	if fm.Difficulty != "" && !difficulties[fm.Difficulty] {
+		errs = append(errs, FileError{path, fieldLine("difficulty"), fmt.Sprintf("difficulty %q must be beginner, intermediate or advanced", fm.Difficulty)})
+	}
+
+	if err := ValidateQuestions(fm.Questions); err != nil {
+		errs = append(errs, FileError{path, fieldLine("questions"), err.Error()})
+	}
+
+	text, explain := splitLessonBody(lines[end+1:])
+	if text == "" {
+		errs = append(errs, FileError{path, end + 2, "lesson body is empty"})
+	}
+	if len(errs) > 0 {
+		return models.Lesson{}, 0, errs
+	}
+
+	return models.Lesson{
+		ID:         fm.ID,
+		Title:      fm.Title,
+		Category:   fm.Category,
+		Text:       text,
+		Explain:    explain,
+		UseCases:   fm.UseCases,
+		Tips:       fm.Tips,
+		Difficulty: fm.Difficulty,
+		Tags:       fm.Tags,
+		Questions:  fm.Questions,
+	}, keyLines["title"], nil
+}
+
+// splitLessonBody returns the first paragraph and everything after it.
+func splitLessonBody(lines []string) (text, explain string) {
+	body := strings.TrimSpace(strings.Join(lines, "\n"))
+	if body == "" {
+		return "", ""
+	}
+	first, rest, _ := strings.Cut(body, "\n\n")
+	return joinLines(first), strings.TrimSpace(rest)
+}
+
+// joinLines unwraps a hard-wrapped Markdown paragraph into one line.
+func joinLines(paragraph string) string {
+	return strings.Join(strings.Fields(paragraph), " ")
+}
+
+// yamlErrorLine pulls the 1-based line out of a yaml.v3 error, defaulting to 1.
+func yamlErrorLine(err error) int {
+	var line int
+	if _, scanErr := fmt.Sscanf(yamlErrorText(err), "line %d:", &line); scanErr == nil && line > 0 {
+		return line
+	}
+	return 1
+}
+
+// yamlErrorMessage is the error text without yaml.v3's own line prefixes,
+// which count from the start of the front matter rather than the file.
+func yamlErrorMessage(err error) string {
+	parts := strings.Split(yamlErrorText(err), "\n")
+	for i, p := range parts {
+		var line int
+		if _, scanErr := fmt.Sscanf(p, "line %d:", &line); scanErr == nil {
+			_, p, _ = strings.Cut(p, ":")
+		}
+		parts[i] = strings.TrimSpace(p)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func yamlErrorText(err error) string {
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) && len(typeErr.Errors) > 0 {
+		return strings.Join(typeErr.Errors, "\n")
+	}
+	return strings.TrimPrefix(err.Error(), "yaml: ")
+}
@@ -0,0 +1,131 @@
+package lessons
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeLessonFile(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadMarkdownDir(t *testing.T) {
+	dir := t.TempDir()
+	writeLessonFile(t, dir, "outbox.md", `---
+title: Transactional Outbox
+category: system-design
+difficulty: Intermediate
+tags: [messaging]
+useCases:
+  - Publishing events
+tips:
+  - Make consumers idempotent
+questions:
+  - type: true-false
+    prompt: The relay may publish an event twice.
+    options:
+      - {text: "True", correct: true, explain: Consumers must dedupe.}
+      - {text: "False"}
+---
+Write events in the same
+transaction as the data.
+
+A relay publishes them later.
+`)
+	writeLessonFile(t, dir, "nested/ignored.txt", "not a lesson")
+
+	got, err := LoadMarkdownDir(dir)
+	if err != nil {
+		t.Fatalf("LoadMarkdownDir: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected 1 lesson, got %d", len(got))
+	}
+	l := got[0]
+	if l.Title != "Transactional Outbox" || l.Category != "system-design" || l.Difficulty != "intermediate" {
+		t.Errorf("unexpected front matter mapping: %+v", l)
+	}
+	if l.Text != "Write events in the same transaction as the data." {
+		t.Errorf("unexpected text %q", l.Text)
+	}
+	if l.Explain != "A relay publishes them later." {
+		t.Errorf("unexpected explain %q", l.Explain)
+	}
+	if len(l.Tags) != 1 || len(l.UseCases) != 1 || len(l.Tips) != 1 {
+		t.Errorf("expected lists from front matter, got %+v", l)
+	}
+	if len(l.Questions) != 1 || QuestionType(l.Questions[0]) != "true-false" || !l.Questions[0].Options[0].Correct {
+		t.Errorf("expected authored question from front matter, got %+v", l.Questions)
+	}
+
+	if got, err := LoadMarkdownDir(filepath.Join(dir, "missing")); err != nil || got != nil {
+		t.Fatalf("missing dir should yield nothing, got %v, %v", got, err)
+	}
+}
+
+func TestLoadMarkdownDirReportsFileLine(t *testing.T) {
+	dir := t.TempDir()
+	valid := writeLessonFile(t, dir, "a.md", "---\ntitle: Caching\ncategory: performance\n---\nCache hot reads.\n")
+	cases := []struct {
+		name string
+		body string
+		line int
+		msg  string
+	}{
+		{"b-unknown.md", "---\ntitle: A\ncategory: x\ncolour: red\n---\nBody\n", 4, `unknown field "colour"`},
+		{"c-missing.md", "---\ntitle: B\n---\nBody\n", 1, "category is required"},
+		{"d-type.md", "---\ntitle: C\ncategory: x\ntips:\n  nested: map\n---\nBody\n", 5, "tips:"},
+		{"e-difficulty.md", "---\ntitle: D\ncategory: x\n\ndifficulty: expert\n---\nBody\n", 5, "difficulty"},
+		{"f-body.md", "---\ntitle: E\ncategory: x\n---\n\n", 5, "body is empty"},
+		{"g-nofront.md", "# Title\n", 1, "missing front matter"},
+		{"h-syntax.md", "---\ntitle: F\ncategory: x: y\n---\nBody\n", 3, "invalid front matter"},
+		{"j-question.md", "---\ntitle: G\ncategory: x\nquestions:\n  - prompt: Pick\n    options: [{text: a}, {text: b}]\n---\nBody\n", 4, "question 0: multiple-choice needs exactly 1 correct option"},
+		{"i-dup.md", "---\ncategory: y\ntitle: caching\n---\nBody\n", 3, "duplicate title"},
+	}
+	for _, c := range cases {
+		writeLessonFile(t, dir, c.name, c.body)
+	}
+
+	got, err := LoadMarkdownDir(dir)
+	var fileErrs FileErrors
+	if !errors.As(err, &fileErrs) {
+		t.Fatalf("expected FileErrors, got %v", err)
+	}
+	if len(got) != 1 || got[0].Title != "Caching" {
+		t.Fatalf("valid files should still load, got %+v", got)
+	}
+
+	byFile := map[string]FileError{}
+	for _, e := range fileErrs {
+		if _, seen := byFile[e.File]; !seen {
+			byFile[e.File] = e
+		}
+		if e.File == valid {
+			t.Errorf("valid file reported: %v", e)
+		}
+	}
+	for _, c := range cases {
+		e, ok := byFile[filepath.Join(dir, c.name)]
+		if !ok {
+			t.Errorf("%s: no error reported", c.name)
+			continue
+		}
+		if e.Line != c.line || !strings.Contains(e.Msg, c.msg) {
+			t.Errorf("%s: got %v, want line %d containing %q", c.name, e, c.line, c.msg)
+		}
+	}
+	if !strings.Contains(err.Error(), filepath.Join(dir, "b-unknown.md")+":4: ") {
+		t.Errorf("error text should use file:line, got %q", err.Error())
+	}
+}
@@ -0,0 +1,83 @@
+package lessons
+
+import (
+	"fmt"
+	"strings"
+
+	"avidlearner/internal/models"
+)
+
+// QuestionType is q's type in lower case, with an empty type read as
+// multiple choice.
+func QuestionType(q models.Question) string {
+	t := strings.ToLower(strings.TrimSpace(q.Type))
+	if t == "" {
+		return models.QuestionMultipleChoice
+	}
+	return t
+}
+
+// ValidateQuestion checks that q is gradable as its type:
+//
+//   - multiple-choice: two or more options, exactly one correct
+//   - multi-select: two or more options, at least one correct
+//   - true-false: two options, exactly one correct
+//   - ordering: two or more options in the right order, none marked correct
+//   - fill-blank: at least one accepted answer; options are wrong answers
+func ValidateQuestion(q models.Question) error {
+	if strings.TrimSpace(q.Prompt) == "" {
+		return fmt.Errorf("prompt is required")
+	}
+	correct := 0
+	for i, o := range q.Options {
+		if strings.TrimSpace(o.Text) == "" {
+			return fmt.Errorf("option %d: text is required", i)
+		}
+		if o.Correct {
+			correct++
+		}
+	}
+	switch t := QuestionType(q); t {
+	case models.QuestionMultipleChoice, models.QuestionMultiSelect, models.QuestionOrdering:
+		if len(q.Options) < 2 {
+			return fmt.Errorf("%s needs at least 2 options", t)
+		}
+		switch {
+		case t == models.QuestionMultipleChoice && correct != 1:
+			return fmt.Errorf("multiple-choice needs exactly 1 correct option, has %d", correct)
+		case t == models.QuestionMultiSelect && correct == 0:
+			return fmt.Errorf("multi-select needs at least 1 correct option")
+		case t == models.QuestionOrdering && correct > 0:
+			return fmt.Errorf("ordering options are listed in order and must not be marked correct")
+		}
+	case models.QuestionTrueFalse:
+		if len(q.Options) != 2 || correct != 1 {
+			return fmt.Errorf("true-false needs 2 options with exactly 1 correct")
+		}
+	case models.QuestionFillBlank:
+		if len(q.Answers) == 0 {
+			return fmt.Errorf("fill-blank needs at least 1 accepted answer")
+		}
+		for _, a := range q.Answers {
+			if strings.TrimSpace(a) == "" {
+				return fmt.Errorf("fill-blank answers must not be empty")
+			}
+		}
+		if correct > 0 {
+			return fmt.Errorf("fill-blank options are wrong answers and must not be marked correct")
+		}
+	default:
+		return fmt.Errorf("unknown type %q", q.Type)
+	}
+	return nil
+}
+
+// ValidateQuestions checks every question of a lesson.
+func ValidateQuestions(qs []models.Question) error {
+	for i, q := range qs {
+		if err := ValidateQuestion(q); err != nil {
+			return fmt.Errorf("question %d: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package lessons
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultSourceTimeout = 30 * time.Second
+
+// Source is one external lesson feed. Fetch downloads the raw payload and
+// Parse turns it into lessons; keeping them apart lets parsers be tested
+// against fixtures without the network.
+type Source interface {
+	Name() string
+	Fetch(ctx context.Context) ([]byte, error)
+	Parse(raw []byte) ([]Lesson, error)
+}
+
+// SourceConfig describes one entry of the lesson sources file. Name becomes
+// every lesson's Source and is part of its ID, so renaming a source changes
+// the IDs of its lessons.
+type SourceConfig struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	URL        string   `json:"url,omitempty"`
+	Tags       []string `json:"tags,omitempty"`       // devto: tags to pull
+	PerTag     int      `json:"perTag,omitempty"`     // devto: articles per tag
+	Category   string   `json:"category,omitempty"`   // overrides the parser's category
+	Timeout    Duration `json:"timeout,omitempty"`    // whole fetch, default 30s
+	MaxLessons int      `json:"maxLessons,omitempty"` // 0 means no cap
+	Disabled   bool     `json:"disabled,omitempty"`
+}
+
+// Duration is a time.Duration written as a string such as "20s" in JSON.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"20s\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	if v < 0 {
+		return fmt.Errorf("duration %q is negative", s)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// SourceFactory builds a Source of one type from its config.
+type SourceFactory func(cfg SourceConfig, client *http.Client) (Source, error)
+
+var sourceTypes = map[string]SourceFactory{
+	"github-markdown":  newGitHubMarkdownSource,
+	"secret-knowledge": newSecretKnowledgeSource,
+	"devto":            newDevToSource,
+}
+
+// RegisterSourceType makes a new source type available to the sources file.
+// It is meant to be called during start-up, before any Fetcher is built.
+func RegisterSourceType(typ string, factory SourceFactory) {
+	sourceTypes[typ] = factory
+}
+
+// DefaultSourceConfigs reproduces the built-in feeds; it is used when no
+// sources file exists.
+func DefaultSourceConfigs() []SourceConfig {
+	return []SourceConfig{
+		{
+			Name:     "github",
+			Type:     "github-markdown",
+			URL:      "https://raw.githubusercontent.com/donnemartin/system-design-primer/master/README.md",
+			Category: "system-design",
+			Timeout:  Duration(defaultSourceTimeout),
+		},
+		{
+			Name:    "secret-knowledge",
+			Type:    "secret-knowledge",
+			URL:     "https://raw.githubusercontent.com/trimstray/the-book-of-secret-knowledge/master/README.md",
+			Timeout: Duration(defaultSourceTimeout),
+		},
+		{
+			Name:    "devto",
+			Type:    "devto",
+			Tags:    []string{"architecture", "systemdesign", "designpatterns"},
+			PerTag:  10,
+			Timeout: Duration(defaultSourceTimeout),
+		},
+	}
+}
+
+type sourcesFile struct {
+	Sources []SourceConfig `json:"sources"`
+}
+
+// LoadSourceConfig reads the lesson sources file. A missing file yields the
+// defaults; an invalid one is an error so a typo never silently drops feeds.
+func LoadSourceConfig(path string) ([]SourceConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return DefaultSourceConfigs(), nil
+		}
+		return nil, err
+	}
+	var file sourcesFile
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, err
+	}
+	if err := validateSourceConfigs(file.Sources); err != nil {
+		return nil, err
+	}
+	return file.Sources, nil
+}
+
+func validateSourceConfigs(cfgs []SourceConfig) error {
+	seen := map[string]bool{}
+	for i, cfg := range cfgs {
+		if strings.TrimSpace(cfg.Name) == "" {
+			return fmt.Errorf("source %d: name is required", i)
+		}
+		if seen[cfg.Name] {
+			return fmt.Errorf("source %q: duplicate name", cfg.Name)
+		}
+		seen[cfg.Name] = true
+		if _, ok := sourceTypes[cfg.Type]; !ok {
+			return fmt.Errorf("source %q: unknown type %q (known: %s)", cfg.Name, cfg.Type, strings.Join(sourceTypeNames(), ", "))
+		}
+		if cfg.MaxLessons < 0 {
+			return fmt.Errorf("source %q: maxLessons must not be negative", cfg.Name)
+		}
+	}
+	return nil
+}
+
+func sourceTypeNames() []string {
+	names := make([]string, 0, len(sourceTypes))
+	for name := range sourceTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// configuredSource pairs a Source with the limits from its config entry.
+type configuredSource struct {
+	Source
+	timeout    time.Duration
+	maxLessons int
+	category   string
+}
+
+// buildSources instantiates every enabled entry.
+func buildSources(cfgs []SourceConfig, client *http.Client) ([]configuredSource, error) {
+	if err := validateSourceConfigs(cfgs); err != nil {
+		return nil, err
+	}
+	var out []configuredSource
+	for _, cfg := range cfgs {
+		if cfg.Disabled {
+			continue
+		}
+		src, err := sourceTypes[cfg.Type](cfg, client)
+		if err != nil {
+			return nil, fmt.Errorf("source %q: %w", cfg.Name, err)
+		}
+		timeout := time.Duration(cfg.Timeout)
+		if timeout <= 0 {
+			timeout = defaultSourceTimeout
+		}
+		out = append(out, configuredSource{
+			Source:     src,
+			timeout:    timeout,
+			maxLessons: cfg.MaxLessons,
+			category:   cfg.Category,
+		})
+	}
+	return out, nil
+}
+
+// load fetches and parses the source within its timeout and applies the
+// configured category and cap.
+func (s configuredSource) load(ctx context.Context) ([]Lesson, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	raw, err := s.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lessons, err := s.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if s.maxLessons > 0 && len(lessons) > s.maxLessons {
+		lessons = lessons[:s.maxLessons]
+	}
+	if s.category != "" {
+		for i := range lessons {
+			lessons[i].Category = s.category
+		}
+	}
+	return lessons, nil
+}
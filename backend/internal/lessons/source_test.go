@@ -0,0 +1,141 @@
+package lessons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSource serves a fixed number of lessons, or blocks until its context
+// expires when fail is set.
+type fakeSource struct {
+	name string
+	n    int
+	fail *bool
+}
+
+func (s *fakeSource) Name() string { return s.name }
+
+func (s *fakeSource) Fetch(ctx context.Context) ([]byte, error) {
+	if s.fail != nil && *s.fail {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return []byte(fmt.Sprint(s.n)), nil
+}
+
+func (s *fakeSource) Parse(raw []byte) ([]Lesson, error) {
+	var lessons []Lesson
+	for i := range s.n {
+		title := fmt.Sprintf("%s %d", s.name, i)
+		lessons = append(lessons, Lesson{ID: NewID(s.name, title), Title: title, Category: "general", Source: s.name})
+	}
+	return lessons, nil
+}
+
+func TestLoadSourceConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	cfgs, err := LoadSourceConfig(filepath.Join(dir, "missing.json"))
+	if err != nil {
+		t.Fatalf("missing file: %v", err)
+	}
+	if len(cfgs) != len(DefaultSourceConfigs()) {
+		t.Fatalf("expected defaults for a missing file, got %+v", cfgs)
+	}
+
+	path := filepath.Join(dir, "sources.json")
+	write := func(body string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{"sources":[
+		{"name":"primer","type":"github-markdown","url":"https://example.com/README.md","category":"architecture","timeout":"5s","maxLessons":20},
+		{"name":"devto","type":"devto","tags":["go"],"disabled":true}
+	]}`)
+	cfgs, err = LoadSourceConfig(path)
+	if err != nil {
+		t.Fatalf("LoadSourceConfig: %v", err)
+	}
+	if len(cfgs) != 2 || time.Duration(cfgs[0].Timeout) != 5*time.Second || cfgs[0].MaxLessons != 20 || !cfgs[1].Disabled {
+		t.Fatalf("unexpected configs: %+v", cfgs)
+	}
+
+	for name, body := range map[string]string{
+		"unknown type":  `{"sources":[{"name":"x","type":"rss"}]}`,
+		"unknown field": `{"sources":[{"name":"x","type":"devto","tags":["go"],"limit":3}]}`,
+		"bad timeout":   `{"sources":[{"name":"x","type":"devto","tags":["go"],"timeout":"soon"}]}`,
+		"duplicate":     `{"sources":[{"name":"x","type":"devto","tags":["go"]},{"name":"x","type":"devto","tags":["go"]}]}`,
+	} {
+		write(body)
+		if _, err := LoadSourceConfig(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestRefreshCacheAppliesSourceConfig(t *testing.T) {
+	failing := false
+	RegisterSourceType("fake", func(cfg SourceConfig, _ *http.Client) (Source, error) {
+		if cfg.URL == "" {
+			return nil, errors.New("url is required")
+		}
+		n := len(cfg.URL)
+		if strings.HasPrefix(cfg.URL, "flaky") {
+			return &fakeSource{name: cfg.Name, n: n, fail: &failing}, nil
+		}
+		return &fakeSource{name: cfg.Name, n: n}, nil
+	})
+	t.Cleanup(func() { delete(sourceTypes, "fake") })
+
+	f := NewFetcher(nil, time.Hour)
+	err := f.SetSources([]SourceConfig{
+		{Name: "capped", Type: "fake", URL: "xxxxxxxx", MaxLessons: 3, Category: "databases"},
+		{Name: "off", Type: "fake", URL: "xxxx", Disabled: true},
+		{Name: "flaky", Type: "fake", URL: "flaky", Timeout: Duration(20 * time.Millisecond)},
+	})
+	if err != nil {
+		t.Fatalf("SetSources: %v", err)
+	}
+
+	count := func() map[string]int {
+		f.refreshCache(context.Background())
+		got := map[string]int{}
+		for _, l := range f.GetLessons(context.Background()) {
+			got[l.Source]++
+			if l.Source == "capped" && l.Category != "databases" {
+				t.Errorf("expected re-tagged category, got %q", l.Category)
+			}
+		}
+		return got
+	}
+
+	got := count()
+	if got["capped"] != 3 || got["off"] != 0 || got["flaky"] != 5 {
+		t.Fatalf("unexpected lessons per source: %v", got)
+	}
+
+	// A source that times out keeps the lessons from its last good fetch.
+	failing = true
+	start := time.Now()
+	got = count()
+	if got["flaky"] != 5 {
+		t.Fatalf("expected previous batch to survive a failed fetch, got %v", got)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("per-source timeout not applied, refresh took %v", elapsed)
+	}
+
+	if err := f.SetSources([]SourceConfig{{Name: "bad", Type: "fake"}}); err == nil {
+		t.Fatal("expected an error from a source that fails to build")
+	}
+}
@@ -0,0 +1,212 @@
+package lessons
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Category is one entry of the category taxonomy file. Lessons filed under
+// an alias are moved to Name; Parent nests the category in the tree.
+type Category struct {
+	Name        string   `json:"name"`
+	Label       string   `json:"label,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Icon        string   `json:"icon,omitempty"`
+	Parent      string   `json:"parent,omitempty"`
+	Aliases     []string `json:"aliases,omitempty"`
+}
+
+// CategoryNode is a category in the tree served by /api/categories. Count is
+// the number of lessons filed directly under it and Total includes every
+// descendant.
+type CategoryNode struct {
+	Name        string         `json:"name"`
+	Label       string         `json:"label"`
+	Description string         `json:"description,omitempty"`
+	Icon        string         `json:"icon,omitempty"`
+	Aliases     []string       `json:"aliases,omitempty"`
+	Count       int            `json:"count"`
+	Total       int            `json:"total"`
+	Children    []CategoryNode `json:"children,omitempty"`
+}
+
+// Taxonomy is the set of canonical categories. A nil *Taxonomy is valid and
+// leaves every category as it is.
+type Taxonomy struct {
+	categories []Category
+	byName     map[string]int    // name -> index in categories
+	aliases    map[string]string // alias -> name
+}
+
+type taxonomyFile struct {
+	Categories []Category `json:"categories"`
+}
+
+// LoadTaxonomy reads a taxonomy file. A missing file yields a nil taxonomy.
+func LoadTaxonomy(path string) (*Taxonomy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var file taxonomyFile
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, err
+	}
+	return NewTaxonomy(file.Categories)
+}
+
+// NewTaxonomy validates cats and builds a taxonomy from them. Names and
+// aliases are matched case-insensitively and must be unique across both.
+func NewTaxonomy(cats []Category) (*Taxonomy, error) {
+	t := &Taxonomy{
+		byName:  make(map[string]int, len(cats)),
+		aliases: map[string]string{},
+	}
+	for i, c := range cats {
+		c.Name = categoryKey(c.Name)
+		c.Parent = categoryKey(c.Parent)
+		if c.Name == "" {
+			return nil, fmt.Errorf("category %d: name is required", i)
+		}
+		if _, dup := t.byName[c.Name]; dup {
+			return nil, fmt.Errorf("category %q: duplicate name", c.Name)
+		}
+		t.byName[c.Name] = len(t.categories)
+		t.categories = append(t.categories, c)
+	}
+	for _, c := range t.categories {
+		for _, alias := range c.Aliases {
+			alias = categoryKey(alias)
+			if alias == "" {
+				return nil, fmt.Errorf("category %q: empty alias", c.Name)
+			}
+			if _, clash := t.byName[alias]; clash {
+				return nil, fmt.Errorf("category %q: alias %q is already a category name", c.Name, alias)
+			}
+			if other, clash := t.aliases[alias]; clash {
+				return nil, fmt.Errorf("category %q: alias %q already used by %q", c.Name, alias, other)
+			}
+			t.aliases[alias] = c.Name
+		}
+		if c.Parent == "" {
+			continue
+		}
+		if _, ok := t.byName[c.Parent]; !ok {
+			return nil, fmt.Errorf("category %q: unknown parent %q", c.Name, c.Parent)
+		}
+	}
+	for _, c := range t.categories {
+		seen := map[string]bool{c.Name: true}
+		for p := c.Parent; p != ""; p = t.categories[t.byName[p]].Parent {
+			if seen[p] {
+				return nil, fmt.Errorf("category %q: parent cycle through %q", c.Name, p)
+			}
+			seen[p] = true
+		}
+	}
+	return t, nil
+}
+
+func categoryKey(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// Len is the number of canonical categories.
+func (t *Taxonomy) Len() int {
+	if t == nil {
+		return 0
+	}
+	return len(t.categories)
+}
+
+// Canonical maps a category or one of its aliases to the canonical name.
+// Categories the taxonomy does not know are returned unchanged.
+func (t *Taxonomy) Canonical(category string) string {
+	canonical, _, _ := t.Known(category)
+	return canonical
+}
+
+// Known reports whether category is a canonical name, and if not, the
+// canonical name it is an alias of.
+func (t *Taxonomy) Known(category string) (canonical string, isName, isAlias bool) {
+	if t == nil {
+		return category, false, false
+	}
+	key := categoryKey(category)
+	if _, ok := t.byName[key]; ok {
+		return key, true, false
+	}
+	if name, ok := t.aliases[key]; ok {
+		return name, false, true
+	}
+	return category, false, false
+}
+
+// Tree arranges the taxonomy into parent/child nodes in file order, with
+// lesson counts from counts (keyed by canonical name). Categories in counts
+// that the taxonomy does not define are appended as top-level nodes in name
+// order.
+func (t *Taxonomy) Tree(counts map[string]int) []CategoryNode {
+	var cats []Category
+	if t != nil {
+		cats = t.categories
+	}
+	defined := make(map[string]bool, len(cats))
+	children := map[string][]Category{}
+	var roots []Category
+	for _, c := range cats {
+		defined[c.Name] = true
+		if c.Parent == "" {
+			roots = append(roots, c)
+		} else {
+			children[c.Parent] = append(children[c.Parent], c)
+		}
+	}
+	var extra []string
+	for name := range counts {
+		if !defined[name] {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		roots = append(roots, Category{Name: name})
+	}
+
+	var build func(c Category) CategoryNode
+	build = func(c Category) CategoryNode {
+		n := CategoryNode{
+			Name:        c.Name,
+			Label:       c.Label,
+			Description: c.Description,
+			Icon:        c.Icon,
+			Aliases:     c.Aliases,
+			Count:       counts[c.Name],
+		}
+		if n.Label == "" {
+			n.Label = c.Name
+		}
+		n.Total = n.Count
+		for _, child := range children[c.Name] {
+			cn := build(child)
+			n.Total += cn.Total
+			n.Children = append(n.Children, cn)
+		}
+		return n
+	}
+	nodes := make([]CategoryNode, 0, len(roots))
+	for _, c := range roots {
+		nodes = append(nodes, build(c))
+	}
+	return nodes
+}
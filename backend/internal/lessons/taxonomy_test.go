@@ -0,0 +1,67 @@
+package lessons
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewTaxonomyValidates(t *testing.T) {
+	cases := []struct {
+		name string
+		cats []Category
+		want string
+	}{
+		{"missing name", []Category{{Label: "X"}}, "name is required"},
+		{"duplicate", []Category{{Name: "go"}, {Name: "Go"}}, "duplicate name"},
+		{"alias is a name", []Category{{Name: "go"}, {Name: "golang", Aliases: []string{"go"}}}, "already a category name"},
+		{"shared alias", []Category{{Name: "a", Aliases: []string{"x"}}, {Name: "b", Aliases: []string{"X"}}}, "already used by"},
+		{"unknown parent", []Category{{Name: "a", Parent: "b"}}, "unknown parent"},
+		{"cycle", []Category{{Name: "a", Parent: "b"}, {Name: "b", Parent: "a"}}, "parent cycle"},
+	}
+	for _, c := range cases {
+		if _, err := NewTaxonomy(c.cats); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: got %v, want error containing %q", c.name, err, c.want)
+		}
+	}
+}
+
+func TestTaxonomyCanonical(t *testing.T) {
+	taxonomy, err := NewTaxonomy([]Category{
+		{Name: "api-design", Aliases: []string{"apis", "REST"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for in, want := range map[string]string{
+		"api-design": "api-design",
+		" APIs ":     "api-design",
+		"rest":       "api-design",
+		"Unknown":    "Unknown",
+	} {
+		if got := taxonomy.Canonical(in); got != want {
+			t.Errorf("Canonical(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	var none *Taxonomy
+	if got := none.Canonical("apis"); got != "apis" {
+		t.Errorf("nil taxonomy should keep categories, got %q", got)
+	}
+}
+
+func TestLoadTaxonomy(t *testing.T) {
+	dir := t.TempDir()
+	if taxonomy, err := LoadTaxonomy(filepath.Join(dir, "missing.json")); err != nil || taxonomy != nil {
+		t.Fatalf("missing file should yield no taxonomy, got %v, %v", taxonomy, err)
+	}
+
+	path := filepath.Join(dir, "categories.json")
+	if err := os.WriteFile(path, []byte(`{"categories":[{"name":"go","colour":"blue"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTaxonomy(path); err == nil || !strings.Contains(err.Error(), "colour") {
+		t.Fatalf("expected unknown field error, got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package lint
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"avidlearner/internal/config"
+)
+
+// Main runs `avidlearner lint` with the given arguments and returns the
+// process exit code: 0 when clean, 1 when errors were found (or warnings
+// with -strict), 2 for usage errors. Paths default to the server's config,
+// so the same environment variables apply.
+func Main(args []string, stdout, stderr io.Writer) int {
+	cfg := config.Load()
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	opts := Options{}
+	fs.StringVar(&opts.LessonsFile, "lessons", cfg.LessonsFile, "lessons JSON file")
+	fs.StringVar(&opts.SecretLessonsFile, "secret-lessons", cfg.SecretLessonsFile, "secret-knowledge lessons JSON file")
+	fs.StringVar(&opts.LessonsDir, "lessons-dir", cfg.LessonsDir, "directory of Markdown lessons")
+	fs.StringVar(&opts.ProChallengesFile, "challenges", cfg.ProChallengesFile, "pro challenges JSON file")
+	fs.StringVar(&opts.CategoriesFile, "categories", cfg.CategoriesFile, "known categories file (empty to skip the check)")
+	fs.StringVar(&opts.ProtestsDir, "protests", defaultProtestsDir(), "directory holding protests/<id>/challenge_test.go")
+	fs.StringVar(&opts.TracksFile, "tracks", cfg.TracksFile, "learning tracks file")
+	format := fs.String("format", "json", "output format: json or text")
+	strict := fs.Bool("strict", false, "fail on warnings too")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "json" && *format != "text" {
+		fmt.Fprintf(stderr, "lint: unknown -format %q (want json or text)\n", *format)
+		return 2
+	}
+
+	report := Run(opts)
+	if *format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(report)
+	} else {
+		for _, is := range report.Issues {
+			fmt.Fprintln(stdout, is)
+		}
+		fmt.Fprintf(stdout, "%d error(s), %d warning(s)\n", report.Errors, report.Warnings)
+	}
+
+	if report.Errors > 0 || (*strict && report.Warnings > 0) {
+		return 1
+	}
+	return 0
+}
+
+func defaultProtestsDir() string {
+	for _, dir := range []string{"protests", filepath.Join("backend", "protests")} {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return "protests"
+}
@@ -0,0 +1,463 @@
+// Package lint checks the lesson and pro challenge catalogs for problems that
+// would otherwise only show up at runtime: missing fields, duplicate titles,
+// unknown categories and challenges without hidden tests or a usable starter.
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"avidlearner/internal/lessons"
+	"avidlearner/internal/models"
+	"avidlearner/internal/tracks"
+)
+
+// Severity of an Issue. Errors fail the lint run; warnings are reported only.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// challengeFile is the file a submission is written to; hidden tests are
+// compiled next to it.
+const challengeFile = "challenge.go"
+
+// Issue is one finding, positioned at a file and, where known, a line.
+type Issue struct {
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	Entry    string `json:"entry,omitempty"` // lesson title or challenge ID
+	Message  string `json:"message"`
+}
+
+func (i Issue) String() string {
+	pos := i.File
+	if i.Line > 0 {
+		pos = fmt.Sprintf("%s:%d", i.File, i.Line)
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", pos, i.Severity, i.Message, i.Code)
+}
+
+// Report is the result of a lint run.
+type Report struct {
+	Errors   int     `json:"errors"`
+	Warnings int     `json:"warnings"`
+	Issues   []Issue `json:"issues"`
+}
+
+// Options names the files to check. Empty paths are skipped.
+type Options struct {
+	LessonsFile       string
+	SecretLessonsFile string
+	LessonsDir        string // Markdown lessons
+	ProChallengesFile string
+	CategoriesFile    string // known categories; unknown ones are errors
+	ProtestsDir       string // hidden tests, protests/<id>/challenge_test.go
+	TracksFile        string // learning tracks; steps must name known lessons and challenges
+}
+
+// Run checks every configured file and returns the findings sorted by file
+// and line.
+func Run(opts Options) Report {
+	var l linter
+	known := l.loadCategories(opts.CategoriesFile)
+
+	var all []lessonEntry
+	all = append(all, l.loadLessonFile(opts.LessonsFile, "local")...)
+	all = append(all, l.loadLessonFile(opts.SecretLessonsFile, "secret-knowledge")...)
+	all = append(all, l.loadMarkdownDir(opts.LessonsDir)...)
+	l.checkLessons(all, known)
+
+	var challenges map[string]int
+	if opts.ProChallengesFile != "" {
+		challenges = l.checkChallenges(opts.ProChallengesFile, opts.ProtestsDir)
+	}
+	if opts.TracksFile != "" {
+		l.checkTracks(opts.TracksFile, all, challenges)
+	}
+
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		a, b := l.issues[i], l.issues[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	r := Report{Issues: l.issues}
+	if r.Issues == nil {
+		r.Issues = []Issue{}
+	}
+	for _, is := range r.Issues {
+		if is.Severity == SeverityError {
+			r.Errors++
+		} else {
+			r.Warnings++
+		}
+	}
+	return r
+}
+
+type linter struct {
+	issues []Issue
+}
+
+func (l *linter) add(severity, file string, line int, code, entry, format string, args ...any) {
+	l.issues = append(l.issues, Issue{
+		File:     file,
+		Line:     line,
+		Severity: severity,
+		Code:     code,
+		Entry:    entry,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// lessonEntry is a lesson with the position it was read from and the ID
+// the server will give it.
+type lessonEntry struct {
+	models.Lesson
+	id   string
+	file string
+	line int
+}
+
+func newLessonEntry(l models.Lesson, source, file string, line int) lessonEntry {
+	key := l.ID
+	if key == "" {
+		key = l.Title
+	}
+	return lessonEntry{Lesson: l, id: lessons.NewID(source, key), file: file, line: line}
+}
+
+// loadCategories reads the category taxonomy, or returns nil when no
+// categories file is configured (which disables the unknown-category check).
+func (l *linter) loadCategories(path string) *lessons.Taxonomy {
+	if path == "" {
+		return nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		l.add(SeverityError, path, 0, "read", "", "%v", err)
+		return nil
+	}
+	taxonomy, err := lessons.LoadTaxonomy(path)
+	if err != nil {
+		l.add(SeverityError, path, 0, "taxonomy", "", "invalid taxonomy: %v", err)
+		return nil
+	}
+	return taxonomy
+}
+
+func (l *linter) loadLessonFile(path, source string) []lessonEntry {
+	if path == "" {
+		return nil
+	}
+	var out []lessonEntry
+	err := decodeArray(path, func(line int, raw json.RawMessage) error {
+		var lesson models.Lesson
+		if err := json.Unmarshal(raw, &lesson); err != nil {
+			l.add(SeverityError, path, line, "json", "", "invalid lesson: %v", err)
+			return nil
+		}
+		out = append(out, newLessonEntry(lesson, source, path, line))
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		l.add(SeverityError, path, 0, "json", "", "%v", err)
+	}
+	return out
+}
+
+func (l *linter) loadMarkdownDir(dir string) []lessonEntry {
+	if dir == "" {
+		return nil
+	}
+	list, err := lessons.LoadMarkdownLessons(dir)
+	var fileErrs lessons.FileErrors
+	switch {
+	case errors.As(err, &fileErrs):
+		for _, e := range fileErrs {
+			l.add(SeverityError, e.File, e.Line, "markdown", "", "%s", e.Msg)
+		}
+	case err != nil:
+		l.add(SeverityError, dir, 0, "read", "", "%v", err)
+	}
+	out := make([]lessonEntry, 0, len(list))
+	for _, ml := range list {
+		out = append(out, newLessonEntry(ml.Lesson, "local", ml.File, ml.Line))
+	}
+	return out
+}
+
+func (l *linter) checkLessons(all []lessonEntry, taxonomy *lessons.Taxonomy) {
+	titles := map[string]lessonEntry{}
+	ids := map[string]lessonEntry{}
+	perCategory := map[string][]lessonEntry{}
+	for _, e := range all {
+		title := strings.TrimSpace(e.Title)
+		for _, f := range [...]struct{ name, value string }{
+			{"title", e.Title},
+			{"category", e.Category},
+			{"text", e.Text},
+			{"explain", e.Explain},
+		} {
+			if strings.TrimSpace(f.value) == "" {
+				l.add(SeverityError, e.file, e.line, "missing-field", title, "%s is required", f.name)
+			}
+		}
+		// The ID is derived from the id key when there is one and from the
+		// title otherwise, so a lesson without one changes ID, and loses its
+		// saved progress and track steps, when its title is edited.
+		if strings.TrimSpace(e.ID) == "" {
+			l.add(SeverityError, e.file, e.line, "missing-id", title, "id is required; %q keeps the lesson's current ID", strings.Join(strings.Fields(strings.ToLower(e.Title)), " "))
+		}
+		if len(e.UseCases) == 0 {
+			l.add(SeverityWarning, e.file, e.line, "missing-field", title, "useCases is empty")
+		}
+		if len(e.Tips) == 0 {
+			l.add(SeverityWarning, e.file, e.line, "missing-field", title, "tips is empty")
+		}
+		for i, q := range e.Questions {
+			if err := lessons.ValidateQuestion(q); err != nil {
+				l.add(SeverityError, e.file, e.line, "invalid-question", title, "question %d: %v", i, err)
+			}
+		}
+
+		if title != "" {
+			key := strings.ToLower(title)
+			if first, dup := titles[key]; dup {
+				l.add(SeverityError, e.file, e.line, "duplicate-title", title, "title already used at %s", position(first))
+			} else {
+				titles[key] = e
+			}
+		}
+		// Lessons are compared by the ID the server gives them, so a Markdown
+		// lesson whose id or title matches a lessons.json one is caught too:
+		// the server would keep only the first.
+		switch first, dup := ids[e.id]; {
+		case strings.TrimSpace(e.ID) == "" && title == "":
+			// Nothing to derive an ID from; missing-field reports it.
+		case dup:
+			l.add(SeverityError, e.file, e.line, "duplicate-id", title, "lesson ID %s already used at %s", e.id, position(first))
+		default:
+			ids[e.id] = e
+		}
+		if cat := strings.TrimSpace(e.Category); cat != "" {
+			if taxonomy != nil {
+				canonical, isName, isAlias := taxonomy.Known(cat)
+				switch {
+				case isAlias:
+					l.add(SeverityWarning, e.file, e.line, "category-alias", title, "category %q is an alias of %q", cat, canonical)
+				case !isName:
+					l.add(SeverityError, e.file, e.line, "unknown-category", title, "unknown category %q", cat)
+				}
+				cat = canonical
+			}
+			perCategory[cat] = append(perCategory[cat], e)
+		}
+	}
+
+	cats := make([]string, 0, len(perCategory))
+	for cat := range perCategory {
+		cats = append(cats, cat)
+	}
+	sort.Strings(cats)
+	for _, cat := range cats {
+		if entries := perCategory[cat]; len(entries) == 1 {
+			e := entries[0]
+			l.add(SeverityWarning, e.file, e.line, "small-category", strings.TrimSpace(e.Title), "category %q has only one lesson", cat)
+		}
+	}
+}
+
+// checkChallenges returns the line of every challenge by ID.
+func (l *linter) checkChallenges(path, protestsDir string) map[string]int {
+	seen := map[string]int{}
+	err := decodeArray(path, func(line int, raw json.RawMessage) error {
+		var ch models.ProChallenge
+		if err := json.Unmarshal(raw, &ch); err != nil {
+			l.add(SeverityError, path, line, "json", "", "invalid challenge: %v", err)
+			return nil
+		}
+		id := strings.TrimSpace(ch.ID)
+		for _, f := range [...]struct{ name, value string }{
+			{"id", ch.ID},
+			{"title", ch.Title},
+			{"difficulty", ch.Difficulty},
+			{"description", ch.Description},
+		} {
+			if strings.TrimSpace(f.value) == "" {
+				l.add(SeverityError, path, line, "missing-field", id, "%s is required", f.name)
+			}
+		}
+		if len(ch.Hints) == 0 {
+			l.add(SeverityWarning, path, line, "missing-field", id, "hints is empty")
+		}
+		if id == "" {
+			return nil
+		}
+		if first, dup := seen[id]; dup {
+			l.add(SeverityError, path, line, "duplicate-id", id, "id already used at %s:%d", path, first)
+			return nil
+		}
+		seen[id] = line
+		if strings.ContainsAny(id, `/\`) || strings.Contains(id, "..") {
+			l.add(SeverityError, path, line, "invalid-id", id, "id must not contain path separators or ..")
+			return nil
+		}
+		l.checkStarter(path, line, ch, protestsDir)
+		return nil
+	})
+	if err != nil {
+		l.add(SeverityError, path, 0, "json", "", "%v", err)
+	}
+
+	if protestsDir == "" {
+		return seen
+	}
+	dirs, err := os.ReadDir(protestsDir)
+	if err != nil {
+		return seen
+	}
+	for _, d := range dirs {
+		if d.IsDir() && seen[d.Name()] == 0 {
+			l.add(SeverityWarning, filepath.Join(protestsDir, d.Name()), 0, "orphan-tests", d.Name(), "hidden tests have no entry in %s", filepath.Base(path))
+		}
+	}
+	return seen
+}
+
+// checkTracks verifies that every track step names a known lesson or
+// challenge. Lessons from remote sources are not in the local files and are
+// not checked. challenges is nil when no challenge file was linted.
+func (l *linter) checkTracks(path string, all []lessonEntry, challenges map[string]int) {
+	list, err := tracks.Load(path)
+	if err != nil {
+		l.add(SeverityError, path, 0, "tracks", "", "%v", err)
+		return
+	}
+	lessonIDs := make(map[string]bool, len(all))
+	for _, e := range all {
+		lessonIDs[e.id] = true
+	}
+	for _, t := range list {
+		for _, s := range t.Steps {
+			switch {
+			case s.Challenge != "" && challenges != nil && challenges[s.Challenge] == 0:
+				l.add(SeverityError, path, 0, "unknown-step", t.ID, "step %q: unknown challenge %q", s.ID, s.Challenge)
+			case s.Lesson != "" && isLocalLessonID(s.Lesson) && !lessonIDs[s.Lesson]:
+				l.add(SeverityError, path, 0, "unknown-step", t.ID, "step %q: unknown lesson %q", s.ID, s.Lesson)
+			}
+		}
+	}
+}
+
+func isLocalLessonID(id string) bool {
+	return strings.HasPrefix(id, "local-") || strings.HasPrefix(id, "secret-knowledge-")
+}
+
+// checkStarter verifies that the hidden tests exist and that the starter
+// code parses and declares the same package as the tests.
+func (l *linter) checkStarter(path string, line int, ch models.ProChallenge, protestsDir string) {
+	testPkg := ""
+	if protestsDir != "" {
+		testPath := filepath.Join(protestsDir, ch.ID, "challenge_test.go")
+		pkg, err := packageName(testPath, nil)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			l.add(SeverityError, path, line, "missing-tests", ch.ID, "hidden tests %s not found", testPath)
+		case err != nil:
+			l.add(SeverityError, testPath, 0, "invalid-tests", ch.ID, "%v", err)
+		default:
+			testPkg = pkg
+		}
+	}
+
+	if strings.TrimSpace(ch.Starter.Code) == "" {
+		l.add(SeverityError, path, line, "missing-starter", ch.ID, "starter.code is required")
+		return
+	}
+	if ch.Starter.Filename != challengeFile {
+		l.add(SeverityError, path, line, "starter-filename", ch.ID, "starter.filename is %q; submissions are compiled as %s", ch.Starter.Filename, challengeFile)
+	}
+	starterPkg, err := packageName(challengeFile, []byte(ch.Starter.Code))
+	if err != nil {
+		l.add(SeverityError, path, line, "starter-syntax", ch.ID, "starter does not parse: %v", err)
+		return
+	}
+	if testPkg != "" && starterPkg != testPkg {
+		l.add(SeverityError, path, line, "starter-package", ch.ID, "starter declares package %s but the hidden tests use package %s", starterPkg, testPkg)
+	}
+}
+
+// packageName parses a Go file (from src if non-nil) and returns its package.
+func packageName(filename string, src []byte) (string, error) {
+	if src == nil {
+		b, err := os.ReadFile(filename)
+		if err != nil {
+			return "", err
+		}
+		src = b
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), filename, src, parser.AllErrors)
+	if err != nil {
+		return "", err
+	}
+	return f.Name.Name, nil
+}
+
+// decodeArray streams a JSON array and calls fn with the line on which each
+// element starts.
+func decodeArray(path string, fn func(line int, raw json.RawMessage) error) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return errors.New("expected a JSON array")
+	}
+	for dec.More() {
+		start := int(dec.InputOffset())
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("line %d: invalid JSON: %w", lineAt(b, start), err)
+		}
+		if err := fn(lineAt(b, elementStart(b, start)), raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// elementStart skips the separator and whitespace before an array element.
+func elementStart(b []byte, offset int) int {
+	for offset < len(b) && (b[offset] == ',' || b[offset] == ' ' || b[offset] == '\n' || b[offset] == '\r' || b[offset] == '\t') {
+		offset++
+	}
+	return offset
+}
+
+func lineAt(b []byte, offset int) int {
+	return bytes.Count(b[:min(offset, len(b))], []byte("\n")) + 1
+}
+
+func position(e lessonEntry) string {
+	if e.line > 0 {
+		return fmt.Sprintf("%s:%d", e.file, e.line)
+	}
+	return e.file
+}
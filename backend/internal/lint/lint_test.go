@@ -0,0 +1,188 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"avidlearner/internal/models"
+)
+
+func writeFile(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func lintFixture(t *testing.T) Options {
+	t.Helper()
+	dir := t.TempDir()
+	opts := Options{
+		LessonsFile:       filepath.Join(dir, "lessons.json"),
+		ProChallengesFile: filepath.Join(dir, "pro_challenges.json"),
+		CategoriesFile:    filepath.Join(dir, "categories.json"),
+		ProtestsDir:       filepath.Join(dir, "protests"),
+	}
+	writeFile(t, opts.CategoriesFile, `{"categories":[{"name":"performance"},{"name":"testing"}]}`)
+	writeFile(t, opts.LessonsFile, `[
+  {"id":"caching","title":"Caching","category":"performance","text":"Cache hot reads.","explain":"Reads dominate.","useCases":["a"],"tips":["b"]},
+  {"id":"profiling","title":"Profiling","category":"performance","text":"Measure first.","useCases":["a"],"tips":["b"]},
+  {"id":"caching tests","title":"caching","category":"testing","text":"Again.","explain":"x","useCases":["a"],"tips":["b"]},
+  {"title":"Fuzzing","category":"fuzz","text":"Random inputs.","explain":"x","useCases":["a"],"tips":["b"]}
+]`)
+	writeFile(t, opts.ProChallengesFile, `[
+  {"id":"ok","title":"OK","difficulty":"easy","description":"d","hints":["h"],
+   "starter":{"filename":"challenge.go","code":"package ok\n"}},
+  {"id":"wrong-pkg","title":"Wrong","difficulty":"easy","description":"d","hints":["h"],
+   "starter":{"filename":"challenge.go","code":"package main\n"}},
+  {"id":"no-tests","title":"None","difficulty":"easy","description":"d","hints":["h"],
+   "starter":{"filename":"challenge.go","code":"package x\n"}}
+]`)
+	writeFile(t, filepath.Join(opts.ProtestsDir, "ok", "challenge_test.go"), "package ok\n")
+	writeFile(t, filepath.Join(opts.ProtestsDir, "wrong-pkg", "challenge_test.go"), "package wrongpkg\n")
+	return opts
+}
+
+func TestRunReportsCatalogProblems(t *testing.T) {
+	opts := lintFixture(t)
+	report := Run(opts)
+
+	want := map[string]struct {
+		file string
+		line int
+	}{
+		"missing-field:Profiling":   {opts.LessonsFile, 3},
+		"duplicate-title:caching":   {opts.LessonsFile, 4},
+		"unknown-category:Fuzzing":  {opts.LessonsFile, 5},
+		"missing-id:Fuzzing":        {opts.LessonsFile, 5},
+		"starter-package:wrong-pkg": {opts.ProChallengesFile, 4},
+		"missing-tests:no-tests":    {opts.ProChallengesFile, 6},
+	}
+	for _, is := range report.Issues {
+		if is.Severity != SeverityError {
+			continue
+		}
+		key := is.Code + ":" + is.Entry
+		w, ok := want[key]
+		if !ok {
+			t.Errorf("unexpected error %v", is)
+			continue
+		}
+		if is.File != w.file || is.Line != w.line {
+			t.Errorf("%s: got %s:%d, want %s:%d", key, is.File, is.Line, w.file, w.line)
+		}
+		delete(want, key)
+	}
+	for key := range want {
+		t.Errorf("missing error %s", key)
+	}
+	if report.Errors != 6 {
+		t.Errorf("expected 6 errors, got %d", report.Errors)
+	}
+}
+
+func TestMainExitCodeAndJSON(t *testing.T) {
+	opts := lintFixture(t)
+	args := []string{
+		"-lessons", opts.LessonsFile,
+		"-secret-lessons", "",
+		"-lessons-dir", "",
+		"-challenges", opts.ProChallengesFile,
+		"-categories", opts.CategoriesFile,
+		"-protests", opts.ProtestsDir,
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Main(args, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit 1, got %d (stderr %q)", code, stderr.String())
+	}
+	var report Report
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, stdout.String())
+	}
+	if report.Errors != 6 || len(report.Issues) == 0 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	stdout.Reset()
+	if code := Main(append(args, "-format", "text"), &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit 1 for text output, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), opts.LessonsFile+":5: error: unknown category \"fuzz\" [unknown-category]") {
+		t.Errorf("unexpected text output:\n%s", stdout.String())
+	}
+
+	if code := Main([]string{"-format", "yaml"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit 2 for bad format, got %d", code)
+	}
+}
+
+func TestRunChecksTrackSteps(t *testing.T) {
+	opts := lintFixture(t)
+	opts.TracksFile = filepath.Join(filepath.Dir(opts.LessonsFile), "tracks.json")
+	caching := newLessonEntry(models.Lesson{Title: "Caching"}, "local", "", 0).id
+	writeFile(t, opts.TracksFile, `{"tracks":[{"id":"perf","title":"Performance","steps":[
+  {"id":"caching","lesson":"`+caching+`"},
+  {"id":"gone","lesson":"local-000000000000","requires":["caching"]},
+  {"id":"remote","lesson":"devto-000000000000"},
+  {"challenge":"ok","requires":["caching"]},
+  {"challenge":"missing"}
+]}]}`)
+
+	var got []string
+	for _, is := range Run(opts).Issues {
+		if is.Code == "unknown-step" {
+			got = append(got, is.Message)
+		}
+	}
+	if len(got) != 2 || !strings.Contains(got[0], `"gone"`) || !strings.Contains(got[1], `"missing"`) {
+		t.Fatalf("expected unknown lesson and challenge steps, got %v", got)
+	}
+}
+
+func TestRunReportsLessonIDsUsedAcrossSources(t *testing.T) {
+	opts := lintFixture(t)
+	opts.LessonsDir = filepath.Join(filepath.Dir(opts.LessonsFile), "lessons")
+	// One takes a lessons.json lesson's title, the other its id.
+	writeFile(t, filepath.Join(opts.LessonsDir, "caching.md"), "---\ntitle: Caching\ncategory: performance\n---\nCache hot reads.\n")
+	writeFile(t, filepath.Join(opts.LessonsDir, "profiling.md"), "---\nid: profiling\ntitle: Measuring\ncategory: performance\n---\nMeasure first.\n")
+
+	got := map[string]string{}
+	for _, is := range Run(opts).Issues {
+		if is.Code == "duplicate-id" {
+			got[filepath.Base(is.File)] = is.Message
+		}
+	}
+	if len(got) != 2 || !strings.Contains(got["caching.md"], opts.LessonsFile+":2") || !strings.Contains(got["profiling.md"], opts.LessonsFile+":3") {
+		t.Fatalf("expected both Markdown lessons reported against lessons.json, got %v", got)
+	}
+}
+
+func TestRunChecksQuestions(t *testing.T) {
+	opts := lintFixture(t)
+	opts.SecretLessonsFile = filepath.Join(filepath.Dir(opts.LessonsFile), "secret.json")
+	writeFile(t, opts.SecretLessonsFile, `[
+  {"title":"Timeouts","category":"testing","text":"Bound every call.","explain":"x","useCases":["a"],"tips":["b"],"questions":[
+    {"type":"true-false","prompt":"Deadlines propagate.","options":[{"text":"True","correct":true},{"text":"False"}]},
+    {"prompt":"Pick one","options":[{"text":"a","correct":true},{"text":"b","correct":true}]},
+    {"type":"fill-blank","prompt":"A ___ bounds a call."}
+  ]}
+]`)
+
+	var got []string
+	for _, is := range Run(opts).Issues {
+		if is.Code == "invalid-question" {
+			got = append(got, is.Message)
+		}
+	}
+	if len(got) != 2 || !strings.HasPrefix(got[0], "question 1:") || !strings.HasPrefix(got[1], "question 2:") {
+		t.Fatalf("expected the two broken questions, got %v", got)
+	}
+}
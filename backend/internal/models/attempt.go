@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// QuizAttempt is one finished quiz of a signed-in user. Seq orders a user's
+// attempts and doubles as the attempt ID.
+type QuizAttempt struct {
+	Seq        int64             `json:"seq"`
+	UserID     string            `json:"userId"`
+	Review     bool              `json:"review,omitempty"` // a spaced-repetition review quiz
+	Timed      bool              `json:"timed,omitempty"`
+	Daily      string            `json:"daily,omitempty"`  // the day of a daily quiz
+	Score      int               `json:"score"`            // correct answers
+	Points     int               `json:"points,omitempty"` // speed-weighted score of a timed quiz
+	Total      int               `json:"total"`
+	StartedAt  time.Time         `json:"startedAt"`
+	FinishedAt time.Time         `json:"finishedAt"`
+	Questions  []AttemptQuestion `json:"questions"`
+}
+
+// AttemptQuestion is one answered question as it was asked. Chosen holds
+// the picked option indexes, or the submitted order for ordering questions;
+// Correct and Accepted are the right answer as in AnswerFeedback.
+type AttemptQuestion struct {
+	LessonID    string    `json:"lessonId"`
+	LessonTitle string    `json:"lessonTitle"`
+	Type        string    `json:"type"`
+	Question    string    `json:"question"`
+	Options     []string  `json:"options,omitempty"`
+	Explains    []string  `json:"explains,omitempty"` // per option
+	Chosen      []int     `json:"chosen,omitempty"`
+	AnswerText  string    `json:"answerText,omitempty"`
+	Correct     []int     `json:"correct,omitempty"`
+	Accepted    []string  `json:"accepted,omitempty"`
+	Explain     string    `json:"explain,omitempty"`
+	IsCorrect   bool      `json:"isCorrect"`
+	Late        bool      `json:"late,omitempty"`
+	AskedAt     time.Time `json:"askedAt"`
+	AnsweredAt  time.Time `json:"answeredAt"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Ledger entry kinds.
+const (
+	LedgerOpeningBalance  = "opening_balance"
+	LedgerSessionMerge    = "session_merge"
+	LedgerQuizAnswer      = "quiz_answer"
+	LedgerChallengeSubmit = "challenge_submit"
+	LedgerHintPurchase    = "hint_purchase"
+	LedgerTypingSession   = "typing_session"
+	LedgerLessonRead      = "lesson_read"
+	LedgerDailyQuiz       = "daily_quiz"
+	LedgerDailyChallenge  = "daily_challenge"
+)
+
+// LedgerEntry is one server-recorded earning or spending event. Profile
+// counters are the fold of a user's entries in Seq order.
+type LedgerEntry struct {
+	Seq         int64      `json:"seq"`
+	UserID      string     `json:"userId"`
+	Kind        string     `json:"kind"`
+	Ref         string     `json:"ref,omitempty"` // lesson ID, challenge ID or session fingerprint
+	Coins       int        `json:"coins,omitempty"`
+	XP          int        `json:"xp,omitempty"`
+	CodingScore int        `json:"codingScore,omitempty"`
+	TypingScore int        `json:"typingScore,omitempty"`
+	Streak      int        `json:"streak,omitempty"`
+	Correct     bool       `json:"correct,omitempty"`
+	Score       int        `json:"score,omitempty"` // daily quiz or challenge: correct answers or passed tests out of Total
+	Total       int        `json:"total,omitempty"`
+	Stats       *UserStats `json:"stats,omitempty"` // opening balance only
+	At          time.Time  `json:"at"`
+}
@@ -3,6 +3,7 @@ package models
 import "time"
 
 type Lesson struct {
+	ID       string   `json:"id,omitempty"`
 	Title    string   `json:"title"`
 	Category string   `json:"category"`
 	Text     string   `json:"text"`
@@ -10,6 +11,39 @@ type Lesson struct {
 	UseCases []string `json:"useCases"`
 	Tips     []string `json:"tips"`
 	Source   string   `json:"source,omitempty"`
+	// Difficulty (beginner, intermediate, advanced) and Tags are optional;
+	// Markdown lessons set them in front matter.
+	Difficulty string   `json:"difficulty,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	// Questions are authored quiz questions. They carry the answers, so the
+	// lesson map keeps them aside and never serves them with the lesson.
+	Questions []Question `json:"questions,omitempty"`
+}
+
+// Authored question types. An empty type is multiple choice.
+const (
+	QuestionMultipleChoice = "multiple-choice"
+	QuestionMultiSelect    = "multi-select"
+	QuestionTrueFalse      = "true-false"
+	QuestionOrdering       = "ordering"
+	QuestionFillBlank      = "fill-blank"
+)
+
+// Question is an authored quiz question. Options are listed in the correct
+// order for ordering questions; for fill-blank, Answers are the accepted
+// answers and Options, if any, are common wrong answers with an explanation.
+type Question struct {
+	Type    string           `json:"type,omitempty"`
+	Prompt  string           `json:"prompt"`
+	Options []QuestionOption `json:"options,omitempty"`
+	Answers []string         `json:"answers,omitempty"`
+	Explain string           `json:"explain,omitempty"`
+}
+
+type QuestionOption struct {
+	Text    string `json:"text"`
+	Correct bool   `json:"correct,omitempty"`
+	Explain string `json:"explain,omitempty"`
 }
 
 type LessonsResponse struct {
@@ -22,25 +56,64 @@ type SessionState struct {
 	// lesson/reading
 	Lesson *Lesson `json:"lesson,omitempty"`
 	// quiz
-	Question string   `json:"question,omitempty"`
-	Options  []string `json:"options,omitempty"`
-	Index    int      `json:"index,omitempty"`
-	Total    int      `json:"total,omitempty"`
+	QuestionType string   `json:"questionType,omitempty"`
+	Question     string   `json:"question,omitempty"`
+	Options      []string `json:"options,omitempty"`
+	Index        int      `json:"index,omitempty"`
+	Total        int      `json:"total,omitempty"`
+	// timed quiz: server-measured time left for the question and the quiz
+	Timed              bool  `json:"timed,omitempty"`
+	QuestionTimeLeftMs int64 `json:"questionTimeLeftMs,omitempty"`
+	QuizTimeLeftMs     int64 `json:"quizTimeLeftMs,omitempty"`
 	// result/answer
-	Correct     bool   `json:"correct,omitempty"`
-	CoinsEarned int    `json:"coinsEarned,omitempty"`
-	CoinsTotal  int    `json:"coinsTotal,omitempty"`
-	XPTotal     int    `json:"xpTotal,omitempty"`
-	More        bool   `json:"more,omitempty"`
-	Message     string `json:"message,omitempty"`
+	Correct     bool            `json:"correct,omitempty"`
+	Late        bool            `json:"late,omitempty"`     // answered after the deadline; scored zero
+	Points      int             `json:"points,omitempty"`   // added to the quiz score
+	Feedback    *AnswerFeedback `json:"feedback,omitempty"` // on the question just answered
+	CoinsEarned int             `json:"coinsEarned,omitempty"`
+	CoinsTotal  int             `json:"coinsTotal,omitempty"`
+	XPEarned    int             `json:"xpEarned,omitempty"`
+	XPTotal     int             `json:"xpTotal,omitempty"`
+	LevelUp     *LevelUp        `json:"levelUp,omitempty"`
+	More        bool            `json:"more,omitempty"`
+	Message     string          `json:"message,omitempty"`
+	AttemptID   int64           `json:"attemptId,omitempty"` // the saved attempt, when the quiz ends signed in
+	// daily quiz
+	Daily       string `json:"daily,omitempty"`
+	DailyScored bool   `json:"dailyScored,omitempty"`
+	DailyStreak int    `json:"dailyStreak,omitempty"`
+}
+
+// AnswerFeedback explains a graded answer. Correct holds the right option
+// indexes (in order, for ordering questions) and Accepted the accepted
+// fill-blank answers.
+type AnswerFeedback struct {
+	Type     string           `json:"type"`
+	Correct  []int            `json:"correct,omitempty"`
+	Accepted []string         `json:"accepted,omitempty"`
+	Options  []OptionFeedback `json:"options,omitempty"`
+	Explain  string           `json:"explain,omitempty"`
+}
+
+type OptionFeedback struct {
+	Text    string `json:"text"`
+	Chosen  bool   `json:"chosen,omitempty"`
+	Correct bool   `json:"correct,omitempty"`
+	Explain string `json:"explain,omitempty"`
 }
 
-// One generated MCQ
+// One quiz question, authored or generated, with options in display order.
 type QuizQuestion struct {
+	LessonID     string
 	LessonTitle  string
+	Type         string
 	Question     string
-	Options      []string
-	CorrectIndex int
+	Options      []string // fill-blank: known wrong answers, never shown
+	Explains     []string // per option, parallel to Options
+	CorrectIndex int      // multiple-choice, true-false
+	Correct      []int    // multi-select: right options; ordering: right order
+	Accepted     []string // fill-blank
+	Explain      string
 }
 
 type ChallengeStarter struct {
@@ -62,6 +135,9 @@ type ProChallenge struct {
 	Starter     ChallengeStarter `json:"starter"`
 	Hints       []string         `json:"hints"`
 	Reward      ChallengeReward  `json:"reward"`
+	// Loopback gives the sandboxed tests a private loopback network, for
+	// hidden tests that start an httptest server.
+	Loopback bool `json:"loopback,omitempty"`
 }
 
 type TestFailure struct {
@@ -69,12 +145,39 @@ type TestFailure struct {
 	Output string `json:"output"`
 }
 
+// TestCase is one test or subtest of a graded submission, from go test
+// -json. Status is pass, fail or skip; a test still running when the binary
+// was killed counts as failed. Elapsed is in seconds.
+type TestCase struct {
+	Name     string     `json:"name"` // full name, e.g. TestParse/empty
+	Status   string     `json:"status"`
+	Elapsed  float64    `json:"elapsed"`
+	Output   string     `json:"output,omitempty"` // the test's own log lines
+	Subtests []TestCase `json:"subtests,omitempty"`
+}
+
+// BuildDiagnostic is one compiler error, with File relative to the
+// submission's module, such as challenge.go.
+type BuildDiagnostic struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
 type ChallengeTestResult struct {
-	Passed   bool
-	Total    int
-	Failures []TestFailure
-	Stdout   string
-	Stderr   string
+	Passed      bool
+	Total       int // tests and subtests without subtests of their own
+	Failures    []TestFailure
+	Tests       []TestCase // top-level tests, in the order they started
+	Diagnostics []BuildDiagnostic
+	Stdout      string
+	Stderr      string
+	Truncated   bool // the run printed more output than grading keeps
+	// CompileTime and RunTime split grading time between building the test
+	// binary and running it; only the run is subject to the test timeout.
+	CompileTime time.Duration
+	RunTime     time.Duration
 }
 
 // Per-session state
@@ -82,19 +185,32 @@ type Profile struct {
 	Coins       int
 	Streak      int
 	XP          int
-	LessonsSeen []string
+	LessonsSeen []string // lesson IDs
 
 	CurrentQuiz   []QuizQuestion
 	QuizIndex     int
+	ReviewQuiz    bool // CurrentQuiz holds due reviews, not the study list
+	QuizStarted   time.Time
+	QuestionAsked time.Time         // when the current question was served
+	QuizAnswers   []AttemptQuestion // answered so far; saved as an attempt at the end
+	Timed         bool              // answers are due by the question and quiz deadlines
+	QuizDeadline  time.Time
+	Daily         string // day of the daily quiz in CurrentQuiz, or ""
+	DailyScored   bool   // this run is the user's scored attempt for Daily
 	LastLesson    *Lesson
-	RecentLessons []string
+	RecentLessons []string       // lesson IDs
 	HintIdx       map[string]int // challengeID -> next hint index
 	PlayerName    string         // for leaderboard
 
 	QuizScore       int       // Current quiz session score
+	QuizScoreTimed  bool      // QuizScore is from a timed quiz
 	TypingScore     int       // Best typing score this session
+	TypingStarted   time.Time // when the typing session in progress was started, or zero
+	TypingChars     int       // length of the text being typed
 	CodingScore     int       // Coding challenges score
 	LastScoreSubmit time.Time // Prevent spam submissions
+
+	MergedInto string // user ID this session's progress was merged into
 }
 
 // Leaderboard entry
@@ -104,6 +220,8 @@ type LeaderboardEntry struct {
 	Mode     string    `json:"mode"` // "quiz", "typing", "coding"
 	Date     time.Time `json:"date"`
 	Category string    `json:"category,omitempty"`
+	Level    int       `json:"level,omitempty"` // the player's level when the score was submitted
+	Title    string    `json:"title,omitempty"`
 }
 
 type NewsCacheEntry struct {
@@ -126,4 +244,4 @@ type RssChannel struct {
 
 type RssDoc struct {
 	Channel RssChannel `xml:"channel"`
-}
\ No newline at end of file
+}
@@ -3,6 +3,7 @@ package models
 import "time"
 
 type SavedLesson struct {
+	ID       string    `json:"id,omitempty"`
 	Title    string    `json:"title"`
 	Category string    `json:"category"`
 	Source   string    `json:"source,omitempty"`
@@ -26,10 +27,82 @@ type UserProfile struct {
 	TypingStreak int           `json:"typingStreak"`
 	TypingBest   int           `json:"typingBest"`
 	CodingScore  int           `json:"codingScore"`
-	LessonsSeen  []string      `json:"lessonsSeen"`
+	LessonsSeen  []string      `json:"lessonsSeen"` // lesson IDs
 	SavedLessons []SavedLesson `json:"savedLessons"`
 	Stats        UserStats     `json:"stats"`
 	UpdatedAt    time.Time     `json:"updatedAt"`
+	// Level is derived from XP on the level curve.
+	Level Level `json:"level"`
+	// Daily is the daily quiz record, derived from the ledger. DailyStarted
+	// is the last day whose scored attempt was started (YYYY-MM-DD).
+	Daily        DailyProgress `json:"daily"`
+	DailyStarted string        `json:"dailyStarted,omitempty"`
+
+	HintIdx map[string]int `json:"hintIdx,omitempty"` // challengeID -> next hint index
+	// Reviews is the spaced-repetition schedule by lesson ID, derived from
+	// quiz answers in the ledger.
+	Reviews map[string]ReviewItem `json:"reviews,omitempty"`
+	// Tracks is learning track progress by track ID.
+	Tracks map[string]TrackProgress `json:"tracks,omitempty"`
+	// Fingerprints of anonymous sessions already folded into this account.
+	MergedSessions []string `json:"mergedSessions,omitempty"`
+	// LessonRefsVersion records which lesson reference format LessonsSeen and
+	// SavedLessons use; 0 means titles, 1 means lesson IDs.
+	LessonRefsVersion int `json:"lessonRefsVersion,omitempty"`
+}
+
+// Level places a total XP on the level curve. LevelXP and NextLevelXP are
+// the totals at which the level starts and the next one begins.
+type Level struct {
+	Level       int    `json:"level"`
+	Title       string `json:"title"`
+	XP          int    `json:"xp"`
+	LevelXP     int    `json:"levelXp"`
+	NextLevelXP int    `json:"nextLevelXp"`
+}
+
+// DailyProgress is a user's daily quiz and challenge record. Days are UTC
+// dates (YYYY-MM-DD); Streak counts consecutive days up to LastDone on which
+// either was done.
+type DailyProgress struct {
+	LastDone   string                `json:"lastDone,omitempty"`
+	Streak     int                   `json:"streak"`
+	BestStreak int                   `json:"bestStreak"`
+	Scores     map[string]DailyScore `json:"scores,omitempty"`     // recent quizzes by date
+	Challenges map[string]DailyScore `json:"challenges,omitempty"` // recent challenges by date
+}
+
+type DailyScore struct {
+	Score      int       `json:"score"`
+	Total      int       `json:"total"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// LevelUp is returned by handlers whose award moved the player up a level.
+type LevelUp struct {
+	From         int    `json:"from"`
+	To           int    `json:"to"`
+	Title        string `json:"title"`
+	TitleChanged bool   `json:"titleChanged,omitempty"` // a new rank title was reached
+}
+
+// ReviewItem is the SM-2 state of one lesson for one user. Interval is in
+// days.
+type ReviewItem struct {
+	Ease         float64   `json:"ease"`
+	Interval     int       `json:"interval"`
+	Repetitions  int       `json:"repetitions"`
+	Lapses       int       `json:"lapses,omitempty"`
+	Due          time.Time `json:"due"`
+	LastReviewed time.Time `json:"lastReviewed"`
+}
+
+// TrackProgress is one user's progress through a learning track. Completed
+// maps step IDs to when they were completed.
+type TrackProgress struct {
+	EnrolledAt  time.Time            `json:"enrolledAt"`
+	Completed   map[string]time.Time `json:"completed"`
+	CompletedAt *time.Time           `json:"completedAt,omitempty"`
 }
 
 type User struct {
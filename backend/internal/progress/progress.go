@@ -0,0 +1,136 @@
+// Package progress holds the XP rules for every activity and the level curve
+// that turns total XP into a level and rank title.
+package progress
+
+import "avidlearner/internal/models"
+
+// Rules is the XP table. Handlers ask it what an activity is worth and record
+// the result on the ledger entry, so changing the table never rewrites XP
+// already earned.
+type Rules struct {
+	QuizCorrect int // per correct quiz answer
+	// StreakBonus is added on every StreakEvery-th correct answer in a row.
+	StreakBonus int
+	StreakEvery int
+	LessonRead  int // the first time a lesson goes on the study list
+	// A typing session earns TypingSession plus one XP per TypingWPMStep
+	// words per minute, counting at most TypingWPMCap.
+	TypingSession int
+	TypingWPMStep int
+	TypingWPMCap  int
+	// ChallengeBonus is added to a passed challenge's own reward XP by
+	// difficulty.
+	ChallengeBonus map[string]int
+	// DailyComplete is earned by finishing the scored daily quiz.
+	DailyComplete int
+}
+
+// Default is the XP table used by the app.
+var Default = Rules{
+	QuizCorrect:   5,
+	StreakBonus:   10,
+	StreakEvery:   5,
+	LessonRead:    2,
+	TypingSession: 5,
+	TypingWPMStep: 10,
+	TypingWPMCap:  150,
+	ChallengeBonus: map[string]int{
+		"easy":     0,
+		"medium":   10,
+		"advanced": 25,
+		"expert":   40,
+	},
+	DailyComplete: 20,
+}
+
+// QuizAnswer is the XP for a quiz answer; streak is the run of correct
+// answers including this one.
+func (r Rules) QuizAnswer(correct bool, streak int) int {
+	if !correct {
+		return 0
+	}
+	xp := r.QuizCorrect
+	if r.StreakEvery > 0 && streak > 0 && streak%r.StreakEvery == 0 {
+		xp += r.StreakBonus
+	}
+	return xp
+}
+
+// Typing is the XP for a finished typing session at wpm words per minute.
+func (r Rules) Typing(wpm int) int {
+	xp := r.TypingSession
+	if r.TypingWPMStep > 0 {
+		xp += min(max(wpm, 0), r.TypingWPMCap) / r.TypingWPMStep
+	}
+	return xp
+}
+
+// Challenge is the XP for passing a challenge of the given difficulty whose
+// own reward is rewardXP.
+func (r Rules) Challenge(difficulty string, rewardXP int) int {
+	return rewardXP + r.ChallengeBonus[difficulty]
+}
+
+// levelStep scales the level curve: reaching level n takes
+// levelStep*n*(n-1)/2 XP, so each level costs levelStep more than the last
+// (100, 300, 600, 1000, ...).
+const levelStep = 100
+
+// titles are the rank titles, each held from its level until the next.
+var titles = []struct {
+	level int
+	title string
+}{
+	{1, "Novice"},
+	{3, "Apprentice"},
+	{5, "Practitioner"},
+	{8, "Engineer"},
+	{12, "Senior Engineer"},
+	{17, "Staff Engineer"},
+	{23, "Principal Engineer"},
+	{30, "Distinguished Engineer"},
+}
+
+// LevelXP is the total XP at which level n starts.
+func LevelXP(n int) int {
+	if n <= 1 {
+		return 0
+	}
+	return levelStep * n * (n - 1) / 2
+}
+
+// Title is the rank title held at level n.
+func Title(n int) string {
+	title := titles[0].title
+	for _, t := range titles {
+		if n >= t.level {
+			title = t.title
+		}
+	}
+	return title
+}
+
+// LevelFor places xp on the level curve.
+func LevelFor(xp int) models.Level {
+	n := 1
+	for LevelXP(n+1) <= xp {
+		n++
+	}
+	return models.Level{
+		Level:       n,
+		Title:       Title(n),
+		XP:          xp,
+		LevelXP:     LevelXP(n),
+		NextLevelXP: LevelXP(n + 1),
+	}
+}
+
+// LevelUp reports the level reached when XP went from before to after, or
+// nil when the level did not change.
+func LevelUp(before, after int) *models.LevelUp {
+	from, to := LevelFor(before), LevelFor(after)
+	if to.Level <= from.Level {
+		return nil
+	}
+	return &models.LevelUp{From: from.Level, To: to.Level, Title: to.Title, TitleChanged: to.Title != from.Title}
+}
@@ -0,0 +1,59 @@
+package progress
+
+import "testing"
+
+func TestLevelCurve(t *testing.T) {
+	for _, tc := range []struct {
+		xp    int
+		level int
+		title string
+	}{
+		{0, 1, "Novice"},
+		{99, 1, "Novice"},
+		{100, 2, "Novice"},
+		{300, 3, "Apprentice"},
+		{1000, 5, "Practitioner"},
+		{2799, 7, "Practitioner"},
+		{2800, 8, "Engineer"},
+	} {
+		got := LevelFor(tc.xp)
+		if got.Level != tc.level || got.Title != tc.title {
+			t.Errorf("LevelFor(%d) = %d %q, want %d %q", tc.xp, got.Level, got.Title, tc.level, tc.title)
+		}
+		if got.LevelXP > tc.xp || got.NextLevelXP <= tc.xp {
+			t.Errorf("LevelFor(%d): %d is not in [%d, %d)", tc.xp, tc.xp, got.LevelXP, got.NextLevelXP)
+		}
+	}
+}
+
+func TestLevelUp(t *testing.T) {
+	if up := LevelUp(90, 99); up != nil {
+		t.Fatalf("no level change, got %+v", up)
+	}
+	up := LevelUp(250, 320)
+	if up == nil || up.From != 2 || up.To != 3 || up.Title != "Apprentice" || !up.TitleChanged {
+		t.Fatalf("expected level 3 with a new title, got %+v", up)
+	}
+}
+
+func TestRules(t *testing.T) {
+	r := Default
+	if got := r.QuizAnswer(false, 0); got != 0 {
+		t.Errorf("wrong answer earned %d", got)
+	}
+	if got := r.QuizAnswer(true, 4); got != r.QuizCorrect {
+		t.Errorf("plain correct answer earned %d", got)
+	}
+	if got := r.QuizAnswer(true, r.StreakEvery); got != r.QuizCorrect+r.StreakBonus {
+		t.Errorf("streak answer earned %d", got)
+	}
+	if got := r.Typing(1000); got != r.TypingSession+r.TypingWPMCap/r.TypingWPMStep {
+		t.Errorf("typing XP should be capped, got %d", got)
+	}
+	if got := r.Challenge("advanced", 50); got != 50+r.ChallengeBonus["advanced"] {
+		t.Errorf("challenge XP %d", got)
+	}
+	if got := r.Challenge("unknown", 50); got != 50 {
+		t.Errorf("unknown difficulty should add nothing, got %d", got)
+	}
+}
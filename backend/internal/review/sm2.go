@@ -0,0 +1,69 @@
+// Package review schedules lessons for spaced repetition with the SM-2
+// algorithm: every answer grades recall from 0 to 5, and the grade moves the
+// lesson's ease factor, interval and next due date.
+package review
+
+import (
+	"math"
+	"time"
+
+	"avidlearner/internal/models"
+)
+
+// SM-2 parameters.
+const (
+	InitialEase = 2.5
+	MinEase     = 1.3
+
+	// Grades used for quiz answers, which are only right or wrong: a correct
+	// answer keeps the ease, a wrong one lowers it and restarts the interval.
+	QualityCorrect = 4
+	QualityWrong   = 2
+
+	passingQuality = 3
+	day            = 24 * time.Hour
+)
+
+// Grade maps a quiz answer to an SM-2 quality.
+func Grade(correct bool) int {
+	if correct {
+		return QualityCorrect
+	}
+	return QualityWrong
+}
+
+// Schedule applies one review graded quality (0-5) at now and returns the
+// updated item. A zero item is a lesson that has never been reviewed.
+func Schedule(item models.ReviewItem, quality int, now time.Time) models.ReviewItem {
+	quality = min(max(quality, 0), 5)
+	if item.Ease == 0 {
+		item.Ease = InitialEase
+	}
+
+	if quality >= passingQuality {
+		switch item.Repetitions {
+		case 0:
+			item.Interval = 1
+		case 1:
+			item.Interval = 6
+		default:
+			item.Interval = int(math.Round(float64(item.Interval) * item.Ease))
+		}
+		item.Repetitions++
+	} else {
+		item.Repetitions = 0
+		item.Interval = 1
+		item.Lapses++
+	}
+
+	miss := float64(5 - quality)
+	item.Ease = max(item.Ease+0.1-miss*(0.08+miss*0.02), MinEase)
+	item.LastReviewed = now
+	item.Due = now.Add(time.Duration(item.Interval) * day)
+	return item
+}
+
+// IsDue reports whether item should be reviewed at now.
+func IsDue(item models.ReviewItem, now time.Time) bool {
+	return !item.Due.After(now)
+}
@@ -0,0 +1,52 @@
+package review
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"avidlearner/internal/models"
+)
+
+func TestScheduleIntervals(t *testing.T) {
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	var item models.ReviewItem
+
+	// Correct answers grow the interval 1, 6, then by the ease factor.
+	for i, want := range []int{1, 6, 15, 38} {
+		item = Schedule(item, QualityCorrect, now)
+		if item.Interval != want {
+			t.Fatalf("review %d: interval %d, want %d", i, item.Interval, want)
+		}
+		if item.Ease != InitialEase {
+			t.Fatalf("review %d: quality 4 should keep the ease, got %v", i, item.Ease)
+		}
+		if !item.Due.Equal(now.Add(time.Duration(want) * day)) {
+			t.Fatalf("review %d: due %v", i, item.Due)
+		}
+		now = item.Due
+	}
+
+	// A wrong answer restarts the interval and lowers the ease.
+	item = Schedule(item, QualityWrong, now)
+	if item.Interval != 1 || item.Repetitions != 0 || item.Lapses != 1 {
+		t.Fatalf("unexpected item after a lapse: %+v", item)
+	}
+	if math.Abs(item.Ease-2.18) > 1e-9 {
+		t.Fatalf("ease after quality 2: %v, want 2.18", item.Ease)
+	}
+	if IsDue(item, now) || !IsDue(item, now.Add(day)) {
+		t.Fatalf("item should be due one day after the lapse")
+	}
+}
+
+func TestScheduleEaseFloor(t *testing.T) {
+	var item models.ReviewItem
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		item = Schedule(item, 0, now)
+	}
+	if item.Ease != MinEase {
+		t.Fatalf("ease should bottom out at %v, got %v", MinEase, item.Ease)
+	}
+}
@@ -0,0 +1,165 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"avidlearner/internal/models"
+	"avidlearner/internal/store"
+)
+
+const (
+	attemptsPageDefault = 20
+	attemptsPageMax     = 100
+)
+
+type attemptSummary struct {
+	ID         int64     `json:"id"`
+	Review     bool      `json:"review,omitempty"`
+	Timed      bool      `json:"timed,omitempty"`
+	Score      int       `json:"score"`
+	Points     int       `json:"points,omitempty"`
+	Total      int       `json:"total"`
+	Missed     int       `json:"missed"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// missReview is a missed question with the lesson to go back over.
+type missReview struct {
+	Index int `json:"index"`
+	models.AttemptQuestion
+	Lesson *lessonNote `json:"lesson,omitempty"` // nil when the lesson is gone
+}
+
+type lessonNote struct {
+	ID      string   `json:"id"`
+	Title   string   `json:"title"`
+	Explain string   `json:"explain"`
+	Tips    []string `json:"tips,omitempty"`
+}
+
+// handleQuizAttempts pages through the signed-in user's finished quizzes,
+// newest first.
+//
+//	GET /api/quiz/attempts?limit=20&before= -> { attempts: [{ id, review, timed, score, points, total, missed, startedAt, finishedAt }], nextBefore }
+func handleQuizAttempts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	user, err := requireAuthUser(w, r)
+	if err != nil {
+		return
+	}
+	if dataStore == nil {
+		http.Error(w, `{"error":"store not configured"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	q := r.URL.Query()
+	limit := attemptsPageDefault
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, `{"error":"invalid limit"}`, http.StatusBadRequest)
+			return
+		}
+		limit = min(n, attemptsPageMax)
+	}
+	var before int64
+	if v := q.Get("before"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			http.Error(w, `{"error":"invalid before"}`, http.StatusBadRequest)
+			return
+		}
+		before = n
+	}
+
+	// Fetch one extra attempt to know whether another page exists.
+	attempts, err := dataStore.QuizAttempts(user.ID, before, limit+1)
+	if err != nil {
+		log.Printf("Error reading quiz attempts for %s: %v", user.ID, err)
+		http.Error(w, `{"error":"unable to load attempts"}`, http.StatusInternalServerError)
+		return
+	}
+	resp := map[string]any{}
+	if len(attempts) > limit {
+		attempts = attempts[:limit]
+		resp["nextBefore"] = attempts[limit-1].Seq
+	}
+	out := make([]attemptSummary, 0, len(attempts))
+	for _, a := range attempts {
+		out = append(out, attemptSummary{
+			ID:         a.Seq,
+			Review:     a.Review,
+			Timed:      a.Timed,
+			Score:      a.Score,
+			Points:     a.Points,
+			Total:      a.Total,
+			Missed:     a.Total - a.Score,
+			StartedAt:  a.StartedAt,
+			FinishedAt: a.FinishedAt,
+		})
+	}
+	resp["attempts"] = out
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// handleQuizAttempt returns one attempt with every question as it was asked
+// and answered, plus a review of the misses with their lesson's explanation
+// and tips.
+//
+//	GET /api/quiz/attempts/{id} -> { attempt, misses: [{ index, ...question, lesson: { id, title, explain, tips } }] }
+func handleQuizAttempt(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	id, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/quiz/attempts/"), "/"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, `{"error":"invalid attempt id"}`, http.StatusBadRequest)
+		return
+	}
+	user, err := requireAuthUser(w, r)
+	if err != nil {
+		return
+	}
+	if dataStore == nil {
+		http.Error(w, `{"error":"store not configured"}`, http.StatusServiceUnavailable)
+		return
+	}
+	a, err := dataStore.QuizAttempt(user.ID, id)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, `{"error":"attempt not found"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error reading quiz attempt %d for %s: %v", id, user.ID, err)
+		http.Error(w, `{"error":"unable to load attempt"}`, http.StatusInternalServerError)
+		return
+	}
+
+	misses := []missReview{}
+	contentMu.RLock()
+	for i, q := range a.Questions {
+		if q.IsCorrect {
+			continue
+		}
+		m := missReview{Index: i, AttemptQuestion: q}
+		if l := findLessonByID(q.LessonID); l != nil {
+			m.Lesson = &lessonNote{ID: l.ID, Title: l.Title, Explain: l.Explain, Tips: l.Tips}
+		}
+		misses = append(misses, m)
+	}
+	contentMu.RUnlock()
+	_ = json.NewEncoder(w).Encode(map[string]any{"attempt": a, "misses": misses})
+}
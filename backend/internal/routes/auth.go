@@ -5,12 +5,14 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"log"
 	"net/http"
 	"regexp"
 	"strings"
 	"time"
 
 	"avidlearner/internal/auth"
+	"avidlearner/internal/lessons"
 	"avidlearner/internal/models"
 )
 
@@ -100,7 +102,8 @@ func handleSignup(w http.ResponseWriter, r *http.Request) {
 			Stats: models.UserStats{
 				LastActive: now,
 			},
-			UpdatedAt: now,
+			UpdatedAt:         now,
+			LessonRefsVersion: lessonRefsVersion,
 		},
 	}
 
@@ -109,17 +112,32 @@ func handleSignup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	session := getProfile(r)
+	sid := requestSessionID(r)
+	merged := false
+	updateUserByID(user.ID, func(u *models.User) {
+		merged = mergeSessionProgress(sid, session, u)
+		alignSessionWithUser(session, u)
+	})
+
 	token, err := authManager.IssueToken(user.ID, user.Username)
 	if err != nil {
 		http.Error(w, `{"error":"unable to issue token"}`, http.StatusInternalServerError)
 		return
 	}
 
-	_ = SaveUsers(usersPathOrDefault())
+	// Only the store: the session is still locked by this request, and the
+	// flusher persists it afterwards.
+	if dataStore != nil {
+		if err := dataStore.Flush(); err != nil {
+			log.Printf("Error saving users: %v", err)
+		}
+	}
 
 	resp := map[string]any{
-		"token": token,
-		"user":  user.Public(),
+		"token":         token,
+		"user":          user.Public(),
+		"sessionMerged": merged,
 	}
 	_ = json.NewEncoder(w).Encode(resp)
 }
@@ -157,14 +175,25 @@ func handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	session := getProfile(r)
+	sid := requestSessionID(r)
+	merged := false
 	updateUserByID(user.ID, func(u *models.User) {
+		merged = mergeSessionProgress(sid, session, u)
+		alignSessionWithUser(session, u)
 		u.Profile.Stats.LastActive = time.Now()
 		u.Profile.UpdatedAt = time.Now()
 	})
 
+	updated := getUserByID(user.ID)
+	if updated == nil {
+		http.Error(w, `{"error":"user not found"}`, http.StatusNotFound)
+		return
+	}
 	resp := map[string]any{
-		"token": token,
-		"user":  user.Public(),
+		"token":         token,
+		"user":          updated.Public(),
+		"sessionMerged": merged,
 	}
 	_ = json.NewEncoder(w).Encode(resp)
 }
@@ -194,56 +223,49 @@ func handleProfile(w http.ResponseWriter, r *http.Request) {
 		_ = json.NewEncoder(w).Encode(user.Public())
 		return
 	case http.MethodPatch:
+		// Only preferences the user owns can be written here. Coins, XP,
+		// scores and stats are derived from the ledger and only change
+		// through the handlers that award them.
+		var fields map[string]json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&fields); err != nil {
+			http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+			return
+		}
 		var req struct {
-			Coins        *int               `json:"coins"`
-			XP           *int               `json:"xp"`
-			QuizStreak   *int               `json:"quizStreak"`
-			TypingStreak *int               `json:"typingStreak"`
-			TypingBest   *int               `json:"typingBest"`
-			CodingScore  *int               `json:"codingScore"`
-			LessonsSeen  []string           `json:"lessonsSeen"`
-			Stats        *models.UserStats  `json:"stats"`
+			TypingStreak     *int
+			LeaderboardOptIn *bool
 		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		for name, value := range fields {
+			var err error
+			switch name {
+			case "typingStreak":
+				err = json.Unmarshal(value, &req.TypingStreak)
+			case "leaderboardOptIn":
+				err = json.Unmarshal(value, &req.LeaderboardOptIn)
+			default:
+				msg, _ := json.Marshal(map[string]string{"error": name + " is not user-editable"})
+				http.Error(w, string(msg), http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				msg, _ := json.Marshal(map[string]string{"error": "invalid " + name})
+				http.Error(w, string(msg), http.StatusBadRequest)
+				return
+			}
+		}
+		if req.TypingStreak != nil && *req.TypingStreak < 0 {
+			http.Error(w, `{"error":"typingStreak must not be negative"}`, http.StatusBadRequest)
 			return
 		}
 
 		updateUserByID(user.ID, func(u *models.User) {
-			if req.Coins != nil {
-				u.Profile.Coins = *req.Coins
-			}
-			if req.XP != nil {
-				u.Profile.XP = *req.XP
-			}
-			if req.QuizStreak != nil {
-				u.Profile.QuizStreak = *req.QuizStreak
-			}
 			if req.TypingStreak != nil {
 				u.Profile.TypingStreak = *req.TypingStreak
 			}
-			if req.TypingBest != nil {
-				if *req.TypingBest > u.Profile.TypingBest {
-					u.Profile.TypingBest = *req.TypingBest
-				}
-			}
-			if req.CodingScore != nil {
-				u.Profile.CodingScore = *req.CodingScore
-			}
-			if req.LessonsSeen != nil {
-				u.Profile.LessonsSeen = dedupeStrings(req.LessonsSeen)
-				u.Profile.Stats.LessonsRead = len(u.Profile.LessonsSeen)
-			}
-			if req.Stats != nil {
-				u.Profile.Stats.QuizzesTaken = req.Stats.QuizzesTaken
-				u.Profile.Stats.QuizCorrect = req.Stats.QuizCorrect
-				u.Profile.Stats.TypingSessions = req.Stats.TypingSessions
-				u.Profile.Stats.CodingSubmissions = req.Stats.CodingSubmissions
-				u.Profile.Stats.CodingPassed = req.Stats.CodingPassed
-				u.Profile.Stats.LastActive = time.Now()
-			} else {
-				u.Profile.Stats.LastActive = time.Now()
+			if req.LeaderboardOptIn != nil {
+				u.LeaderboardOptIn = *req.LeaderboardOptIn
 			}
+			u.Profile.Stats.LastActive = time.Now()
 			u.Profile.UpdatedAt = time.Now()
 		})
 
@@ -272,6 +294,7 @@ func handleSaveLesson(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
+		ID       string `json:"id"`
 		Title    string `json:"title"`
 		Category string `json:"category"`
 		Source   string `json:"source"`
@@ -280,21 +303,29 @@ func handleSaveLesson(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
 		return
 	}
+	req.ID = strings.TrimSpace(req.ID)
+	if l := findLessonByID(req.ID); l != nil {
+		req.Title, req.Category, req.Source = l.Title, l.Category, l.Source
+	}
 	req.Title = strings.TrimSpace(req.Title)
 	req.Category = strings.TrimSpace(req.Category)
 	if req.Title == "" || req.Category == "" {
 		http.Error(w, `{"error":"title and category required"}`, http.StatusBadRequest)
 		return
 	}
+	if req.ID == "" {
+		req.ID = lessons.NewID(req.Source, req.Title)
+	}
 
 	updateUserByID(user.ID, func(u *models.User) {
 		ensureProfileDefaults(&u.Profile)
 		for _, saved := range u.Profile.SavedLessons {
-			if strings.EqualFold(saved.Title, req.Title) && strings.EqualFold(saved.Category, req.Category) {
+			if saved.ID == req.ID {
 				return
 			}
 		}
 		u.Profile.SavedLessons = append(u.Profile.SavedLessons, models.SavedLesson{
+			ID:       req.ID,
 			Title:    req.Title,
 			Category: req.Category,
 			Source:   req.Source,
@@ -324,6 +355,7 @@ func handleRemoveLesson(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
+		ID       string `json:"id"`
 		Title    string `json:"title"`
 		Category string `json:"category"`
 	}
@@ -331,10 +363,11 @@ func handleRemoveLesson(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
 		return
 	}
+	req.ID = strings.TrimSpace(req.ID)
 	req.Title = strings.TrimSpace(req.Title)
 	req.Category = strings.TrimSpace(req.Category)
-	if req.Title == "" {
-		http.Error(w, `{"error":"title required"}`, http.StatusBadRequest)
+	if req.ID == "" && req.Title == "" {
+		http.Error(w, `{"error":"id or title required"}`, http.StatusBadRequest)
 		return
 	}
 
@@ -342,6 +375,12 @@ func handleRemoveLesson(w http.ResponseWriter, r *http.Request) {
 		ensureProfileDefaults(&u.Profile)
 		filtered := u.Profile.SavedLessons[:0]
 		for _, saved := range u.Profile.SavedLessons {
+			if req.ID != "" {
+				if saved.ID != req.ID {
+					filtered = append(filtered, saved)
+				}
+				continue
+			}
 			if strings.EqualFold(saved.Title, req.Title) {
 				if req.Category == "" || strings.EqualFold(saved.Category, req.Category) {
 					continue
@@ -0,0 +1,228 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"avidlearner/internal/sandbox"
+)
+
+// challengeCacheMu is held for reading by sandboxed builds using the build
+// cache and for writing while trimBuildCache removes entries from it, so no
+// build sees an entry vanish halfway.
+var challengeCacheMu sync.RWMutex
+
+// startBuildCache prepares the sandbox build cache for grading: it builds
+// the tools and compiles the standard library packages the challenges
+// import, then keeps the cache under maxBytes until ctx is done. Grading
+// works without it, only more slowly until the cache fills.
+func startBuildCache(ctx context.Context, maxBytes int64) {
+	if !sandboxRunner.Available() {
+		return
+	}
+	go func() {
+		start := time.Now()
+		if n, err := prewarmChallengeBuilds(ctx); err != nil {
+			log.Printf("warning: prewarming the sandbox build cache failed: %v", err)
+		} else {
+			log.Printf("sandbox build cache prewarmed with %d standard library packages in %v", n, time.Since(start).Round(time.Millisecond))
+		}
+		trim := func() {
+			dir, err := challengeCacheDir()
+			if err != nil {
+				return
+			}
+			if n, err := trimBuildCache(dir, maxBytes); err != nil {
+				log.Printf("warning: trimming the sandbox build cache failed: %v", err)
+			} else if n > 0 {
+				log.Printf("sandbox build cache trimmed by %d entries", n)
+			}
+		}
+		trim()
+		ticker := time.NewTicker(challengeCacheTrimEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				trim()
+			}
+		}
+	}()
+}
+
+// trimBuildCache removes the least recently used entries of the go build
+// cache in dir until it holds no more than three quarters of maxBytes, so it
+// is not trimmed again on every tick. The go command refreshes an entry's
+// modification time when it uses it. It returns the number of files removed.
+func trimBuildCache(dir string, maxBytes int64) (int, error) {
+	type entry struct {
+		path string
+		size int64
+		used time.Time
+	}
+	var entries []entry
+	var total int64
+	// Entries live in the 256 two-hex-digit subdirectories; the files at the
+	// top are the go command's own.
+	subdirs, err := filepath.Glob(filepath.Join(dir, "[0-9a-f][0-9a-f]"))
+	if err != nil {
+		return 0, err
+	}
+	for _, sub := range subdirs {
+		files, err := os.ReadDir(sub)
+		if err != nil {
+			return 0, err
+		}
+		for _, f := range files {
+			info, err := f.Info()
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			entries = append(entries, entry{filepath.Join(sub, f.Name()), info.Size(), info.ModTime()})
+			total += info.Size()
+		}
+	}
+	if total <= maxBytes {
+		return 0, nil
+	}
+
+	challengeCacheMu.Lock()
+	defer challengeCacheMu.Unlock()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].used.Before(entries[j].used) })
+	removed := 0
+	for _, e := range entries {
+		if total <= maxBytes/4*3 {
+			break
+		}
+		if err := os.Remove(e.path); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		total -= e.size
+		removed++
+	}
+	return removed, nil
+}
+
+// prewarmChallengeBuilds builds test2json and compiles, in the sandbox and
+// with the flags submissions are built with, a test package importing every
+// standard library package the pro challenges and their hidden tests
+// import. It returns how many packages that was.
+func prewarmChallengeBuilds(ctx context.Context) (int, error) {
+	goroot, err := goRoot()
+	if err != nil {
+		return 0, err
+	}
+	cache, err := challengeCacheDir()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := challengeTest2JSON(); err != nil {
+		return 0, err
+	}
+	imports := challengeStdImports()
+
+	dir, err := os.MkdirTemp("", "avid-prewarm-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(dir)
+	var src strings.Builder
+	src.WriteString("package challenge\n\nimport (\n")
+	for _, path := range imports {
+		if path != "testing" {
+			fmt.Fprintf(&src, "\t_ %s\n", strconv.Quote(path))
+		}
+	}
+	src.WriteString("\t\"testing\"\n)\n\nfunc TestWarm(t *testing.T) {}\n")
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(challengeModule), 0o644); err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "warm_test.go"), []byte(src.String()), 0o644); err != nil {
+		return 0, err
+	}
+
+	challengeCacheMu.RLock()
+	defer challengeCacheMu.RUnlock()
+	buildCtx, cancel := context.WithTimeout(ctx, challengePrewarmTimeout)
+	defer cancel()
+	var stderr bytes.Buffer
+	err = sandboxRunner.Run(buildCtx, sandbox.Command{
+		Path:   filepath.Join(goroot, "bin", "go"),
+		Args:   challengeBuildArgs("warm.test"),
+		Env:    challengeBuildEnv(goroot, cache, dir),
+		Dir:    dir,
+		Mounts: []sandbox.Mount{{Path: goroot}, {Path: cache, Writable: true}},
+		Limits: sandbox.Limits{CPU: challengePrewarmTimeout},
+		Stderr: &stderr,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return len(imports), nil
+}
+
+// challengeBuildArgs is the go command compiling a grading workspace into
+// the test binary out. Prewarming must use the same flags, or the packages it
+// compiles would not be the ones submissions look up in the cache.
+func challengeBuildArgs(out string) []string {
+	return []string{"go", "test", "-c", "-trimpath", "-o", out, "."}
+}
+
+// challengeStdImports lists the standard library packages imported by the
+// pro challenges' starter code and hidden tests, sorted.
+func challengeStdImports() []string {
+	contentMu.RLock()
+	var sources []string
+	var ids []string
+	for _, ch := range proChallenges {
+		sources = append(sources, ch.Starter.Code)
+		ids = append(ids, ch.ID)
+	}
+	contentMu.RUnlock()
+	for _, id := range ids {
+		if path, err := resolveChallengeTestPath(id); err == nil {
+			if data, err := readChallengeTest(path); err == nil {
+				sources = append(sources, string(data))
+			}
+		}
+	}
+
+	seen := map[string]bool{"testing": true}
+	fset := token.NewFileSet()
+	for _, src := range sources {
+		f, err := parser.ParseFile(fset, "", src, parser.ImportsOnly)
+		if err != nil {
+			continue
+		}
+		for _, spec := range f.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err != nil || path == "C" {
+				continue
+			}
+			// Standard library paths have no dot in their first element.
+			if first, _, _ := strings.Cut(path, "/"); !strings.Contains(first, ".") {
+				seen[path] = true
+			}
+		}
+	}
+	imports := make([]string, 0, len(seen))
+	for path := range seen {
+		imports = append(imports, path)
+	}
+	slices.Sort(imports)
+	return imports
+}
@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"avidlearner/internal/lessons"
+)
+
+// SetTaxonomy installs the category taxonomy. It applies from the next
+// UpdateLessonMap; a nil taxonomy keeps categories as the sources report them.
+func SetTaxonomy(t *lessons.Taxonomy) {
+	contentMu.Lock()
+	lessonTaxonomy = t
+	contentMu.Unlock()
+}
+
+// canonicalCategory resolves a category from a request, which may be an
+// alias. Callers hold contentMu.
+func canonicalCategory(cat string) string {
+	return lessonTaxonomy.Canonical(cat)
+}
+
+// handleCategories serves the category taxonomy as a tree with lesson counts.
+//
+//	GET /api/categories -> { categories: [{ name, label, description, icon, aliases, count, total, children }] }
+//
+// count is the number of lessons filed directly under a category and total
+// includes its descendants. Categories found in lessons but missing from the
+// taxonomy are listed at the top level.
+func handleCategories(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"categories": categoryTree})
+}
@@ -0,0 +1,148 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"avidlearner/internal/models"
+)
+
+// ContentStatus describes the live version of one content file and the last
+// reload attempt. A failed reload keeps the previous version live, so Error
+// can be set while Version still names what is being served.
+type ContentStatus struct {
+	Name     string     `json:"name"`
+	Path     string     `json:"path"`
+	Version  string     `json:"version,omitempty"` // content hash of the live version
+	Items    int        `json:"items"`
+	LoadedAt time.Time  `json:"loadedAt"`
+	Error    string     `json:"error,omitempty"`
+	ErrorAt  *time.Time `json:"errorAt,omitempty"`
+}
+
+var (
+	contentStatusMu  sync.Mutex
+	contentStatus    = map[string]ContentStatus{}
+	contentLastCheck time.Time
+	adminUsernames   = map[string]bool{}
+	adminUsernamesMu sync.RWMutex
+)
+
+// readsContent holds contentMu for reading while next runs, so a handler
+// never sees a half-swapped lesson map or challenge list. Only wrap handlers
+// that do in-memory work; slow handlers should copy what they need instead
+// (see lookupProChallenge).
+func readsContent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		contentMu.RLock()
+		defer contentMu.RUnlock()
+		next(w, r)
+	}
+}
+
+func lookupProChallenge(id string) (models.ProChallenge, bool) {
+	contentMu.RLock()
+	defer contentMu.RUnlock()
+	ch, ok := proChallengesByID[id]
+	return ch, ok
+}
+
+// ReportContentLoaded records that a content file was (re)loaded and clears
+// any earlier error.
+func ReportContentLoaded(name, path, version string, items int) {
+	contentStatusMu.Lock()
+	defer contentStatusMu.Unlock()
+	contentStatus[name] = ContentStatus{
+		Name:     name,
+		Path:     path,
+		Version:  version,
+		Items:    items,
+		LoadedAt: time.Now(),
+	}
+}
+
+// ReportContentError records a rejected reload; the previous version stays.
+func ReportContentError(name, path string, err error) {
+	contentStatusMu.Lock()
+	defer contentStatusMu.Unlock()
+	st := contentStatus[name]
+	st.Name = name
+	st.Path = path
+	st.Error = err.Error()
+	now := time.Now()
+	st.ErrorAt = &now
+	contentStatus[name] = st
+}
+
+// ReportContentChecked records the time of the latest poll.
+func ReportContentChecked(at time.Time) {
+	contentStatusMu.Lock()
+	contentLastCheck = at
+	contentStatusMu.Unlock()
+}
+
+// ContentStatuses returns the status of every content file by name, and the
+// time of the latest poll.
+func ContentStatuses() ([]ContentStatus, time.Time) {
+	contentStatusMu.Lock()
+	defer contentStatusMu.Unlock()
+	files := make([]ContentStatus, 0, len(contentStatus))
+	for _, st := range contentStatus {
+		files = append(files, st)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, contentLastCheck
+}
+
+// SetAdmins sets the usernames allowed to use /api/admin endpoints.
+func SetAdmins(usernames []string) {
+	admins := make(map[string]bool, len(usernames))
+	for _, name := range usernames {
+		if name = normalizeUsername(name); name != "" {
+			admins[name] = true
+		}
+	}
+	adminUsernamesMu.Lock()
+	adminUsernames = admins
+	adminUsernamesMu.Unlock()
+}
+
+func requireAdmin(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	user, err := requireAuthUser(w, r)
+	if err != nil {
+		return nil, false
+	}
+	adminUsernamesMu.RLock()
+	ok := adminUsernames[normalizeUsername(user.Username)]
+	adminUsernamesMu.RUnlock()
+	if !ok {
+		http.Error(w, `{"error":"admin only"}`, http.StatusForbidden)
+		return nil, false
+	}
+	return user, true
+}
+
+// handleAdminContent reports the live version of every hot-reloaded content
+// file and the last reload error, if any.
+//
+//	GET /api/admin/content -> { lastCheck, files: [ContentStatus] }
+func handleAdminContent(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	files, lastCheck := ContentStatuses()
+	resp := map[string]any{"files": files}
+	if !lastCheck.IsZero() {
+		resp["lastCheck"] = lastCheck
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
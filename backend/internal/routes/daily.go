@@ -0,0 +1,296 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	mrand "math/rand"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"avidlearner/internal/models"
+	"avidlearner/internal/progress"
+)
+
+// dailyKey is the UTC day t falls on; the daily quiz and challenge change at
+// midnight UTC for everyone.
+func dailyKey(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+// dailyRand is seeded from the day and what is being picked, so every server
+// makes the same picks for a given day.
+func dailyRand(day, purpose string) *mrand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(day + "/" + purpose))
+	return mrand.New(mrand.NewSource(int64(h.Sum64())))
+}
+
+// dailyLessons are the lessons the daily quiz picks from: built-in lessons
+// in ID order, so the pick depends neither on map order nor on what a server
+// has fetched from external sources. Callers hold contentMu for reading.
+func dailyLessons() []models.Lesson {
+	var pool []models.Lesson
+	for _, l := range allLessons() {
+		if l.Source == "" || l.Source == "local" {
+			pool = append(pool, l)
+		}
+	}
+	if len(pool) == 0 {
+		pool = allLessons()
+	}
+	sort.Slice(pool, func(i, j int) bool { return pool[i].ID < pool[j].ID })
+	return pool
+}
+
+// dailyQuiz builds day's quiz: the same generated questions, options and
+// order for everyone. Callers hold contentMu for reading.
+func dailyQuiz(day string) []models.QuizQuestion {
+	pool := dailyLessons()
+	rng := dailyRand(day, "quiz")
+	var quiz []models.QuizQuestion
+	for _, i := range rng.Perm(len(pool))[:min(dailyQuizSize, len(pool))] {
+		quiz = append(quiz, buildQuizForLesson(pool[i], rng.Shuffle))
+	}
+	return quiz
+}
+
+// dailyChallenge picks day's pro challenge. Callers hold contentMu for
+// reading.
+func dailyChallenge(day string) (models.ProChallenge, bool) {
+	if len(proChallenges) == 0 {
+		return models.ProChallenge{}, false
+	}
+	list := slices.Clone(proChallenges)
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list[dailyRand(day, "challenge").Intn(len(list))], true
+}
+
+// applyDailyResult folds a daily quiz or challenge result into d. The
+// streak continues when the previous day done was the day before, stays when
+// it is the same day and restarts at 1 otherwise; a result already counted
+// for the day is ignored.
+func applyDailyResult(d *models.DailyProgress, e models.LedgerEntry) {
+	day, err := time.Parse(time.DateOnly, e.Ref)
+	if err != nil || e.Ref < d.LastDone {
+		return
+	}
+	scores := &d.Scores
+	if e.Kind == models.LedgerDailyChallenge {
+		scores = &d.Challenges
+	}
+	if _, done := (*scores)[e.Ref]; done {
+		return
+	}
+	if e.Ref != d.LastDone {
+		if d.LastDone == dailyKey(day.AddDate(0, 0, -1)) {
+			d.Streak++
+		} else {
+			d.Streak = 1
+		}
+		d.LastDone = e.Ref
+		d.BestStreak = max(d.BestStreak, d.Streak)
+	}
+	if *scores == nil {
+		*scores = map[string]models.DailyScore{}
+	}
+	(*scores)[e.Ref] = models.DailyScore{Score: e.Score, Total: e.Total, FinishedAt: e.At}
+	cutoff := dailyKey(day.AddDate(0, 0, 1-dailyScoresKept))
+	for _, m := range []map[string]models.DailyScore{d.Scores, d.Challenges} {
+		for k := range m {
+			if k < cutoff {
+				delete(m, k)
+			}
+		}
+	}
+}
+
+// finishDailyQuiz records p's finished daily quiz as userID's result for
+// the day and adds the streak and completion XP to resp.
+func finishDailyQuiz(resp models.SessionState, userID string, p *models.Profile, xpBefore int, now time.Time) models.SessionState {
+	score := 0
+	for _, q := range p.QuizAnswers {
+		if q.IsCorrect {
+			score++
+		}
+	}
+	xp := progress.Default.DailyComplete
+	updateUserByID(userID, func(u *models.User) {
+		recordLedgerLocked(u, models.LedgerEntry{
+			Kind:  models.LedgerDailyQuiz,
+			Ref:   p.Daily,
+			XP:    xp,
+			Score: score,
+			Total: len(p.CurrentQuiz),
+			At:    now,
+		})
+		alignSessionWithUser(p, u)
+		resp.DailyStreak = dailyStreak(u.Profile.Daily, now)
+	})
+	resp.Daily = p.Daily
+	resp.XPEarned += xp
+	resp.XPTotal = p.XP
+	resp.LevelUp = progress.LevelUp(xpBefore, p.XP)
+	resp.Message = fmt.Sprintf("Daily quiz done: %d/%d. Streak: %d day(s). +%d XP", score, len(p.CurrentQuiz), resp.DailyStreak, xp)
+	return resp
+}
+
+// recordDailyChallengeLocked records res as u's scored daily challenge for
+// day, scoring the tests that passed, unless u already has one: only the
+// first graded submission of the day counts and later ones are practice. It
+// reports whether res was recorded. Callers hold usersMu.
+func recordDailyChallengeLocked(u *models.User, day string, res models.ChallengeTestResult, now time.Time) bool {
+	if _, done := u.Profile.Daily.Challenges[day]; done {
+		return false
+	}
+	score := max(res.Total-len(res.Failures), 0)
+	if res.Passed {
+		score = res.Total
+	}
+	recordLedgerLocked(u, models.LedgerEntry{
+		Kind:    models.LedgerDailyChallenge,
+		Ref:     day,
+		Correct: res.Passed,
+		Score:   score,
+		Total:   res.Total,
+		At:      now,
+	})
+	return true
+}
+
+// dailyStreak is d's streak as of today: a streak whose last day was before
+// yesterday is broken.
+func dailyStreak(d models.DailyProgress, now time.Time) int {
+	if d.LastDone == dailyKey(now) || d.LastDone == dailyKey(now.AddDate(0, 0, -1)) {
+		return d.Streak
+	}
+	return 0
+}
+
+// handleDaily describes today's daily quiz and challenge, and for a
+// signed-in user whether today's scored attempts are used and their streak.
+//
+//	GET /api/daily -> { date, total, challenge: { id, title, difficulty, topics }, started, completed, score, challengeCompleted, challengeScore, streak, bestStreak }
+func handleDaily(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	now := time.Now()
+	day := dailyKey(now)
+	resp := map[string]any{
+		"date":  day,
+		"total": min(dailyQuizSize, len(dailyLessons())),
+	}
+	if ch, ok := dailyChallenge(day); ok {
+		resp["challenge"] = map[string]any{
+			"id":         ch.ID,
+			"title":      ch.Title,
+			"difficulty": ch.Difficulty,
+			"topics":     ch.Topics,
+		}
+	}
+	if bearerToken(r) != "" {
+		if user, err := authUserFromRequest(r); err == nil {
+			usersMu.RLock()
+			d := user.Profile.Daily
+			score, done := d.Scores[day]
+			chScore, chDone := d.Challenges[day]
+			started := user.Profile.DailyStarted == day
+			usersMu.RUnlock()
+			resp["started"] = started
+			resp["completed"] = done
+			if done {
+				resp["score"] = score
+			}
+			resp["challengeCompleted"] = chDone
+			if chDone {
+				resp["challengeScore"] = chScore
+			}
+			resp["streak"] = dailyStreak(d, now)
+			resp["bestStreak"] = d.BestStreak
+		}
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// dailyEntry is one row of a daily leaderboard.
+type dailyEntry struct {
+	models.LeaderboardEntry
+	Total  int `json:"total"`
+	Streak int `json:"streak"`
+}
+
+// handleDailyLeaderboard ranks the scored daily quizzes, or with
+// kind=challenge the scored daily challenges, of opted-in users for one day
+// by score, then by who finished first.
+//
+//	GET /api/daily/leaderboard?date=YYYY-MM-DD&kind=quiz|challenge -> { date, kind, entries: [{ name, score, total, level, title, streak, date }] }
+func handleDailyLeaderboard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	now := time.Now()
+	day := strings.TrimSpace(r.URL.Query().Get("date"))
+	if day == "" {
+		day = dailyKey(now)
+	} else if _, err := time.Parse(time.DateOnly, day); err != nil {
+		http.Error(w, `{"error":"invalid date"}`, http.StatusBadRequest)
+		return
+	}
+	kind := strings.TrimSpace(r.URL.Query().Get("kind"))
+	mode := "daily"
+	switch kind {
+	case "", "quiz":
+		kind = "quiz"
+	case "challenge":
+		mode = "daily-challenge"
+	default:
+		http.Error(w, `{"error":"unknown kind"}`, http.StatusBadRequest)
+		return
+	}
+
+	entries := []dailyEntry{}
+	usersMu.RLock()
+	for _, u := range usersByID {
+		scores := u.Profile.Daily.Scores
+		if kind == "challenge" {
+			scores = u.Profile.Daily.Challenges
+		}
+		score, ok := scores[day]
+		if !ok || !u.LeaderboardOptIn {
+			continue
+		}
+		level := progress.LevelFor(u.Profile.XP)
+		entries = append(entries, dailyEntry{
+			LeaderboardEntry: models.LeaderboardEntry{
+				Name:  u.Username,
+				Score: score.Score,
+				Mode:  mode,
+				Date:  score.FinishedAt,
+				Level: level.Level,
+				Title: level.Title,
+			},
+			Total:  score.Total,
+			Streak: dailyStreak(u.Profile.Daily, now),
+		})
+	}
+	usersMu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Score != entries[j].Score {
+			return entries[i].Score > entries[j].Score
+		}
+		return entries[i].Date.Before(entries[j].Date)
+	})
+	if len(entries) > leaderboardLimit {
+		entries = entries[:leaderboardLimit]
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"date": day, "kind": kind, "entries": entries})
+}
@@ -0,0 +1,663 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"avidlearner/internal/models"
+	"avidlearner/internal/progress"
+	"avidlearner/internal/sandbox"
+	"avidlearner/internal/tracks"
+)
+
+const (
+	defaultGradingWorkers    = 2
+	defaultGradingQueueDepth = 20
+	defaultGradingCacheSize  = 1 << 30
+)
+
+// GradingOptions sizes the pro challenge grading queue.
+type GradingOptions struct {
+	Workers    int   // submissions compiled and tested at once
+	QueueDepth int   // submissions waiting beyond those; more are refused with 429
+	CacheSize  int64 // bytes the shared build cache is trimmed to stay under
+}
+
+// Job statuses, in order. A job ends as done, with a result, or as error
+// when it could not be graded at all.
+const (
+	jobQueued  = "queued"
+	jobRunning = "running"
+	jobDone    = "done"
+	jobError   = "error"
+)
+
+// errGradingStopped is the error of jobs that were never graded because the
+// server was shutting down.
+const errGradingStopped = "grading stopped: the server is shutting down"
+
+// gradingJob is one pro challenge submission. The fields below mu change as
+// it is graded; changed is closed and replaced on every change, so streams
+// can wait for the next one.
+type gradingJob struct {
+	id     string
+	sid    string // session that submitted it
+	userID string // signed-in user at submission, if any
+	ch     models.ProChallenge
+	code   string
+
+	mu        sync.Mutex
+	status    string
+	created   time.Time
+	started   time.Time
+	finished  time.Time
+	output    []byte         // live test output, at most gradingOutputMax
+	truncated bool           // output went over gradingOutputMax
+	result    map[string]any // the graded response, once done
+	err       string
+	changed   chan struct{}
+}
+
+// gradingJobView is the JSON form of a job.
+type gradingJobView struct {
+	ID          string         `json:"id"`
+	ChallengeID string         `json:"challengeId"`
+	Status      string         `json:"status"`
+	Position    int            `json:"position,omitempty"` // 1 is next, while queued
+	CreatedAt   time.Time      `json:"createdAt"`
+	StartedAt   *time.Time     `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time     `json:"finishedAt,omitempty"`
+	Output      string         `json:"output"`
+	Result      map[string]any `json:"result,omitempty"`
+	Error       string         `json:"error,omitempty"`
+}
+
+func newGradingJob(sid, userID string, ch models.ProChallenge, code string) (*gradingJob, error) {
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	return &gradingJob{
+		id:      id,
+		sid:     sid,
+		userID:  userID,
+		ch:      ch,
+		code:    code,
+		status:  jobQueued,
+		created: time.Now(),
+		changed: make(chan struct{}),
+	}, nil
+}
+
+// update applies fn to j under its lock and wakes anyone watching it.
+func (j *gradingJob) update(fn func()) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	fn()
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// Write appends live test output, so j can be handed to the sandbox as
+// stdout and stderr.
+func (j *gradingJob) Write(b []byte) (int, error) {
+	j.update(func() {
+		if j.truncated {
+			return
+		}
+		if room := gradingOutputMax - len(j.output); len(b) > room {
+			j.output = append(j.output, b[:room]...)
+			j.output = append(j.output, "\n... output truncated\n"...)
+			j.truncated = true
+			return
+		}
+		j.output = append(j.output, b...)
+	})
+	return len(b), nil
+}
+
+// visibleTo reports whether r comes from the session or user that submitted
+// j. Job IDs are unguessable, but results are still only shown to their
+// owner.
+func (j *gradingJob) visibleTo(r *http.Request) bool {
+	if c, err := r.Cookie("sid"); err == nil && c.Value != "" && c.Value == j.sid {
+		return true
+	}
+	if j.userID == "" || bearerToken(r) == "" {
+		return false
+	}
+	user, err := authUserFromRequest(r)
+	return err == nil && user.ID == j.userID
+}
+
+func (j *gradingJob) view(position int) gradingJobView {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	v := gradingJobView{
+		ID:          j.id,
+		ChallengeID: j.ch.ID,
+		Status:      j.status,
+		CreatedAt:   j.created,
+		Output:      string(j.output),
+		Result:      j.result,
+		Error:       j.err,
+	}
+	if j.status == jobQueued {
+		v.Position = position
+	}
+	if !j.started.IsZero() {
+		v.StartedAt = &j.started
+	}
+	if !j.finished.IsZero() {
+		v.FinishedAt = &j.finished
+	}
+	return v
+}
+
+// challengeRunner compiles and tests a submission, copying the test output
+// to live as it is produced. It is runChallengeTests outside of tests.
+type challengeRunner func(ctx context.Context, ch models.ProChallenge, source string, live io.Writer) (models.ChallengeTestResult, error)
+
+// gradingQueue grades pro challenge submissions on a fixed number of
+// workers, so a burst of submissions waits in line instead of starting a
+// toolchain build each. Finished jobs are kept for gradingJobTTL so their
+// result can still be fetched.
+type gradingQueue struct {
+	opts    GradingOptions
+	run     challengeRunner
+	pending chan *gradingJob
+	done    <-chan struct{} // closed when the workers stop
+
+	mu   sync.Mutex
+	jobs map[string]*gradingJob
+	avg  time.Duration // moving average of grading time, for Retry-After
+}
+
+// grading is the queue behind /api/prochallenge/submit; nil until
+// StartGrading.
+var grading *gradingQueue
+
+func newGradingQueue(opts GradingOptions, run challengeRunner) *gradingQueue {
+	if opts.Workers <= 0 {
+		opts.Workers = defaultGradingWorkers
+	}
+	if opts.QueueDepth <= 0 {
+		opts.QueueDepth = defaultGradingQueueDepth
+	}
+	if opts.CacheSize <= 0 {
+		opts.CacheSize = defaultGradingCacheSize
+	}
+	return &gradingQueue{
+		opts:    opts,
+		run:     run,
+		pending: make(chan *gradingJob, opts.QueueDepth),
+		jobs:    map[string]*gradingJob{},
+	}
+}
+
+// StartGrading starts the grading workers, and the prewarming and trimming
+// of the build cache they share. They stop when ctx is done, failing the
+// jobs they are running, those still queued and any submitted later.
+func StartGrading(ctx context.Context, opts GradingOptions) {
+	q := newGradingQueue(opts, runChallengeTests)
+	q.start(ctx)
+	grading = q
+	startBuildCache(ctx, q.opts.CacheSize)
+}
+
+func (q *gradingQueue) start(ctx context.Context) {
+	q.done = ctx.Done()
+	for range q.opts.Workers {
+		go q.work(ctx)
+	}
+}
+
+func (q *gradingQueue) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			q.drain()
+			return
+		case j := <-q.pending:
+			if ctx.Err() != nil {
+				j.fail(errGradingStopped)
+				continue
+			}
+			q.grade(ctx, j)
+		}
+	}
+}
+
+// drain fails the jobs still queued once the workers have stopped, so their
+// streams end and their status is final. submit queues under q.mu only
+// while the workers run, so nothing is queued after a drain.
+func (q *gradingQueue) drain() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		select {
+		case j := <-q.pending:
+			j.fail(errGradingStopped)
+		default:
+			return
+		}
+	}
+}
+
+// fail ends j with msg as its error.
+func (j *gradingJob) fail(msg string) {
+	j.update(func() {
+		j.status = jobError
+		j.err = msg
+		j.finished = time.Now()
+	})
+}
+
+// submit queues j, or reports false when the queue is full. Once the
+// workers have stopped, j is failed instead of queued.
+func (q *gradingQueue) submit(j *gradingJob) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pruneLocked(time.Now())
+	select {
+	case <-q.done:
+		j.fail(errGradingStopped)
+	default:
+		select {
+		case q.pending <- j:
+		default:
+			return false
+		}
+	}
+	q.jobs[j.id] = j
+	return true
+}
+
+// lookup returns the job with id and, while it is queued, its place in
+// line.
+func (q *gradingQueue) lookup(id string) (*gradingJob, int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.jobs[id]
+	if !ok {
+		return nil, 0
+	}
+	j.mu.Lock()
+	created, queued := j.created, j.status == jobQueued
+	j.mu.Unlock()
+	if !queued {
+		return j, 0
+	}
+	position := 1
+	for _, other := range q.jobs {
+		if other == j {
+			continue
+		}
+		other.mu.Lock()
+		if other.status == jobQueued && other.created.Before(created) {
+			position++
+		}
+		other.mu.Unlock()
+	}
+	return j, position
+}
+
+// retryAfter estimates when a full queue next has room: one slot frees up
+// about every average grading time divided by the workers.
+func (q *gradingQueue) retryAfter() time.Duration {
+	q.mu.Lock()
+	avg := q.avg
+	q.mu.Unlock()
+	if avg == 0 {
+		avg = challengeTestTimeout
+	}
+	return max(avg/time.Duration(q.opts.Workers), time.Second)
+}
+
+func (q *gradingQueue) pruneLocked(now time.Time) {
+	for id, j := range q.jobs {
+		j.mu.Lock()
+		expired := !j.finished.IsZero() && now.Sub(j.finished) > gradingJobTTL
+		j.mu.Unlock()
+		if expired {
+			delete(q.jobs, id)
+		}
+	}
+}
+
+// grade runs j and applies its rewards. This is the only place a
+// submission's rewards are applied, once per job.
+func (q *gradingQueue) grade(ctx context.Context, j *gradingJob) {
+	j.update(func() {
+		j.status = jobRunning
+		j.started = time.Now()
+	})
+	res, err := q.run(ctx, j.ch, j.code, j)
+	if err != nil {
+		msg := fmt.Sprintf("test execution failed: %v", err)
+		if errors.Is(err, sandbox.ErrUnavailable) {
+			msg = "code execution sandbox unavailable"
+		} else if ctx.Err() != nil {
+			msg = errGradingStopped
+		}
+		log.Printf("Grading job %s for %s: %v", j.id, j.ch.ID, err)
+		j.fail(msg)
+		return
+	}
+
+	resp := applyChallengeResult(j.sid, j.userID, j.ch, res)
+	var took time.Duration
+	j.update(func() {
+		j.status = jobDone
+		j.result = resp
+		j.finished = time.Now()
+		took = j.finished.Sub(j.started)
+	})
+
+	q.mu.Lock()
+	if q.avg == 0 {
+		q.avg = took
+	} else {
+		q.avg = (4*q.avg + took) / 5
+	}
+	q.mu.Unlock()
+}
+
+// applyChallengeResult rewards a graded submission to the session and user
+// that made it and returns the submit response. The session is locked as an
+// API request would lock it; when it has expired since, a signed-in user
+// still gets the rewards.
+func applyChallengeResult(sid, userID string, ch models.ProChallenge, res models.ChallengeTestResult) map[string]any {
+	var resp map[string]any
+	apply := func(p *models.Profile) {
+		resp = rewardChallenge(p, userID, ch, res)
+	}
+	if sid == "" || !sessions.update(sid, apply) {
+		apply(newProfile())
+	}
+	return resp
+}
+
+func rewardChallenge(p *models.Profile, userID string, ch models.ProChallenge, res models.ChallengeTestResult) map[string]any {
+	if p.HintIdx == nil {
+		p.HintIdx = map[string]int{}
+	}
+	xp, xpBefore := 0, p.XP
+	if res.Passed {
+		xp = progress.Default.Challenge(ch.Difficulty, ch.Reward.XP)
+		p.Coins += ch.Reward.Coins
+		p.XP += xp
+		p.CodingScore += ch.Reward.XP // Track coding score for leaderboard
+	}
+	var tracksAdvanced []string
+	var daily map[string]any
+	if userID != "" {
+		trackList := lookupTracks()
+		now := time.Now()
+		day := dailyKey(now)
+		contentMu.RLock()
+		pick, isDaily := dailyChallenge(day)
+		contentMu.RUnlock()
+		isDaily = isDaily && pick.ID == ch.ID
+		updateUserByID(userID, func(u *models.User) {
+			xpBefore = u.Profile.XP
+			if res.Passed {
+				tracksAdvanced = completeChallengeStepsLocked(u, trackList, ch.ID, time.Now())
+			}
+			entry := models.LedgerEntry{
+				Kind:    models.LedgerChallengeSubmit,
+				Ref:     ch.ID,
+				Correct: res.Passed,
+			}
+			if res.Passed {
+				entry.Coins = ch.Reward.Coins
+				entry.XP = xp
+				entry.CodingScore = ch.Reward.XP
+			}
+			recordLedgerLocked(u, entry)
+			if isDaily {
+				daily = map[string]any{
+					"date":   day,
+					"scored": recordDailyChallengeLocked(u, day, res, now),
+					"streak": dailyStreak(u.Profile.Daily, now),
+				}
+			}
+			alignSessionWithUser(p, u)
+		})
+	}
+	if !res.Passed {
+		resp := map[string]any{
+			"passed":    false,
+			"total":     res.Total,
+			"failures":  res.Failures,
+			"tests":     res.Tests,
+			"stdout":    res.Stdout,
+			"stderr":    res.Stderr,
+			"compileMs": res.CompileTime.Milliseconds(),
+			"runMs":     res.RunTime.Milliseconds(),
+		}
+		if len(res.Diagnostics) > 0 {
+			resp["diagnostics"] = res.Diagnostics
+		}
+		if res.Truncated {
+			resp["truncated"] = true
+		}
+		if daily != nil {
+			resp["daily"] = daily
+		}
+		return resp
+	}
+	resp := map[string]any{
+		"passed":      true,
+		"total":       res.Total,
+		"tests":       res.Tests,
+		"coinsEarned": ch.Reward.Coins,
+		"coinsTotal":  p.Coins,
+		"xpEarned":    xp,
+		"xpTotal":     p.XP,
+		"message":     fmt.Sprintf("All tests passed! +%d coins · +%d XP", ch.Reward.Coins, xp),
+		"stdout":      res.Stdout,
+		"compileMs":   res.CompileTime.Milliseconds(),
+		"runMs":       res.RunTime.Milliseconds(),
+	}
+	if up := progress.LevelUp(xpBefore, p.XP); up != nil {
+		resp["levelUp"] = up
+	}
+	if len(tracksAdvanced) > 0 {
+		resp["tracksAdvanced"] = tracksAdvanced
+	}
+	if res.Truncated {
+		resp["truncated"] = true
+	}
+	if daily != nil {
+		resp["daily"] = daily
+	}
+	return resp
+}
+
+// handleProChallengeSubmit queues a submission for grading. The response
+// names the job to follow; the result, and the rewards, come when it is done.
+//
+//	POST /api/prochallenge/submit { id, code } -> 202 { jobId, status, position, statusUrl, eventsUrl }
+//
+// position is the job's place in line while queued, 1 being next.
+//
+// A full queue is refused with 429 and a Retry-After estimate.
+func handleProChallengeSubmit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var body struct {
+		ID   string `json:"id"`
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	ch, ok := lookupProChallenge(body.ID)
+	if !ok {
+		http.Error(w, "challenge not found", http.StatusNotFound)
+		return
+	}
+	if locks := trackLocks(r, lookupTracks(), tracks.KindChallenge, ch.ID); locks != nil {
+		writeTrackLocked(w, locks)
+		return
+	}
+	if !sandboxRunner.Available() {
+		http.Error(w, "code execution sandbox unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	q := grading
+	if q == nil {
+		http.Error(w, "grading is not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	var sid, userID string
+	if c, err := r.Cookie("sid"); err == nil {
+		sid = c.Value
+	}
+	if bearerToken(r) != "" {
+		if user, err := authUserFromRequest(r); err == nil {
+			userID = user.ID
+		}
+	}
+	j, err := newGradingJob(sid, userID, ch, body.Code)
+	if err != nil {
+		http.Error(w, "could not create job", http.StatusInternalServerError)
+		return
+	}
+	if !q.submit(j) {
+		secs := int(math.Ceil(q.retryAfter().Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(secs))
+		http.Error(w, "grading queue is full, try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	_, position := q.lookup(j.id)
+	v := j.view(position)
+	base := "/api/prochallenge/jobs/" + j.id
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Location", base)
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"jobId":     j.id,
+		"status":    v.Status,
+		"position":  v.Position,
+		"statusUrl": base,
+		"eventsUrl": base + "/events",
+	})
+}
+
+// handleGradingJob reports on a submission's grading job, to the session or
+// user that submitted it.
+//
+//	GET /api/prochallenge/jobs/{id}        -> { id, challengeId, status, position, output, result, error, ... }
+//	GET /api/prochallenge/jobs/{id}/events -> text/event-stream of status, output and done events
+//
+// result is the submit response of the old synchronous endpoint: passed,
+// total, failures, stdout and stderr, and the rewards when passed, plus the
+// test tree in tests and, when the build failed, the compiler errors in
+// diagnostics.
+func handleGradingJob(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/prochallenge/jobs/"), "/")
+	id, sub, _ := strings.Cut(rest, "/")
+	var (
+		j        *gradingJob
+		position int
+	)
+	if q := grading; q != nil {
+		j, position = q.lookup(id)
+	}
+	if j == nil || !j.visibleTo(r) {
+		http.Error(w, `{"error":"job not found"}`, http.StatusNotFound)
+		return
+	}
+	switch sub {
+	case "":
+		_ = json.NewEncoder(w).Encode(j.view(position))
+	case "events":
+		streamGradingJob(w, r, j)
+	default:
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	}
+}
+
+// streamGradingJob sends j's progress as server-sent events until it is
+// finished: a status event whenever the status changes, output events with
+// new test output, and a final done event carrying the whole job. A client
+// connecting late gets everything so far first.
+func streamGradingJob(w http.ResponseWriter, r *http.Request, j *gradingJob) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, `{"error":"streaming unsupported"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	keepAlive := time.NewTicker(gradingKeepAlive)
+	defer keepAlive.Stop()
+	var stop <-chan struct{}
+	if q := grading; q != nil {
+		stop = q.done
+	}
+	sent, status := 0, ""
+	for {
+		j.mu.Lock()
+		out := string(j.output[sent:])
+		sent = len(j.output)
+		current, changed := j.status, j.changed
+		j.mu.Unlock()
+
+		if current != status {
+			status = current
+			writeEvent(w, "status", map[string]string{"status": status})
+		}
+		if out != "" {
+			writeEvent(w, "output", map[string]string{"text": out})
+		}
+		if status == jobDone || status == jobError {
+			writeEvent(w, "done", j.view(0))
+			flusher.Flush()
+			return
+		}
+		flusher.Flush()
+
+		select {
+		case <-changed:
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case <-stop:
+			// Shutting down: every job is failed shortly, so keep
+			// watching for its final event.
+			stop = nil
+		}
+	}
+}
+
+func writeEvent(w io.Writer, event string, data any) {
+	b, _ := json.Marshal(data)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+}
@@ -0,0 +1,203 @@
+package routes
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"avidlearner/internal/models"
+	"avidlearner/internal/progress"
+	"avidlearner/internal/review"
+)
+
+const (
+	ledgerPageDefault = 50
+	ledgerPageMax     = 200
+)
+
+// recordLedgerLocked appends e to u's ledger and folds it into the profile
+// totals. Callers must hold usersMu (i.e. run inside updateUserByID). If the
+// store rejects the entry the profile is left untouched, so totals never
+// drift from what a restart would re-derive.
+func recordLedgerLocked(u *models.User, e models.LedgerEntry) {
+	if u == nil {
+		return
+	}
+	e.UserID = u.ID
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+	if dataStore != nil {
+		stored, err := dataStore.AppendLedger(e)
+		if err != nil {
+			log.Printf("Error recording %s for user %s: %v", e.Kind, u.ID, err)
+			return
+		}
+		e = stored
+	}
+	ensureProfileDefaults(&u.Profile)
+	applyLedgerEntry(&u.Profile, e)
+	u.Profile.Stats.LastActive = e.At
+	u.Profile.UpdatedAt = e.At
+}
+
+// applyLedgerEntry folds a single entry into the ledger-derived profile
+// fields.
+func applyLedgerEntry(p *models.UserProfile, e models.LedgerEntry) {
+	p.Coins = max(p.Coins+e.Coins, 0)
+	p.XP += e.XP
+	p.Level = progress.LevelFor(p.XP)
+	p.CodingScore += e.CodingScore
+	p.TypingBest = max(p.TypingBest, e.TypingScore)
+
+	switch e.Kind {
+	case models.LedgerOpeningBalance:
+		p.QuizStreak = e.Streak
+		if e.Stats != nil {
+			p.Stats.QuizzesTaken += e.Stats.QuizzesTaken
+			p.Stats.QuizCorrect += e.Stats.QuizCorrect
+			p.Stats.TypingSessions += e.Stats.TypingSessions
+			p.Stats.CodingSubmissions += e.Stats.CodingSubmissions
+			p.Stats.CodingPassed += e.Stats.CodingPassed
+		}
+	case models.LedgerSessionMerge:
+		p.QuizStreak = max(p.QuizStreak, e.Streak)
+	case models.LedgerQuizAnswer:
+		p.Stats.QuizzesTaken++
+		if e.Correct {
+			p.Stats.QuizCorrect++
+			p.QuizStreak++
+		} else {
+			p.QuizStreak = 0
+		}
+		if e.Ref != "" {
+			if p.Reviews == nil {
+				p.Reviews = map[string]models.ReviewItem{}
+			}
+			p.Reviews[e.Ref] = review.Schedule(p.Reviews[e.Ref], review.Grade(e.Correct), e.At)
+		}
+	case models.LedgerChallengeSubmit:
+		p.Stats.CodingSubmissions++
+		if e.Correct {
+			p.Stats.CodingPassed++
+		}
+	case models.LedgerTypingSession:
+		p.Stats.TypingSessions++
+	case models.LedgerDailyQuiz, models.LedgerDailyChallenge:
+		applyDailyResult(&p.Daily, e)
+	}
+}
+
+// resetLedgerTotals zeroes every profile field that is derived from the
+// ledger.
+func resetLedgerTotals(p *models.UserProfile) {
+	p.Coins = 0
+	p.XP = 0
+	p.Level = progress.LevelFor(0)
+	p.QuizStreak = 0
+	p.TypingBest = 0
+	p.CodingScore = 0
+	p.Stats.QuizzesTaken = 0
+	p.Stats.QuizCorrect = 0
+	p.Stats.TypingSessions = 0
+	p.Stats.CodingSubmissions = 0
+	p.Stats.CodingPassed = 0
+	p.Reviews = nil
+	p.Daily = models.DailyProgress{}
+}
+
+// reconcileLedgerLocked makes u's totals match its ledger. Accounts created
+// before the ledger existed get an opening balance entry carrying their
+// stored totals; everyone else has the totals re-derived, which discards any
+// edits made to the users file by hand.
+func reconcileLedgerLocked(u *models.User) error {
+	entries, err := dataStore.Ledger(u.ID, 0, 0)
+	if err != nil {
+		return err
+	}
+	p := &u.Profile
+	if len(entries) == 0 {
+		if p.Coins == 0 && p.XP == 0 && p.CodingScore == 0 && p.TypingBest == 0 &&
+			p.QuizStreak == 0 && p.Stats.QuizzesTaken == 0 && p.Stats.TypingSessions == 0 &&
+			p.Stats.CodingSubmissions == 0 {
+			return nil
+		}
+		stats := p.Stats
+		_, err := dataStore.AppendLedger(models.LedgerEntry{
+			UserID:      u.ID,
+			Kind:        models.LedgerOpeningBalance,
+			Coins:       p.Coins,
+			XP:          p.XP,
+			CodingScore: p.CodingScore,
+			TypingScore: p.TypingBest,
+			Streak:      p.QuizStreak,
+			Stats:       &stats,
+			At:          time.Now(),
+		})
+		return err
+	}
+
+	resetLedgerTotals(p)
+	for i := len(entries) - 1; i >= 0; i-- {
+		applyLedgerEntry(p, entries[i])
+	}
+	return nil
+}
+
+// handleLedger pages through the signed-in user's ledger, newest first.
+// Pass the returned nextBefore as ?before= to fetch the next page.
+func handleLedger(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	user, err := requireAuthUser(w, r)
+	if err != nil {
+		return
+	}
+	if dataStore == nil {
+		http.Error(w, `{"error":"store not configured"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	q := r.URL.Query()
+	limit := ledgerPageDefault
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, `{"error":"invalid limit"}`, http.StatusBadRequest)
+			return
+		}
+		limit = min(n, ledgerPageMax)
+	}
+	var before int64
+	if v := q.Get("before"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			http.Error(w, `{"error":"invalid before"}`, http.StatusBadRequest)
+			return
+		}
+		before = n
+	}
+
+	// Fetch one extra entry to know whether another page exists.
+	entries, err := dataStore.Ledger(user.ID, before, limit+1)
+	if err != nil {
+		log.Printf("Error reading ledger for %s: %v", user.ID, err)
+		http.Error(w, `{"error":"unable to load history"}`, http.StatusInternalServerError)
+		return
+	}
+	resp := map[string]any{"entries": entries}
+	if len(entries) > limit {
+		entries = entries[:limit]
+		resp["entries"] = entries
+		resp["nextBefore"] = entries[limit-1].Seq
+	}
+	if entries == nil {
+		resp["entries"] = []models.LedgerEntry{}
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
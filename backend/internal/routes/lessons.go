@@ -0,0 +1,211 @@
+package routes
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"avidlearner/internal/models"
+)
+
+const (
+	lessonsPageDefault = 50
+	lessonsPageMax     = 200
+)
+
+// lessonsContentHash hashes the lesson map in a stable order. It is computed
+// once per map swap and is the base of every /api/lessons ETag.
+func lessonsContentHash(sorted []models.Lesson) string {
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	for _, l := range sorted {
+		_ = enc.Encode(l)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// sortLessons orders lessons by category, title and ID, the order pages are
+// served in.
+func sortLessons(ls []models.Lesson) {
+	sort.Slice(ls, func(i, j int) bool {
+		return lessonSortKey(ls[i]) < lessonSortKey(ls[j])
+	})
+}
+
+func lessonSortKey(l models.Lesson) string {
+	return l.Category + "\x00" + l.Title + "\x00" + l.ID
+}
+
+// lessonSource is the source a lesson is filtered by: lessons without one
+// are local, as in search.
+func lessonSource(l models.Lesson) string {
+	if l.Source == "" {
+		return "local"
+	}
+	return l.Source
+}
+
+func encodeLessonCursor(l models.Lesson) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(lessonSortKey(l)))
+}
+
+func decodeLessonCursor(cursor string) (string, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || strings.Count(string(b), "\x00") != 2 {
+		return "", false
+	}
+	return string(b), true
+}
+
+// lessonsETag derives a weak ETag from the content hash and everything else
+// that shapes the response: the query and, for seen/unseen filters, the
+// user's seen set.
+func lessonsETag(query string, seen []string) string {
+	h := sha256.New()
+	h.Write([]byte(lessonsHash))
+	h.Write([]byte{0})
+	h.Write([]byte(query))
+	for _, id := range seen {
+		h.Write([]byte{0})
+		h.Write([]byte(id))
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:12]) + `"`
+}
+
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// handleLessons serves the lesson catalog.
+//
+//	GET /api/lessons                      -> { categories, lessons } (every lesson by category)
+//	GET /api/lessons?view=categories      -> { categories, counts }
+//	GET /api/lessons?limit=&cursor=&category=&source=&seen=seen|unseen
+//	                                      -> { lessons, nextCursor } in category/title order
+//
+// The seen filter needs a signed-in user. Every response carries an ETag and
+// honours If-None-Match.
+func handleLessons(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+
+	seenFilter := q.Get("seen")
+	var seen []string
+	switch seenFilter {
+	case "":
+	case "seen", "unseen":
+		user, err := requireAuthUser(w, r)
+		if err != nil {
+			return
+		}
+		usersMu.RLock()
+		seen = append(seen, user.Profile.LessonsSeen...)
+		usersMu.RUnlock()
+		sort.Strings(seen)
+	default:
+		http.Error(w, `{"error":"seen must be seen or unseen"}`, http.StatusBadRequest)
+		return
+	}
+
+	etag := lessonsETag(q.Encode(), seen)
+	w.Header().Set("ETag", etag)
+	if seenFilter != "" {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	if match := r.Header.Get("If-None-Match"); match != "" && etagMatches(match, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if q.Get("view") == "categories" {
+		counts := make(map[string]int, len(lessonsByCat))
+		for cat, ls := range lessonsByCat {
+			counts[cat] = len(ls)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"categories": categories,
+			"counts":     counts,
+		})
+		return
+	}
+
+	if len(q) == 0 {
+		_ = json.NewEncoder(w).Encode(models.LessonsResponse{
+			Categories: categories,
+			Lessons:    lessonsByCat,
+		})
+		return
+	}
+
+	limit := lessonsPageDefault
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, `{"error":"invalid limit"}`, http.StatusBadRequest)
+			return
+		}
+		limit = min(n, lessonsPageMax)
+	}
+	after := ""
+	if v := q.Get("cursor"); v != "" {
+		key, ok := decodeLessonCursor(v)
+		if !ok {
+			http.Error(w, `{"error":"invalid cursor"}`, http.StatusBadRequest)
+			return
+		}
+		after = key
+	}
+	category := canonicalCategory(q.Get("category"))
+	source := q.Get("source")
+	seenSet := make(map[string]struct{}, len(seen))
+	for _, id := range seen {
+		seenSet[id] = struct{}{}
+	}
+
+	page := []models.Lesson{}
+	nextCursor := ""
+	pool := lessonsSorted
+	start := sort.Search(len(pool), func(i int) bool { return lessonSortKey(pool[i]) > after })
+	for _, l := range pool[start:] {
+		if category != "" && !strings.EqualFold(l.Category, category) {
+			continue
+		}
+		if source != "" && !strings.EqualFold(lessonSource(l), source) {
+			continue
+		}
+		if seenFilter != "" {
+			_, isSeen := seenSet[l.ID]
+			if isSeen != (seenFilter == "seen") {
+				continue
+			}
+		}
+		if len(page) == limit {
+			nextCursor = encodeLessonCursor(page[len(page)-1])
+			break
+		}
+		page = append(page, l)
+	}
+
+	resp := map[string]any{"lessons": page}
+	if nextCursor != "" {
+		resp["nextCursor"] = nextCursor
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
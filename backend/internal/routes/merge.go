@@ -0,0 +1,102 @@
+package routes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	"avidlearner/internal/models"
+)
+
+// sessionFingerprint identifies a session in a user's merge history without
+// storing the sid cookie value itself.
+func sessionFingerprint(sid string) string {
+	sum := sha256.Sum256([]byte(sid))
+	return hex.EncodeToString(sum[:16])
+}
+
+func requestSessionID(r *http.Request) string {
+	c, err := r.Cookie("sid")
+	if err != nil {
+		return ""
+	}
+	return c.Value
+}
+
+// mergeSessionProgress folds anonymous session progress into the account
+// profile and reports whether anything was merged. The policy is:
+//   - coins and XP are summed;
+//   - best scores (coding score, typing best, quiz streak) keep the maximum;
+//   - lessons seen are unioned;
+//   - hint unlocks keep the furthest index per challenge.
+//
+// A session is merged at most once: it is stamped with the account ID and its
+// fingerprint is recorded on the account, so repeating signup/login with the
+// same cookie, or logging into a second account, cannot replay the credit.
+// An empty session is only stamped. The totals are credited through a
+// session_merge ledger entry, so callers must hold usersMu.
+func mergeSessionProgress(sid string, p *models.Profile, u *models.User) bool {
+	if sid == "" || p == nil || u == nil || p.MergedInto != "" {
+		return false
+	}
+	fingerprint := sessionFingerprint(sid)
+	for _, merged := range u.Profile.MergedSessions {
+		if merged == fingerprint {
+			return false
+		}
+	}
+
+	if p.Coins == 0 && p.XP == 0 && p.CodingScore == 0 && p.TypingScore == 0 &&
+		p.Streak == 0 && len(p.LessonsSeen) == 0 && len(p.HintIdx) == 0 {
+		p.MergedInto = u.ID
+		return false
+	}
+
+	dst := &u.Profile
+	ensureProfileDefaults(dst)
+	recordLedgerLocked(u, models.LedgerEntry{
+		Kind:        models.LedgerSessionMerge,
+		Ref:         fingerprint,
+		Coins:       p.Coins,
+		XP:          p.XP,
+		CodingScore: max(p.CodingScore-dst.CodingScore, 0),
+		TypingScore: p.TypingScore,
+		Streak:      p.Streak,
+	})
+	dst.LessonsSeen = dedupeStrings(append(dst.LessonsSeen, p.LessonsSeen...))
+	dst.Stats.LessonsRead = len(dst.LessonsSeen)
+	for id, idx := range p.HintIdx {
+		if idx > dst.HintIdx[id] {
+			dst.HintIdx[id] = idx
+		}
+	}
+	dst.MergedSessions = append(dst.MergedSessions, fingerprint)
+
+	p.MergedInto = u.ID
+	return true
+}
+
+// alignSessionWithUser makes the session mirror the account totals so later
+// handlers, which copy session counters onto the account, start from the
+// account's values instead of the anonymous ones. The session then holds the
+// account's progress, not anonymous progress, so it is stamped as merged:
+// signing in to another account with the same cookie must not credit it.
+func alignSessionWithUser(p *models.Profile, u *models.User) {
+	if p == nil || u == nil {
+		return
+	}
+	if p.MergedInto == "" {
+		p.MergedInto = u.ID
+	}
+	p.Coins = u.Profile.Coins
+	p.XP = u.Profile.XP
+	p.CodingScore = u.Profile.CodingScore
+	p.Streak = u.Profile.QuizStreak
+	if u.Profile.TypingBest > p.TypingScore {
+		p.TypingScore = u.Profile.TypingBest
+	}
+	p.HintIdx = make(map[string]int, len(u.Profile.HintIdx))
+	for id, idx := range u.Profile.HintIdx {
+		p.HintIdx[id] = idx
+	}
+}
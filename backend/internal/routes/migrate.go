@@ -0,0 +1,68 @@
+package routes
+
+import (
+	"avidlearner/internal/lessons"
+	"avidlearner/internal/models"
+)
+
+// lessonRefsVersion is the current UserProfile.LessonRefsVersion.
+const lessonRefsVersion = 1
+
+// MigrateLessonRefs rewrites title references in every account's
+// LessonsSeen and SavedLessons to lesson IDs. It runs once per account: the
+// profile is stamped with lessonRefsVersion afterwards. The lesson map must
+// be loaded first. It returns how many accounts were migrated and how many
+// lessons-seen titles no longer match any lesson (those are kept verbatim).
+// The content reloader may already be running, so contentMu is held for
+// reading throughout.
+func MigrateLessonRefs() (migrated, unresolved int) {
+	contentMu.RLock()
+	defer contentMu.RUnlock()
+	usersMu.Lock()
+	defer usersMu.Unlock()
+
+	for _, u := range usersByID {
+		if u.Profile.LessonRefsVersion >= lessonRefsVersion {
+			continue
+		}
+		unresolved += migrateProfileLessonRefs(&u.Profile)
+		u.Profile.LessonRefsVersion = lessonRefsVersion
+		persistUserLocked(u)
+		migrated++
+	}
+	return migrated, unresolved
+}
+
+// migrateProfileLessonRefs rewrites p's lesson references. Callers hold
+// contentMu for reading.
+func migrateProfileLessonRefs(p *models.UserProfile) (unresolved int) {
+	seen := make([]string, 0, len(p.LessonsSeen))
+	for _, ref := range p.LessonsSeen {
+		if findLessonByID(ref) == nil {
+			if l := findLessonByTitle(ref); l != nil {
+				ref = l.ID
+			} else {
+				unresolved++
+			}
+		}
+		seen = append(seen, ref)
+	}
+	p.LessonsSeen = dedupeStrings(seen)
+	p.Stats.LessonsRead = len(p.LessonsSeen)
+
+	for i := range p.SavedLessons {
+		saved := &p.SavedLessons[i]
+		if saved.ID != "" {
+			continue
+		}
+		if l := findLessonByTitleSource(saved.Title, saved.Source); l != nil {
+			saved.ID = l.ID
+		} else {
+			// External lessons may not be fetched yet. Derive the ID from
+			// source and title, which matches every source keyed by title
+			// (all but Dev.to, whose IDs come from the article URL).
+			saved.ID = lessons.NewID(saved.Source, saved.Title)
+		}
+	}
+	return unresolved
+}
@@ -0,0 +1,280 @@
+package routes
+
+import (
+	"log"
+	"math"
+	mrand "math/rand"
+	"slices"
+	"strings"
+	"time"
+
+	"avidlearner/internal/lessons"
+	"avidlearner/internal/models"
+)
+
+// quizAnswer is the body of POST /api/session?stage=answer. Which field is
+// read depends on the question type:
+//
+//	multiple-choice, true-false -> { answerIndex }
+//	multi-select                -> { answerIndexes: [..] }
+//	ordering                    -> { order: [option indexes, first to last] }
+//	fill-blank                  -> { answerText }
+type quizAnswer struct {
+	AnswerIndex   int    `json:"answerIndex"`
+	AnswerIndexes []int  `json:"answerIndexes"`
+	Order         []int  `json:"order"`
+	AnswerText    string `json:"answerText"`
+}
+
+// quizForLesson picks one of l's authored questions, or generates a multiple
+// choice question when it has none. Callers hold contentMu for reading.
+func quizForLesson(l models.Lesson) models.QuizQuestion {
+	qs := lessonQuestions[l.ID]
+	if len(qs) == 0 {
+		return buildQuizForLesson(l, mrand.Shuffle)
+	}
+	return authoredQuiz(l, qs[mrand.Intn(len(qs))])
+}
+
+// authoredQuiz turns an authored question into a quiz question. Choice
+// options are shuffled; true/false keeps its authored order and ordering
+// options are shuffled out of order.
+func authoredQuiz(l models.Lesson, q models.Question) models.QuizQuestion {
+	qq := models.QuizQuestion{
+		LessonID:    l.ID,
+		LessonTitle: l.Title,
+		Type:        lessons.QuestionType(q),
+		Question:    q.Prompt,
+		Explain:     q.Explain,
+	}
+	order := make([]int, len(q.Options)) // display position -> authored index
+	for i := range order {
+		order[i] = i
+	}
+	shuffle := func() {
+		mrand.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+	}
+	switch qq.Type {
+	case models.QuestionMultipleChoice, models.QuestionMultiSelect:
+		shuffle()
+	case models.QuestionOrdering:
+		for tries := 0; tries < 5 && slices.IsSorted(order); tries++ {
+			shuffle()
+		}
+	case models.QuestionFillBlank:
+		qq.Accepted = q.Answers
+	}
+
+	for pos, i := range order {
+		o := q.Options[i]
+		qq.Options = append(qq.Options, o.Text)
+		qq.Explains = append(qq.Explains, o.Explain)
+		if !o.Correct {
+			continue
+		}
+		if qq.Type == models.QuestionMultiSelect {
+			qq.Correct = append(qq.Correct, pos)
+		} else {
+			qq.CorrectIndex = pos
+		}
+	}
+	if qq.Type == models.QuestionOrdering {
+		qq.Correct = make([]int, len(order))
+		for pos, i := range order {
+			qq.Correct[i] = pos
+		}
+	}
+	return qq
+}
+
+// gradeQuizAnswer grades a against q and explains the right answer.
+func gradeQuizAnswer(q models.QuizQuestion, a quizAnswer) (bool, *models.AnswerFeedback) {
+	typ := q.Type
+	if typ == "" {
+		typ = models.QuestionMultipleChoice
+	}
+	fb := &models.AnswerFeedback{Type: typ, Explain: q.Explain}
+	option := func(i int) models.OptionFeedback {
+		of := models.OptionFeedback{Text: q.Options[i]}
+		if i < len(q.Explains) {
+			of.Explain = q.Explains[i]
+		}
+		return of
+	}
+
+	var correct bool
+	switch typ {
+	case models.QuestionMultiSelect:
+		chosen := map[int]bool{}
+		for _, i := range a.AnswerIndexes {
+			chosen[i] = true
+		}
+		right := map[int]bool{}
+		for _, i := range q.Correct {
+			right[i] = true
+		}
+		correct = len(chosen) == len(right)
+		for i := range q.Options {
+			of := option(i)
+			of.Chosen, of.Correct = chosen[i], right[i]
+			if of.Chosen != of.Correct {
+				correct = false
+			}
+			fb.Options = append(fb.Options, of)
+		}
+		fb.Correct = q.Correct
+
+	case models.QuestionOrdering:
+		correct = slices.Equal(a.Order, q.Correct)
+		for i := range q.Options {
+			fb.Options = append(fb.Options, option(i))
+		}
+		fb.Correct = q.Correct
+
+	case models.QuestionFillBlank:
+		given := normalizeAnswer(a.AnswerText)
+		for _, ans := range q.Accepted {
+			if normalizeAnswer(ans) == given {
+				correct = true
+				break
+			}
+		}
+		for i, wrong := range q.Options {
+			if normalizeAnswer(wrong) == given {
+				of := option(i)
+				of.Chosen = true
+				fb.Options = append(fb.Options, of)
+			}
+		}
+		fb.Accepted = q.Accepted
+
+	default: // multiple-choice, true-false
+		correct = a.AnswerIndex == q.CorrectIndex
+		for i := range q.Options {
+			of := option(i)
+			of.Chosen, of.Correct = i == a.AnswerIndex, i == q.CorrectIndex
+			fb.Options = append(fb.Options, of)
+		}
+		fb.Correct = []int{q.CorrectIndex}
+	}
+	return correct, fb
+}
+
+// normalizeAnswer folds case, runs of spaces and a trailing full stop so
+// "Context  Cancellation." matches "context cancellation".
+func normalizeAnswer(s string) string {
+	return strings.TrimSuffix(strings.Join(strings.Fields(strings.ToLower(s)), " "), ".")
+}
+
+// withQuizQuestion fills in the quiz stage for p's current question.
+// Fill-blank options are known wrong answers and are not shown.
+func withQuizQuestion(st models.SessionState, p *models.Profile) models.SessionState {
+	q := p.CurrentQuiz[p.QuizIndex]
+	st.Stage = "quiz"
+	st.QuestionType = q.Type
+	st.Question = q.Question
+	st.Options = q.Options
+	if q.Type == models.QuestionFillBlank {
+		st.Options = nil
+	}
+	st.Index = p.QuizIndex + 1
+	st.Total = len(p.CurrentQuiz)
+	if p.Timed {
+		now := time.Now()
+		st.Timed = true
+		st.QuestionTimeLeftMs = max(questionDeadline(p).Sub(now), 0).Milliseconds()
+		st.QuizTimeLeftMs = max(p.QuizDeadline.Sub(now), 0).Milliseconds()
+	}
+	return st
+}
+
+// questionDeadline is when the current question of a timed quiz is due: its
+// own limit, or the quiz deadline if that comes first.
+func questionDeadline(p *models.Profile) time.Time {
+	due := p.QuestionAsked.Add(timedQuestionLimit)
+	if p.QuizDeadline.Before(due) {
+		return p.QuizDeadline
+	}
+	return due
+}
+
+// timedPoints weights a correct timed answer by the share of the question's
+// time limit left: timedMaxPoints when instant, down to 1 at the deadline.
+func timedPoints(left time.Duration) int {
+	frac := min(max(float64(left)/float64(timedQuestionLimit), 0), 1)
+	return 1 + int(math.Round(frac*(timedMaxPoints-1)))
+}
+
+// startQuizClock resets the attempt record for a new quiz whose first
+// question is served at now, and sets the quiz deadline when timed.
+func startQuizClock(p *models.Profile, now time.Time, timed bool) {
+	p.QuizStarted = now
+	p.QuestionAsked = now
+	p.QuizAnswers = nil
+	p.Timed = timed
+	p.QuizScoreTimed = timed
+	p.QuizDeadline = time.Time{}
+	p.Daily, p.DailyScored = "", false
+	if timed {
+		p.QuizDeadline = now.Add(time.Duration(len(p.CurrentQuiz)) * timedQuizPerQuestion)
+	}
+}
+
+// attemptQuestion records how q was answered, for the quiz attempt history.
+func attemptQuestion(q models.QuizQuestion, a quizAnswer, correct bool, asked, answered time.Time) models.AttemptQuestion {
+	aq := models.AttemptQuestion{
+		LessonID:    q.LessonID,
+		LessonTitle: q.LessonTitle,
+		Type:        q.Type,
+		Question:    q.Question,
+		Options:     q.Options,
+		Explains:    q.Explains,
+		Explain:     q.Explain,
+		IsCorrect:   correct,
+		AskedAt:     asked,
+		AnsweredAt:  answered,
+	}
+	switch q.Type {
+	case models.QuestionMultiSelect:
+		aq.Chosen, aq.Correct = a.AnswerIndexes, q.Correct
+	case models.QuestionOrdering:
+		aq.Chosen, aq.Correct = a.Order, q.Correct
+	case models.QuestionFillBlank:
+		aq.AnswerText, aq.Accepted = a.AnswerText, q.Accepted
+	default:
+		aq.Chosen, aq.Correct = []int{a.AnswerIndex}, []int{q.CorrectIndex}
+	}
+	return aq
+}
+
+// saveQuizAttempt stores p's finished quiz for userID and returns its ID, or
+// 0 when it could not be saved.
+func saveQuizAttempt(userID string, p *models.Profile, finished time.Time) int64 {
+	if dataStore == nil || len(p.QuizAnswers) == 0 {
+		return 0
+	}
+	a := models.QuizAttempt{
+		UserID:     userID,
+		Review:     p.ReviewQuiz,
+		Timed:      p.Timed,
+		Daily:      p.Daily,
+		Total:      len(p.CurrentQuiz),
+		StartedAt:  p.QuizStarted,
+		FinishedAt: finished,
+		Questions:  p.QuizAnswers,
+	}
+	for _, q := range a.Questions {
+		if q.IsCorrect {
+			a.Score++
+		}
+	}
+	if p.Timed {
+		a.Points = p.QuizScore
+	}
+	stored, err := dataStore.AppendQuizAttempt(a)
+	if err != nil {
+		log.Printf("Error saving quiz attempt for user %s: %v", userID, err)
+		return 0
+	}
+	return stored.Seq
+}
@@ -0,0 +1,94 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"avidlearner/internal/models"
+	"avidlearner/internal/review"
+)
+
+// DueReview is a lesson whose spaced-repetition review is due.
+type DueReview struct {
+	LessonID string            `json:"lessonId"`
+	Title    string            `json:"title"`
+	Category string            `json:"category"`
+	Review   models.ReviewItem `json:"review"`
+}
+
+// dueReviews returns u's reviews due at now, most overdue first, and the
+// earliest due date among the rest. Lessons that no longer exist are
+// skipped. Callers hold contentMu for reading.
+func dueReviews(u *models.User, now time.Time) (due []DueReview, nextDue time.Time) {
+	usersMu.RLock()
+	items := make(map[string]models.ReviewItem, len(u.Profile.Reviews))
+	for id, item := range u.Profile.Reviews {
+		items[id] = item
+	}
+	usersMu.RUnlock()
+
+	for id, item := range items {
+		l := findLessonByID(id)
+		if l == nil {
+			continue
+		}
+		if !review.IsDue(item, now) {
+			if nextDue.IsZero() || item.Due.Before(nextDue) {
+				nextDue = item.Due
+			}
+			continue
+		}
+		due = append(due, DueReview{LessonID: id, Title: l.Title, Category: l.Category, Review: item})
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].Review.Due.Equal(due[j].Review.Due) {
+			return due[i].Review.Due.Before(due[j].Review.Due)
+		}
+		return due[i].LessonID < due[j].LessonID
+	})
+	return due, nextDue
+}
+
+// handleReviewDue lists the signed-in user's lessons due for review.
+//
+//	GET /api/review/due?limit= -> { total, scheduled, due: [DueReview], nextDue }
+//
+// Lessons are scheduled from quiz answers; total counts every due lesson,
+// nextDue is the earliest upcoming review when nothing else is due.
+func handleReviewDue(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	user, err := requireAuthUser(w, r)
+	if err != nil {
+		return
+	}
+	limit := reviewQuizMax
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, `{"error":"invalid limit"}`, http.StatusBadRequest)
+			return
+		}
+		limit = min(n, reviewDueMax)
+	}
+
+	due, nextDue := dueReviews(user, time.Now())
+	usersMu.RLock()
+	scheduled := len(user.Profile.Reviews)
+	usersMu.RUnlock()
+	resp := map[string]any{
+		"total":     len(due),
+		"scheduled": scheduled,
+		"due":       append([]DueReview{}, due[:min(limit, len(due))]...),
+	}
+	if !nextDue.IsZero() {
+		resp["nextDue"] = nextDue
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
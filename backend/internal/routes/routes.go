@@ -16,15 +16,23 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"avidlearner/internal/ai"
 	"avidlearner/internal/featureflag"
+	"avidlearner/internal/gotest"
 	"avidlearner/internal/httpx"
 	"avidlearner/internal/models"
 	"avidlearner/internal/lessons"
+	"avidlearner/internal/progress"
+	"avidlearner/internal/sandbox"
+	"avidlearner/internal/search"
+	"avidlearner/internal/tracks"
 )
 
 var newsHTTPClient = httpx.NewClient(15 * time.Second)
@@ -42,50 +50,103 @@ func registerAPIHandler() {
 		fmt.Fprint(w, "ok")
 	})
 
-	http.HandleFunc("/api/lessons", cors(handleLessons))
-	http.HandleFunc("/api/random", cors(handleRandom))
-	http.HandleFunc("/api/session", cors(handleSession))
+	http.HandleFunc("/api/lessons", cors(readsContent(handleLessons)))
+	http.HandleFunc("/api/lessons/", cors(readsContent(handleLessonByID)))
+	http.HandleFunc("/api/lessons/search", cors(readsContent(handleLessonSearch)))
+	http.HandleFunc("/api/categories", cors(readsContent(handleCategories)))
+	http.HandleFunc("/api/random", cors(readsContent(handleRandom)))
+	http.HandleFunc("/api/session", cors(readsContent(handleSession)))
 	http.HandleFunc("/api/ai/generate", cors(handleAIGenerate))
 	http.HandleFunc("/api/ai/config", cors(handleAIConfig))
-	http.HandleFunc("/api/prochallenge", cors(handleProChallenge))
+	http.HandleFunc("/api/prochallenge", cors(readsContent(handleProChallenge)))
 	http.HandleFunc("/api/prochallenge/submit", cors(handleProChallengeSubmit))
+	http.HandleFunc("/api/prochallenge/jobs/", corsUnlocked(handleGradingJob))
 	http.HandleFunc("/api/prochallenge/hint", cors(handleProChallengeHint))
 	http.HandleFunc("/api/leaderboard", cors(handleLeaderboard))
 	http.HandleFunc("/api/leaderboard/submit", cors(handleLeaderboardSubmit))
+	http.HandleFunc("/api/typing/start", cors(readsContent(handleTypingStart)))
 	http.HandleFunc("/api/typing/score", cors(handleTypingScore))
 	http.HandleFunc("/api/news", cors(handleNewsFetch))
 	http.HandleFunc("/api/auth/signup", cors(handleSignup))
 	http.HandleFunc("/api/auth/login", cors(handleLogin))
 	http.HandleFunc("/api/auth/me", cors(handleMe))
 	http.HandleFunc("/api/profile", cors(handleProfile))
-	http.HandleFunc("/api/profile/lessons/save", cors(handleSaveLesson))
+	http.HandleFunc("/api/profile/ledger", cors(handleLedger))
+	http.HandleFunc("/api/review/due", cors(readsContent(handleReviewDue)))
+	http.HandleFunc("/api/quiz/attempts", cors(handleQuizAttempts))
+	http.HandleFunc("/api/quiz/attempts/", cors(handleQuizAttempt))
+	http.HandleFunc("/api/daily", cors(readsContent(handleDaily)))
+	http.HandleFunc("/api/daily/leaderboard", cors(handleDailyLeaderboard))
+	http.HandleFunc("/api/tracks", cors(readsContent(handleTracks)))
+	http.HandleFunc("/api/tracks/", cors(readsContent(handleTrack)))
+	http.HandleFunc("/api/profile/lessons/save", cors(readsContent(handleSaveLesson)))
 	http.HandleFunc("/api/profile/lessons/remove", cors(handleRemoveLesson))
+	http.HandleFunc("/api/admin/content", cors(handleAdminContent))
 }
 
 func updateLessonMap(allLessons []lessons.Lesson) {
 	newLessonsByCat := map[string][]models.Lesson{}
+	newLessonsByID := map[string]models.Lesson{}
+	newQuestions := map[string][]models.Question{}
 	newCategories := []string{}
+	var indexed []models.Lesson
+
+	contentMu.RLock()
+	taxonomy := lessonTaxonomy
+	contentMu.RUnlock()
 
 	for _, l := range allLessons {
+		if l.ID == "" {
+			l.ID = lessons.NewID(l.Source, l.Title)
+		}
+		if first, dup := newLessonsByID[l.ID]; dup {
+			log.Printf("Warning: skipped lesson %q: ID %s is already used by %q", l.Title, l.ID, first.Title)
+			continue
+		}
 		mainLesson := models.Lesson{
-			Title:    l.Title,
-			Category: l.Category,
-			Text:     l.Text,
-			Explain:  l.Explain,
-			UseCases: l.UseCases,
-			Tips:     l.Tips,
-			Source:   l.Source,
+			ID:         l.ID,
+			Title:      l.Title,
+			Category:   taxonomy.Canonical(l.Category),
+			Text:       l.Text,
+			Explain:    l.Explain,
+			UseCases:   l.UseCases,
+			Tips:       l.Tips,
+			Source:     l.Source,
+			Difficulty: l.Difficulty,
+			Tags:       l.Tags,
 		}
-		newLessonsByCat[l.Category] = append(newLessonsByCat[l.Category], mainLesson)
+		newLessonsByCat[mainLesson.Category] = append(newLessonsByCat[mainLesson.Category], mainLesson)
+		newLessonsByID[l.ID] = mainLesson
+		if len(l.Questions) > 0 {
+			newQuestions[l.ID] = l.Questions
+		}
+		indexed = append(indexed, mainLesson)
 	}
-	for cat := range newLessonsByCat {
+	counts := make(map[string]int, len(newLessonsByCat))
+	for cat, ls := range newLessonsByCat {
 		newCategories = append(newCategories, cat)
+		counts[cat] = len(ls)
 	}
 	sort.Strings(newCategories)
+	tree := taxonomy.Tree(counts)
+
+	sortLessons(indexed)
+	hash := lessonsContentHash(indexed)
+	index := search.NewIndex(indexed)
+	distractors := search.DistractorPools(indexed, distractorPoolSize)
 
+	contentMu.Lock()
 	lessonsByCat = newLessonsByCat
+	lessonsByID = newLessonsByID
+	lessonQuestions = newQuestions
+	lessonsSorted = indexed
+	lessonsHash = hash
+	lessonIndex = index
+	lessonDistractors = distractors
 	categories = newCategories
-	log.Printf("Refreshed lesson map: %d lessons total", len(allLessons))
+	categoryTree = tree
+	contentMu.Unlock()
+	log.Printf("Refreshed lesson map: %d lessons total", len(newLessonsByID))
 }
 
 func loadLessons(path string) ([]models.Lesson, error) {
@@ -119,17 +180,16 @@ func loadProChallenges(path string) ([]models.ProChallenge, map[string]models.Pr
 	return list, byID, nil
 }
 
-func loadLeaderboard(path string) error {
-	b, err := os.ReadFile(path)
+func loadLeaderboard() error {
+	if dataStore == nil {
+		return errors.New("store not configured")
+	}
+	entries, err := dataStore.Leaderboard()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
 		return err
 	}
-	var entries []models.LeaderboardEntry
-	if err := json.Unmarshal(b, &entries); err != nil {
-		return err
+	if entries == nil {
+		entries = []models.LeaderboardEntry{}
 	}
 	leaderboard = entries
 	return nil
@@ -371,20 +431,6 @@ func fetchTLDRLatest(ctx context.Context, category string) ([]map[string]interfa
 	return nil, fmt.Errorf("unexpected tldr response format (len=%d): %s", len(b), bodyStr)
 }
 
-func saveLeaderboard(path string) error {
-	// Ensure directory exists
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return err
-	}
-
-	b, err := json.MarshalIndent(leaderboard, "", "  ")
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(path, b, 0644)
-}
-
 func pickRandomLesson(cat string) *models.Lesson {
 	var pool []models.Lesson
 	if cat == "" || strings.EqualFold(cat, "any") {
@@ -392,7 +438,7 @@ func pickRandomLesson(cat string) *models.Lesson {
 			pool = append(pool, ls...)
 		}
 	} else {
-		pool = lessonsByCat[cat]
+		pool = lessonsByCat[canonicalCategory(cat)]
 	}
 	if len(pool) == 0 {
 		return nil
@@ -409,10 +455,24 @@ func allLessons() []models.Lesson {
 	return pool
 }
 
+func findLessonByID(id string) *models.Lesson {
+	l, ok := lessonsByID[id]
+	if !ok {
+		return nil
+	}
+	return &l
+}
+
+// findLessonByTitle resolves legacy title references. Titles are not unique
+// across sources; the first match wins.
 func findLessonByTitle(title string) *models.Lesson {
+	return findLessonByTitleSource(title, "")
+}
+
+func findLessonByTitleSource(title, source string) *models.Lesson {
 	for _, ls := range lessonsByCat {
 		for _, l := range ls {
-			if l.Title == title {
+			if l.Title == title && (source == "" || l.Source == source) {
 				ll := l
 				return &ll
 			}
@@ -421,6 +481,15 @@ func findLessonByTitle(title string) *models.Lesson {
 	return nil
 }
 
+// findLessonRef resolves a lesson reference that may still be a title from
+// before lessons had IDs.
+func findLessonRef(ref string) *models.Lesson {
+	if l := findLessonByID(ref); l != nil {
+		return l
+	}
+	return findLessonByTitle(ref)
+}
+
 func uniqueStrings(ss []string) []string {
 	seen := map[string]struct{}{}
 	var out []string
@@ -445,7 +514,7 @@ func pickLessonForProfile(p *models.Profile, cat string, source string) *models.
 			pool = append(pool, ls...)
 		}
 	} else {
-		pool = append(pool, lessonsByCat[cat]...)
+		pool = append(pool, lessonsByCat[canonicalCategory(cat)]...)
 	}
 	log.Printf("pickLessonForProfile: after category filter, pool=%d lessons", len(pool))
 
@@ -476,14 +545,14 @@ func pickLessonForProfile(p *models.Profile, cat string, source string) *models.
 	avoid := map[string]struct{}{}
 	if maxAvoid > 0 {
 		for i := len(p.RecentLessons) - 1; i >= 0 && len(avoid) < maxAvoid; i-- {
-			title := p.RecentLessons[i]
-			if title == "" {
+			id := p.RecentLessons[i]
+			if id == "" {
 				continue
 			}
-			if _, exists := avoid[title]; exists {
+			if _, exists := avoid[id]; exists {
 				continue
 			}
-			avoid[title] = struct{}{}
+			avoid[id] = struct{}{}
 		}
 	}
 
@@ -491,7 +560,7 @@ func pickLessonForProfile(p *models.Profile, cat string, source string) *models.
 	if len(avoid) > 0 {
 		var candidates []models.Lesson
 		for _, l := range pool {
-			if _, ok := avoid[l.Title]; ok {
+			if _, ok := avoid[l.ID]; ok {
 				continue
 			}
 			candidates = append(candidates, l)
@@ -502,7 +571,7 @@ func pickLessonForProfile(p *models.Profile, cat string, source string) *models.
 	}
 
 	chosen := selection[mrand.Intn(len(selection))]
-	p.RecentLessons = append(p.RecentLessons, chosen.Title)
+	p.RecentLessons = append(p.RecentLessons, chosen.ID)
 	if lessonRepeatWindow > 0 && len(p.RecentLessons) > lessonRepeatWindow*2 {
 		p.RecentLessons = p.RecentLessons[len(p.RecentLessons)-lessonRepeatWindow:]
 	}
@@ -523,10 +592,10 @@ func withSession(next http.Handler) http.Handler {
 				Value:    sid,
 				Path:     "/",
 				HttpOnly: true,
-				MaxAge:   60 * 60 * 24 * 30,
+				MaxAge:   int(sessions.opts.AbsoluteTTL / time.Second),
 				SameSite: http.SameSiteLaxMode,
 			})
-			sessions[sid] = newProfile()
+			sessions.get(sid)
 			r.AddCookie(&http.Cookie{Name: "sid", Value: sid})
 		}
 		next.ServeHTTP(w, r)
@@ -538,21 +607,66 @@ func getProfile(r *http.Request) *models.Profile {
 	if err != nil {
 		return newProfile()
 	}
-	p, ok := sessions[c.Value]
-	if !ok {
-		p = newProfile()
-		sessions[c.Value] = p
-	}
-	return p
+	return sessions.get(c.Value).profile
 }
 
 // ---------- Handlers ----------
 
-func handleLessons(w http.ResponseWriter, r *http.Request) {
+// handleLessonByID serves GET /api/lessons/{id}.
+func handleLessonByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/lessons/"), "/")
+	lesson := findLessonByID(id)
+	if lesson == nil {
+		http.Error(w, `{"error":"lesson not found"}`, http.StatusNotFound)
+		return
+	}
+	if locks := trackLocks(r, learningTracks, tracks.KindLesson, lesson.ID); locks != nil {
+		writeTrackLocked(w, locks)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(lesson)
+}
+
+// handleLessonSearch serves GET /api/lessons/search?q=...&category=&source=&limit=.
+// Facet counts cover every match, before the category/source filters.
+func handleLessonSearch(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	_ = json.NewEncoder(w).Encode(models.LessonsResponse{
-		Categories: categories,
-		Lessons:    lessonsByCat,
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	text := strings.TrimSpace(q.Get("q"))
+	if text == "" {
+		http.Error(w, `{"error":"q is required"}`, http.StatusBadRequest)
+		return
+	}
+	limit := searchLimitDefault
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, `{"error":"invalid limit"}`, http.StatusBadRequest)
+			return
+		}
+		limit = min(n, searchLimitMax)
+	}
+
+	res := lessonIndex.Search(search.Query{
+		Text:     text,
+		Category: canonicalCategory(q.Get("category")),
+		Source:   q.Get("source"),
+		Limit:    limit,
+	})
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"query":   text,
+		"total":   res.Total,
+		"results": res.Hits,
+		"facets":  res.Facets,
 	})
 }
 
@@ -571,8 +685,9 @@ func handleRandom(w http.ResponseWriter, r *http.Request) {
 
 // /api/session supports:
 // GET  stage=lesson           -> returns a random lesson for reading
-// POST stage=add              -> body: {"title":"..."} adds lesson to LessonsSeen
+// POST stage=add              -> body: {"id":"..."} adds lesson to LessonsSeen ("title" is still accepted)
 // POST stage=startQuiz        -> builds quiz from LessonsSeen (or all if empty) and returns first question
+// POST stage=review           -> (signed in) builds quiz from lessons due for spaced-repetition review
 // GET  stage=quiz             -> returns current question (index/total)
 // POST stage=answer           -> body: {"answerIndex":0..3} evals; returns result + maybe next question (More=true)
 func handleSession(w http.ResponseWriter, r *http.Request) {
@@ -610,16 +725,10 @@ func handleSession(w http.ResponseWriter, r *http.Request) {
 				http.Error(w, "no active quiz", http.StatusBadRequest)
 				return
 			}
-			q := p.CurrentQuiz[p.QuizIndex]
-			_ = json.NewEncoder(w).Encode(models.SessionState{
-				Stage:      "quiz",
-				Question:   q.Question,
-				Options:    q.Options,
-				Index:      p.QuizIndex + 1,
-				Total:      len(p.CurrentQuiz),
+			_ = json.NewEncoder(w).Encode(withQuizQuestion(models.SessionState{
 				CoinsTotal: p.Coins,
 				XPTotal:    p.XP,
-			})
+			}, p))
 			return
 		}
 
@@ -627,32 +736,64 @@ func handleSession(w http.ResponseWriter, r *http.Request) {
 		switch stage {
 		case "add":
 			var body struct {
+				ID    string `json:"id"`
 				Title string `json:"title"`
 			}
 			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 				http.Error(w, "bad body", http.StatusBadRequest)
 				return
 			}
-			if body.Title != "" {
-				p.LessonsSeen = uniqueStrings(append(p.LessonsSeen, body.Title))
+			if body.ID == "" && body.Title != "" {
+				if l := findLessonByTitle(body.Title); l != nil {
+					body.ID = l.ID
+				}
+			}
+			if body.ID == "" || findLessonByID(body.ID) == nil {
+				http.Error(w, "unknown lesson", http.StatusNotFound)
+				return
+			}
+			// Only a lesson new to the study list earns XP, so re-adding one
+			// cannot be farmed.
+			xp, xpBefore := 0, p.XP
+			if !slices.Contains(p.LessonsSeen, body.ID) {
+				xp = progress.Default.LessonRead
 			}
+			p.LessonsSeen = uniqueStrings(append(p.LessonsSeen, body.ID))
+			p.XP += xp
 			if token := bearerToken(r); token != "" {
 				if user, err := authUserFromRequest(r); err == nil {
+					xp = 0
 					updateUserByID(user.ID, func(u *models.User) {
 						ensureProfileDefaults(&u.Profile)
-						u.Profile.LessonsSeen = dedupeStrings(append(u.Profile.LessonsSeen, body.Title))
+						xpBefore = u.Profile.XP
+						if !slices.Contains(u.Profile.LessonsSeen, body.ID) {
+							xp = progress.Default.LessonRead
+							recordLedgerLocked(u, models.LedgerEntry{
+								Kind: models.LedgerLessonRead,
+								Ref:  body.ID,
+								XP:   xp,
+							})
+						}
+						u.Profile.LessonsSeen = dedupeStrings(append(u.Profile.LessonsSeen, body.ID))
 						u.Profile.Stats.LessonsRead = len(u.Profile.LessonsSeen)
 						u.Profile.Stats.LastActive = time.Now()
 						u.Profile.UpdatedAt = time.Now()
+						alignSessionWithUser(p, u)
 					})
 				}
 			}
-			_ = json.NewEncoder(w).Encode(map[string]any{
+			resp := map[string]any{
 				"stage":       "added",
 				"lessonsSeen": p.LessonsSeen,
 				"count":       len(p.LessonsSeen),
+				"xpEarned":    xp,
+				"xpTotal":     p.XP,
 				"message":     "lesson added to study list",
-			})
+			}
+			if up := progress.LevelUp(xpBefore, p.XP); up != nil {
+				resp["levelUp"] = up
+			}
+			_ = json.NewEncoder(w).Encode(resp)
 			return
 
 		case "startQuiz":
@@ -660,8 +801,8 @@ func handleSession(w http.ResponseWriter, r *http.Request) {
 			if len(p.LessonsSeen) == 0 {
 				pool = allLessons()
 			} else {
-				for _, t := range p.LessonsSeen {
-					if l := findLessonByTitle(t); l != nil {
+				for _, ref := range p.LessonsSeen {
+					if l := findLessonRef(ref); l != nil {
 						pool = append(pool, *l)
 					}
 				}
@@ -673,24 +814,99 @@ func handleSession(w http.ResponseWriter, r *http.Request) {
 			// build quiz
 			p.CurrentQuiz = nil
 			for _, l := range pool {
-				qq := buildQuizForLesson(l)
+				qq := quizForLesson(l)
 				p.CurrentQuiz = append(p.CurrentQuiz, qq)
 			}
 			// shuffle questions
 			mrand.Shuffle(len(p.CurrentQuiz), func(i, j int) { p.CurrentQuiz[i], p.CurrentQuiz[j] = p.CurrentQuiz[j], p.CurrentQuiz[i] })
 			p.QuizIndex = 0
 			p.QuizScore = 0 // Reset score for new quiz
-			first := p.CurrentQuiz[0]
-			_ = json.NewEncoder(w).Encode(models.SessionState{
-				Stage:      "quiz",
-				Question:   first.Question,
-				Options:    first.Options,
-				Index:      1,
-				Total:      len(p.CurrentQuiz),
+			p.ReviewQuiz = false
+			timed, _ := strconv.ParseBool(r.URL.Query().Get("timed"))
+			startQuizClock(p, time.Now(), timed)
+			_ = json.NewEncoder(w).Encode(withQuizQuestion(models.SessionState{
 				Message:    "quiz started",
 				CoinsTotal: p.Coins,
 				XPTotal:    p.XP,
-			})
+			}, p))
+			return
+
+		case "daily":
+			// Everyone gets the same questions today. A signed-in user's
+			// first start of the day is their one scored attempt; later
+			// starts are practice.
+			now := time.Now()
+			day := dailyKey(now)
+			quiz := dailyQuiz(day)
+			if len(quiz) == 0 {
+				http.Error(w, "no lessons to quiz", http.StatusBadRequest)
+				return
+			}
+			scored := false
+			if token := bearerToken(r); token != "" {
+				if user, err := authUserFromRequest(r); err == nil {
+					updateUserByID(user.ID, func(u *models.User) {
+						if u.Profile.DailyStarted != day {
+							u.Profile.DailyStarted = day
+							scored = true
+						}
+					})
+				}
+			}
+			p.CurrentQuiz = quiz
+			p.QuizIndex = 0
+			p.QuizScore = 0
+			p.ReviewQuiz = false
+			startQuizClock(p, now, false)
+			p.Daily, p.DailyScored = day, scored
+			msg := "daily quiz started"
+			if !scored {
+				msg = "daily quiz started as practice; only the first attempt of the day is scored"
+			}
+			_ = json.NewEncoder(w).Encode(withQuizQuestion(models.SessionState{
+				Message:     msg,
+				CoinsTotal:  p.Coins,
+				XPTotal:     p.XP,
+				Daily:       day,
+				DailyScored: scored,
+			}, p))
+			return
+
+		case "review":
+			user, err := requireAuthUser(w, r)
+			if err != nil {
+				return
+			}
+			due, nextDue := dueReviews(user, time.Now())
+			// Most overdue first, so a capped quiz asks what is slipping.
+			// A lesson gone since dueReviews looked is skipped.
+			var quiz []models.QuizQuestion
+			for _, d := range due {
+				if len(quiz) == reviewQuizMax {
+					break
+				}
+				if l := findLessonByID(d.LessonID); l != nil {
+					quiz = append(quiz, quizForLesson(*l))
+				}
+			}
+			if len(quiz) == 0 {
+				resp := map[string]any{"stage": "review", "total": 0, "message": "nothing due for review"}
+				if !nextDue.IsZero() {
+					resp["nextDue"] = nextDue
+				}
+				_ = json.NewEncoder(w).Encode(resp)
+				return
+			}
+			p.CurrentQuiz = quiz
+			p.QuizIndex = 0
+			p.QuizScore = 0
+			p.ReviewQuiz = true
+			startQuizClock(p, time.Now(), false)
+			_ = json.NewEncoder(w).Encode(withQuizQuestion(models.SessionState{
+				Message:    "review started",
+				CoinsTotal: p.Coins,
+				XPTotal:    p.XP,
+			}, p))
 			return
 
 		case "answer":
@@ -698,65 +914,110 @@ func handleSession(w http.ResponseWriter, r *http.Request) {
 				http.Error(w, "no active quiz", http.StatusBadRequest)
 				return
 			}
-			var body struct {
-				AnswerIndex int `json:"answerIndex"`
-			}
+			var body quizAnswer
 			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 				http.Error(w, "bad body", http.StatusBadRequest)
 				return
 			}
 			cur := p.CurrentQuiz[p.QuizIndex]
-			correct := body.AnswerIndex == cur.CorrectIndex
-			earned := 0
+			correct, feedback := gradeQuizAnswer(cur, body)
+			now := time.Now()
+			// A timed answer is judged by the server's clock: past its
+			// deadline it scores zero, and past the quiz deadline the quiz
+			// ends. The feedback still shows the right answer.
+			var late, timeUp bool
+			var left time.Duration
+			if p.Timed {
+				left = questionDeadline(p).Sub(now)
+				late = left < -timedAnswerGrace
+				timeUp = now.Sub(p.QuizDeadline) > timedAnswerGrace
+				correct = correct && !late
+			}
+			aq := attemptQuestion(cur, body, correct, p.QuestionAsked, now)
+			aq.Late = late
+			p.QuizAnswers = append(p.QuizAnswers, aq)
+			earned, points := 0, 0
+			xpBefore := p.XP
 			if correct {
 				earned = 10
+				points = 1
+				if p.Timed {
+					points = timedPoints(left)
+				}
 				p.Coins += earned
 				p.Streak += 1
-				p.QuizScore++ // Track correct answers server-side
+				p.QuizScore += points // Track the score server-side
 			} else {
 				p.Streak = 0
 			}
+			xp := progress.Default.QuizAnswer(correct, p.Streak)
+			p.XP += xp
+			var user *models.User
 			if token := bearerToken(r); token != "" {
-				if user, err := authUserFromRequest(r); err == nil {
+				if u, err := authUserFromRequest(r); err == nil {
+					user = u
 					updateUserByID(user.ID, func(u *models.User) {
-						u.Profile.Coins = p.Coins
-						u.Profile.XP = p.XP
-						u.Profile.QuizStreak = p.Streak
-						u.Profile.Stats.QuizzesTaken++
-						if correct {
-							u.Profile.Stats.QuizCorrect++
-						}
-						u.Profile.Stats.LastActive = time.Now()
-						u.Profile.UpdatedAt = time.Now()
+						xpBefore = u.Profile.XP
+						recordLedgerLocked(u, models.LedgerEntry{
+							Kind:    models.LedgerQuizAnswer,
+							Ref:     cur.LessonID,
+							Coins:   earned,
+							XP:      xp,
+							Correct: correct,
+							At:      now,
+						})
+						alignSessionWithUser(p, u)
 					})
 				}
 			}
 			// advance
 			p.QuizIndex++
-			more := p.QuizIndex < len(p.CurrentQuiz)
+			more := p.QuizIndex < len(p.CurrentQuiz) && !timeUp
 
 			resp := models.SessionState{
 				Stage:       "result",
+				Timed:       p.Timed,
 				Correct:     correct,
+				Late:        late,
+				Points:      points,
+				Feedback:    feedback,
 				CoinsEarned: earned,
 				CoinsTotal:  p.Coins,
+				XPEarned:    xp,
 				XPTotal:     p.XP,
+				LevelUp:     progress.LevelUp(xpBefore, p.XP),
 				More:        more,
-				Message:     map[bool]string{true: "Correct! +10 coins", false: "Not quite. Keep going!"}[correct],
+				Message:     map[bool]string{true: fmt.Sprintf("Correct! +10 coins · +%d XP", xp), false: "Not quite. Keep going!"}[correct],
+			}
+			switch {
+			case timeUp:
+				resp.Message = "Time's up! The quiz is over."
+			case late:
+				resp.Message = "Too slow: that answer came after the deadline."
 			}
 			// include next question if more
 			if more {
-				next := p.CurrentQuiz[p.QuizIndex]
-				resp.Stage = "quiz"
-				resp.Question = next.Question
-				resp.Options = next.Options
-				resp.Index = p.QuizIndex + 1
-				resp.Total = len(p.CurrentQuiz)
+				p.QuestionAsked = now
+				resp = withQuizQuestion(resp, p)
 			} else {
-				// end of quiz; clear selection list but keep progress coins/streak
+				if user != nil {
+					resp.AttemptID = saveQuizAttempt(user.ID, p, now)
+					if p.Daily != "" && p.DailyScored {
+						resp = finishDailyQuiz(resp, user.ID, p, xpBefore, now)
+					}
+				}
+				// end of quiz; clear selection list but keep progress coins/streak.
+				// A review quiz leaves the study list alone.
+				if !p.ReviewQuiz {
+					p.LessonsSeen = nil
+				}
 				p.CurrentQuiz = nil
-				p.LessonsSeen = nil
 				p.QuizIndex = 0
+				p.ReviewQuiz = false
+				p.QuizAnswers = nil
+				p.Timed = false
+				p.QuizDeadline = time.Time{}
+				p.Daily, p.DailyScored = "", false
 			}
 			_ = json.NewEncoder(w).Encode(resp)
 			return
@@ -775,6 +1036,16 @@ func handleProChallenge(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "no challenges available", http.StatusServiceUnavailable)
 		return
 	}
+	if daily, _ := strconv.ParseBool(r.URL.Query().Get("daily")); daily {
+		ch, _ := dailyChallenge(dailyKey(time.Now()))
+		if locks := trackLocks(r, learningTracks, tracks.KindChallenge, ch.ID); locks != nil {
+			writeTrackLocked(w, locks)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(ch)
+		return
+	}
 
 	difficulty := strings.TrimSpace(strings.ToLower(r.URL.Query().Get("difficulty")))
 	if difficulty == "" {
@@ -782,7 +1053,9 @@ func handleProChallenge(w http.ResponseWriter, r *http.Request) {
 	}
 	topic := strings.TrimSpace(strings.ToLower(r.URL.Query().Get("topic")))
 
+	// Challenges locked by the caller's tracks are left out of the pick.
 	var pool []models.ProChallenge
+	var locks []trackLock
 	for _, ch := range proChallenges {
 		if difficulty != "" && difficulty != "any" && !strings.EqualFold(ch.Difficulty, difficulty) {
 			continue
@@ -799,9 +1072,17 @@ func handleProChallenge(w http.ResponseWriter, r *http.Request) {
 				continue
 			}
 		}
+		if l := trackLocks(r, learningTracks, tracks.KindChallenge, ch.ID); l != nil {
+			locks = append(locks, l...)
+			continue
+		}
 		pool = append(pool, ch)
 	}
 
+	if len(pool) == 0 && locks != nil {
+		writeTrackLocked(w, locks)
+		return
+	}
 	if len(pool) == 0 {
 		http.Error(w, "no challenge found for selection", http.StatusNotFound)
 		return
@@ -812,82 +1093,6 @@ func handleProChallenge(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(selected)
 }
 
-func handleProChallengeSubmit(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-	p := getProfile(r)
-	if p.HintIdx == nil {
-		p.HintIdx = map[string]int{}
-	}
-
-	var body struct {
-		ID   string `json:"id"`
-		Code string `json:"code"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		http.Error(w, "invalid body", http.StatusBadRequest)
-		return
-	}
-	ch, ok := proChallengesByID[body.ID]
-	if !ok {
-		http.Error(w, "challenge not found", http.StatusNotFound)
-		return
-	}
-
-	res, err := runChallengeTests(r.Context(), ch, body.Code)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("test execution failed: %v", err), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	if res.Passed {
-		p.Coins += ch.Reward.Coins
-		p.XP += ch.Reward.XP
-		p.CodingScore += ch.Reward.XP // Track coding score for leaderboard
-	}
-	if token := bearerToken(r); token != "" {
-		if user, err := authUserFromRequest(r); err == nil {
-			updateUserByID(user.ID, func(u *models.User) {
-				u.Profile.Coins = p.Coins
-				u.Profile.XP = p.XP
-				u.Profile.CodingScore = p.CodingScore
-				u.Profile.Stats.CodingSubmissions++
-				if res.Passed {
-					u.Profile.Stats.CodingPassed++
-				}
-				u.Profile.Stats.LastActive = time.Now()
-				u.Profile.UpdatedAt = time.Now()
-			})
-		}
-	}
-	if res.Passed {
-		resp := map[string]any{
-			"passed":      true,
-			"total":       res.Total,
-			"coinsEarned": ch.Reward.Coins,
-			"coinsTotal":  p.Coins,
-			"xpEarned":    ch.Reward.XP,
-			"xpTotal":     p.XP,
-			"message":     fmt.Sprintf("All tests passed! +%d coins · +%d XP", ch.Reward.Coins, ch.Reward.XP),
-			"stdout":      res.Stdout,
-		}
-		_ = json.NewEncoder(w).Encode(resp)
-		return
-	}
-
-	resp := map[string]any{
-		"passed":   false,
-		"total":    res.Total,
-		"failures": res.Failures,
-		"stdout":   res.Stdout,
-		"stderr":   res.Stderr,
-	}
-	_ = json.NewEncoder(w).Encode(resp)
-}
-
 func handleProChallengeHint(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -900,21 +1105,21 @@ func handleProChallengeHint(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid body", http.StatusBadRequest)
 		return
 	}
-	ch, ok := proChallengesByID[body.ID]
+	ch, ok := lookupProChallenge(body.ID)
 	if !ok {
 		http.Error(w, "challenge not found", http.StatusNotFound)
 		return
 	}
+	if locks := trackLocks(r, lookupTracks(), tracks.KindChallenge, ch.ID); locks != nil {
+		writeTrackLocked(w, locks)
+		return
+	}
 
 	p := getProfile(r)
 	if p.HintIdx == nil {
 		p.HintIdx = map[string]int{}
 	}
-	if p.Coins >= 2 {
-		p.Coins -= 2
-	} else {
-		p.Coins = 0
-	}
+	p.Coins = max(p.Coins-hintCost, 0)
 
 	index := p.HintIdx[ch.ID]
 	var hint string
@@ -931,6 +1136,19 @@ func handleProChallengeHint(w http.ResponseWriter, r *http.Request) {
 		index = len(ch.Hints)
 	}
 	p.HintIdx[ch.ID] = index
+	if token := bearerToken(r); token != "" {
+		if user, err := authUserFromRequest(r); err == nil {
+			updateUserByID(user.ID, func(u *models.User) {
+				recordLedgerLocked(u, models.LedgerEntry{
+					Kind:  models.LedgerHintPurchase,
+					Ref:   ch.ID,
+					Coins: -min(hintCost, u.Profile.Coins),
+				})
+				u.Profile.HintIdx[ch.ID] = index
+				alignSessionWithUser(p, u)
+			})
+		}
+	}
 
 	resp := map[string]any{
 		"hint":       hint,
@@ -943,29 +1161,16 @@ func handleProChallengeHint(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
-// Build one MCQ for a lesson (correct = lesson explain/text; distractors from others)
-func buildQuizForLesson(l models.Lesson) models.QuizQuestion {
+// buildQuizForLesson generates a multiple choice question for l: the correct
+// option is the lesson's explanation or text, the distractors come from its
+// precomputed pool of similar lessons (see updateLessonMap). shuffle is
+// mrand.Shuffle, or a seeded generator's Shuffle when the question must come
+// out the same every time.
+func buildQuizForLesson(l models.Lesson, shuffle func(n int, swap func(i, j int))) models.QuizQuestion {
 	question := fmt.Sprintf("Which statement best matches the concept '%s'?", l.Title)
-	correct := strings.TrimSpace(l.Explain)
-	if correct == "" {
-		correct = strings.TrimSpace(l.Text)
-	}
-	var pool []string
-	for _, ls := range lessonsByCat {
-		for _, x := range ls {
-			if x.Title == l.Title {
-				continue
-			}
-			cur := strings.TrimSpace(x.Explain)
-			if cur == "" {
-				cur = strings.TrimSpace(x.Text)
-			}
-			if cur != "" {
-				pool = append(pool, cur)
-			}
-		}
-	}
-	mrand.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+	correct := search.AnswerText(l)
+	pool := slices.Clone(lessonDistractors[l.ID])
+	shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
 	opts := []string{correct}
 	for i := 0; i < 3 && i < len(pool); i++ {
 		opts = append(opts, pool[i])
@@ -973,7 +1178,7 @@ func buildQuizForLesson(l models.Lesson) models.QuizQuestion {
 	for len(opts) < 4 {
 		opts = append(opts, "This option does not apply to the concept.")
 	}
-	mrand.Shuffle(len(opts), func(i, j int) { opts[i], opts[j] = opts[j], opts[i] })
+	shuffle(len(opts), func(i, j int) { opts[i], opts[j] = opts[j], opts[i] })
 	correctIdx := 0
 	for i, o := range opts {
 		if o == correct {
@@ -982,14 +1187,19 @@ func buildQuizForLesson(l models.Lesson) models.QuizQuestion {
 		}
 	}
 	return models.QuizQuestion{
+		LessonID:     l.ID,
 		LessonTitle:  l.Title,
+		Type:         models.QuestionMultipleChoice,
 		Question:     question,
 		Options:      opts,
 		CorrectIndex: correctIdx,
 	}
 }
 
-func runChallengeTests(parent context.Context, ch models.ProChallenge, source string) (models.ChallengeTestResult, error) {
+// runChallengeTests compiles source with ch's hidden tests and runs them in
+// the sandbox. The build errors and test output are copied to live, when not
+// nil, as they are produced.
+func runChallengeTests(parent context.Context, ch models.ProChallenge, source string, live io.Writer) (models.ChallengeTestResult, error) {
 	var result models.ChallengeTestResult
 	if strings.Contains(ch.ID, "..") {
 		return result, fmt.Errorf("invalid challenge id")
@@ -999,53 +1209,123 @@ func runChallengeTests(parent context.Context, ch models.ProChallenge, source st
 		return result, nil
 	}
 
-	tempDir, err := os.MkdirTemp("", "avid-pro-*")
+	if !sandboxRunner.Available() {
+		return result, sandbox.ErrUnavailable
+	}
+	goroot, err := goRoot()
 	if err != nil {
 		return result, err
 	}
-	defer os.RemoveAll(tempDir)
-
-	mod := "module example.com/protmp\n\ngo 1.24\n"
-	if err := os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte(mod), 0o644); err != nil {
+	cache, err := challengeCacheDir()
+	if err != nil {
 		return result, err
 	}
-	if err := os.WriteFile(filepath.Join(tempDir, "challenge.go"), []byte(source), 0o644); err != nil {
+	test2json, err := challengeTest2JSON()
+	if err != nil {
 		return result, err
 	}
 
-	testSrc, err := resolveChallengeTestPath(ch.ID)
+	pool, err := challengeWorkspaces()
 	if err != nil {
 		return result, err
 	}
-	if err := writeChallengeTest(filepath.Join(tempDir, "challenge_test.go"), testSrc); err != nil {
+	workDir, release, err := pool.acquire(ch, source)
+	if err != nil {
 		return result, err
 	}
+	defer release()
 
-	runCtx, cancel := context.WithTimeout(parent, 5*time.Second)
-	defer cancel()
-
-	cmd := exec.CommandContext(runCtx, "go", "test", "-run", "Test", "-count=1", "-timeout=3s", "./...")
-	cmd.Dir = tempDir
+	// Compile first, in the workspace with the build cache mounted but none
+	// of the submission's code running, then run the test binary from a
+	// scratch directory with the workspace read-only and without the cache.
 	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	var out, errOut io.Writer = &stdout, &stderr
+	if live != nil {
+		out, errOut = io.MultiWriter(&stdout, live), io.MultiWriter(&stderr, live)
+	}
+	var exitErr *exec.ExitError
+	buildCtx, cancelBuild := context.WithTimeout(parent, challengeBuildTimeout)
+	defer cancelBuild()
+	buildStart := time.Now()
+	challengeCacheMu.RLock()
+	buildErr := sandboxRunner.Run(buildCtx, sandbox.Command{
+		Path:   filepath.Join(goroot, "bin", "go"),
+		Args:   challengeBuildArgs("challenge.test"),
+		Env:    challengeBuildEnv(goroot, cache, workDir),
+		Dir:    workDir,
+		Mounts: []sandbox.Mount{{Path: goroot}, {Path: cache, Writable: true}},
+		Limits: sandbox.Limits{CPU: challengeBuildTimeout},
+		Stdout: out,
+		Stderr: errOut,
+	})
+	challengeCacheMu.RUnlock()
+	result.CompileTime = time.Since(buildStart)
+	switch {
+	case buildCtx.Err() != nil && parent.Err() == nil:
+		result.Failures = []models.TestFailure{{Name: "build", Output: "build exceeded time limit"}}
+		return result, nil
+	case errors.As(buildErr, &exitErr):
+		result.Stdout = strings.TrimSpace(stdout.String())
+		result.Stderr = strings.TrimSpace(stderr.String())
+		result.Failures = []models.TestFailure{{Name: "build", Output: result.Stderr}}
+		result.Diagnostics = gotest.Diagnostics(result.Stderr, workDir)
+		return result, nil
+	case buildErr != nil:
+		return result, buildErr
+	}
+
+	runDir, err := os.MkdirTemp("", "avid-pro-*")
+	if err != nil {
+		return result, err
+	}
+	defer os.RemoveAll(runDir)
 
-	runErr := cmd.Run()
-	result.Stdout = strings.TrimSpace(stdout.String())
+	// test2json runs the binary in its -test.v=test2json mode, as go test
+	// -json does, and writes events for the converter to build the test tree
+	// from. The test timeout starts here: compiling does not count against it.
+	stderr.Reset()
+	conv := gotest.NewConverter(live, gradingOutputMax)
+	runCtx, cancel := context.WithTimeout(parent, challengeTestTimeout)
+	defer cancel()
+	runStart := time.Now()
+	runErr := sandboxRunner.Run(runCtx, sandbox.Command{
+		Path: test2json,
+		Args: []string{"test2json", "-p", "challenge", filepath.Join(workDir, "challenge.test"),
+			"-test.v=test2json", "-test.run=Test", "-test.count=1", "-test.timeout=3s"},
+		Env:      challengeTestEnv(runDir),
+		Dir:      runDir,
+		Mounts:   []sandbox.Mount{{Path: filepath.Dir(test2json)}, {Path: workDir}},
+		Limits:   sandbox.Limits{CPU: 2 * challengeTestTimeout, Memory: 1 << 30},
+		Loopback: ch.Loopback,
+		Stdout:   conv,
+		Stderr:   errOut,
+	})
+	result.RunTime = time.Since(runStart)
+	if runErr != nil && !errors.As(runErr, &exitErr) && runCtx.Err() == nil {
+		return result, runErr
+	}
+	report := conv.Report()
+	result.Tests = report.Tests
+	result.Total = report.Total()
+	result.Stdout = strings.TrimSpace(report.Output)
 	result.Stderr = strings.TrimSpace(stderr.String())
-	result.Total = countTests(result.Stdout + "\n" + result.Stderr)
+	result.Truncated = report.Truncated
 
-	if runErr != nil {
-		result.Failures = extractFailures(result.Stdout + "\n" + result.Stderr)
+	if runErr != nil || report.Result != "pass" {
+		result.Failures = report.Failures()
+		if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+			timeout := models.TestFailure{Name: "timeout", Output: "tests exceeded execution time limit"}
+			result.Failures = append([]models.TestFailure{timeout}, result.Failures...)
+		}
 		if len(result.Failures) == 0 {
-			if errors.Is(runErr, context.DeadlineExceeded) || errors.Is(runCtx.Err(), context.DeadlineExceeded) {
-				result.Failures = []models.TestFailure{{Name: "timeout", Output: "tests exceeded execution time limit"}}
+			if result.Stdout != "" {
+				result.Failures = []models.TestFailure{{Name: "tests", Output: result.Stdout}}
 			} else if result.Stderr != "" {
 				result.Failures = []models.TestFailure{{Name: "tests", Output: result.Stderr}}
-			} else if result.Stdout != "" {
-				result.Failures = []models.TestFailure{{Name: "tests", Output: result.Stdout}}
-			} else {
+			} else if runErr != nil {
 				result.Failures = []models.TestFailure{{Name: "tests", Output: runErr.Error()}}
+			} else {
+				result.Failures = []models.TestFailure{{Name: "tests", Output: "no test result reported"}}
 			}
 		}
 		return result, nil
@@ -1069,10 +1349,12 @@ func resolveChallengeTestPath(id string) (string, error) {
 	return "", fmt.Errorf("hidden tests for %s not found", id)
 }
 
-func writeChallengeTest(dst, src string) error {
+// readChallengeTest reads the hidden tests at src without their build
+// constraint line, which keeps them out of the server's own build.
+func readChallengeTest(src string) ([]byte, error) {
 	data, err := os.ReadFile(src)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	lines := strings.Split(string(data), "\n")
 	if len(lines) > 0 && strings.HasPrefix(strings.TrimSpace(lines[0]), "//go:build") {
@@ -1081,66 +1363,22 @@ func writeChallengeTest(dst, src string) error {
 			lines = lines[1:]
 		}
 	}
-	cleaned := strings.Join(lines, "\n")
-	return os.WriteFile(dst, []byte(cleaned), 0o644)
+	return []byte(strings.Join(lines, "\n")), nil
 }
 
-func countTests(out string) int {
-	count := strings.Count(out, "--- PASS:")
-	count += strings.Count(out, "--- FAIL:")
-	count += strings.Count(out, "--- SKIP:")
-	if count == 0 && strings.Contains(out, "PASS\n") {
-		// fallback when test output suppressed
-		count = 1
-	}
-	return count
+// Liberal CORS so frontend dev server can call POST endpoints
+func cors(next http.HandlerFunc) http.HandlerFunc {
+	return withCORS(withSession(withSessionLock(next)))
 }
 
-func extractFailures(out string) []models.TestFailure {
-	lines := strings.Split(out, "\n")
-	var (
-		current *models.TestFailure
-		result  []models.TestFailure
-	)
-	flush := func() {
-		if current != nil {
-			result = append(result, models.TestFailure{
-				Name:   current.Name,
-				Output: strings.TrimSpace(current.Output),
-			})
-			current = nil
-		}
-	}
-
-	for _, raw := range lines {
-		line := strings.TrimSpace(raw)
-		if line == "" {
-			continue
-		}
-		if strings.HasPrefix(line, "--- FAIL:") {
-			flush()
-			fields := strings.Fields(line)
-			name := ""
-			if len(fields) >= 3 {
-				name = fields[2]
-			}
-			current = &models.TestFailure{Name: name, Output: line}
-			continue
-		}
-		if strings.HasPrefix(line, "--- PASS:") || strings.HasPrefix(line, "--- SKIP:") || strings.HasPrefix(line, "PASS") || strings.HasPrefix(line, "FAIL") || strings.HasPrefix(line, "ok ") {
-			flush()
-			continue
-		}
-		if current != nil {
-			current.Output += "\n" + line
-		}
-	}
-	flush()
-	return result
+// corsUnlocked is cors without holding the session lock, for long-lived
+// requests such as event streams that must not block the session's other
+// requests. Handlers behind it do not touch the session profile.
+func corsUnlocked(next http.HandlerFunc) http.HandlerFunc {
+	return withCORS(withSession(next))
 }
 
-// Liberal CORS so frontend dev server can call POST endpoints
-func cors(next http.HandlerFunc) http.HandlerFunc {
+func withCORS(next http.Handler) http.HandlerFunc {
 	allowed := os.Getenv("ALLOWED_ORIGIN")
 	if allowed == "" {
 		allowed = "*" // dev-friendly
@@ -1156,12 +1394,13 @@ func cors(next http.HandlerFunc) http.HandlerFunc {
 		}
 		w.Header().Set("Vary", "Origin")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-None-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, Location, Retry-After")
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
 			return
 		}
-		withSession(next).ServeHTTP(w, r)
+		next.ServeHTTP(w, r)
 	}
 }
 
@@ -1223,6 +1462,7 @@ func handleAIGenerate(w http.ResponseWriter, r *http.Request) {
 
 	// Convert AI lesson to main Lesson type
 	mainLesson := &models.Lesson{
+		ID:       lessons.NewID("ai", lesson.Title),
 		Title:    lesson.Title,
 		Category: lesson.Category,
 		Text:     lesson.Text,
@@ -1341,7 +1581,13 @@ func handleLeaderboardSubmit(w http.ResponseWriter, r *http.Request) {
 	// SERVER-SIDE VALIDATION: Check if score is legitimate
 	var validatedScore int
 	switch req.Mode {
-	case "quiz":
+	case "quiz", "quiz-timed":
+		// A timed answer scores up to timedMaxPoints and an untimed one 1,
+		// so each kind of quiz has its own board.
+		if p.QuizScoreTimed != (req.Mode == "quiz-timed") {
+			http.Error(w, `{"error":"timed quiz scores go on the quiz-timed board, untimed ones on the quiz board"}`, http.StatusBadRequest)
+			return
+		}
 		validatedScore = p.QuizScore
 		if req.Score > validatedScore {
 			http.Error(w, `{"error":"invalid score: server validation failed"}`, http.StatusForbidden)
@@ -1379,35 +1625,39 @@ func handleLeaderboardSubmit(w http.ResponseWriter, r *http.Request) {
 		req.Name = req.Name[:30]
 	}
 
+	xp := p.XP
+	if authUser != nil {
+		xp = authUser.Profile.XP
+	}
+	level := progress.LevelFor(xp)
 	entry := models.LeaderboardEntry{
 		Name:     req.Name,
 		Score:    req.Score,
 		Mode:     req.Mode,
 		Category: req.Category,
 		Date:     time.Now(),
+		Level:    level.Level,
+		Title:    level.Title,
 	}
 
 	leaderboard = append(leaderboard, entry)
 
-	// Keep only top 1000 entries to prevent memory issues
-	if len(leaderboard) > 1000 {
+	// Keep only top entries to prevent memory issues
+	if len(leaderboard) > leaderboardLimit {
 		sort.Slice(leaderboard, func(i, j int) bool {
 			return leaderboard[i].Score > leaderboard[j].Score
 		})
-		leaderboard = leaderboard[:1000]
+		leaderboard = leaderboard[:leaderboardLimit]
 	}
 
 	// Save to disk immediately
-	leaderboardPath := os.Getenv("LEADERBOARD_FILE")
-	if leaderboardPath == "" {
-		leaderboardPath = filepath.Join("..", "data", "leaderboard.json")
-		if _, err := os.Stat(filepath.Dir(leaderboardPath)); os.IsNotExist(err) {
-			leaderboardPath = filepath.Join("data", "leaderboard.json")
+	if dataStore != nil {
+		if err := dataStore.AddLeaderboardEntry(entry, leaderboardLimit); err != nil {
+			log.Printf("Error saving leaderboard entry: %v", err)
+		} else if err := dataStore.Flush(); err != nil {
+			log.Printf("Error saving leaderboard: %v", err)
 		}
 	}
-	if err := saveLeaderboard(leaderboardPath); err != nil {
-		log.Printf("Error saving leaderboard: %v", err)
-	}
 
 	response := map[string]interface{}{
 		"success": true,
@@ -1429,7 +1679,43 @@ func calculateRank(entry models.LeaderboardEntry) int {
 	return rank
 }
 
-// handleTypingScore updates the typing score for the session
+// handleTypingStart starts a typing session on a lesson's text. Its score
+// is judged against the server's clock from here.
+//
+//	POST /api/typing/start { lessonId } -> { started, chars }
+func handleTypingStart(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		LessonID string `json:"lessonId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	lesson := findLessonByID(req.LessonID)
+	if lesson == nil {
+		http.Error(w, `{"error":"lesson not found"}`, http.StatusNotFound)
+		return
+	}
+
+	p := getProfile(r)
+	p.TypingStarted = time.Now()
+	p.TypingChars = utf8.RuneCountInString(lesson.Text)
+	_ = json.NewEncoder(w).Encode(map[string]any{"started": true, "chars": p.TypingChars})
+}
+
+// handleTypingScore ends the session's typing session. The score is the
+// client's WPM, credited only up to what typing chars correct characters
+// (at most the text's length) since the start allows; each session is
+// scored once.
+//
+//	POST /api/typing/score { score, chars } -> { success, score, credited, xpEarned, xpTotal, levelUp? }
 func handleTypingScore(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 
@@ -1442,6 +1728,7 @@ func handleTypingScore(w http.ResponseWriter, r *http.Request) {
 
 	var req struct {
 		Score int `json:"score"`
+		Chars int `json:"chars"` // correctly typed characters
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -1449,31 +1736,55 @@ func handleTypingScore(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Score < 0 {
+	if req.Score < 0 || req.Chars < 0 {
 		http.Error(w, `{"error":"invalid score"}`, http.StatusBadRequest)
 		return
 	}
+	if p.TypingStarted.IsZero() {
+		http.Error(w, `{"error":"no typing session in progress"}`, http.StatusConflict)
+		return
+	}
+	elapsed := time.Since(p.TypingStarted)
+	chars := min(req.Chars, p.TypingChars)
+	p.TypingStarted, p.TypingChars = time.Time{}, 0
+	credited := 0
+	if elapsed >= typingMinSession && chars >= typingMinChars {
+		// Five characters to the word.
+		credited = min(req.Score, int(float64(chars)/5/elapsed.Minutes()))
+	}
 
 	// Update typing score (keep best)
-	if req.Score > p.TypingScore {
-		p.TypingScore = req.Score
+	if credited > p.TypingScore {
+		p.TypingScore = credited
 	}
-	if token := bearerToken(r); token != "" {
+	xp, xpBefore := 0, p.XP
+	if credited > 0 {
+		xp = progress.Default.Typing(credited)
+	}
+	p.XP += xp
+	if token := bearerToken(r); token != "" && credited > 0 {
 		if user, err := authUserFromRequest(r); err == nil {
 			updateUserByID(user.ID, func(u *models.User) {
-				if p.TypingScore > u.Profile.TypingBest {
-					u.Profile.TypingBest = p.TypingScore
-				}
-				u.Profile.Stats.TypingSessions++
-				u.Profile.Stats.LastActive = time.Now()
-				u.Profile.UpdatedAt = time.Now()
+				xpBefore = u.Profile.XP
+				recordLedgerLocked(u, models.LedgerEntry{
+					Kind:        models.LedgerTypingSession,
+					XP:          xp,
+					TypingScore: credited,
+				})
+				alignSessionWithUser(p, u)
 			})
 		}
 	}
 
 	response := map[string]interface{}{
-		"success": true,
-		"score":   p.TypingScore,
+		"success":  true,
+		"score":    p.TypingScore,
+		"credited": credited,
+		"xpEarned": xp,
+		"xpTotal":  p.XP,
+	}
+	if up := progress.LevelUp(xpBefore, p.XP); up != nil {
+		response["levelUp"] = up
 	}
 
 	_ = json.NewEncoder(w).Encode(response)
@@ -0,0 +1,103 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"avidlearner/internal/lessons"
+	"avidlearner/internal/models"
+)
+
+func TestQuizAttemptHistoryAndReview(t *testing.T) {
+	_, token := setupLedgerTest(t)
+	sessions = newSessionManager(SessionOptions{})
+	updateLessonMap([]lessons.Lesson{
+		{ID: "caching", Title: "Caching", Category: "performance", Text: "Cache hot reads.", Explain: "Reads dominate.", Tips: []string{"Set a TTL"}},
+		{ID: "retries", Title: "Retries", Category: "reliability", Text: "Retry with backoff.", Explain: "Jitter spreads load.", Tips: []string{"Cap attempts"}},
+	})
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: "sid", Value: "attempt-sid"})
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		switch {
+		case strings.HasPrefix(target, "/api/session"):
+			handleSession(rr, req)
+		case strings.HasPrefix(target, "/api/quiz/attempts/"):
+			handleQuizAttempt(rr, req)
+		default:
+			handleQuizAttempts(rr, req)
+		}
+		return rr
+	}
+	session := func(stage, body string) models.SessionState {
+		t.Helper()
+		rr := do(http.MethodPost, "/api/session?stage="+stage, body)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: %d %s", stage, rr.Code, rr.Body.String())
+		}
+		var st models.SessionState
+		_ = json.Unmarshal(rr.Body.Bytes(), &st)
+		return st
+	}
+
+	sessions.get("attempt-sid").profile.LessonsSeen = []string{"caching", "retries"}
+	st := session("startQuiz", "")
+	var missedTitle string
+	for st.Stage == "quiz" {
+		p := sessions.get("attempt-sid").profile
+		cur := p.CurrentQuiz[p.QuizIndex]
+		answer := cur.CorrectIndex
+		if cur.LessonID == "retries" {
+			answer = (answer + 1) % len(cur.Options)
+			missedTitle = cur.LessonTitle
+		}
+		st = session("answer", `{"answerIndex":`+strconv.Itoa(answer)+`}`)
+	}
+	if st.AttemptID == 0 {
+		t.Fatalf("expected the finished quiz to be saved, got %+v", st)
+	}
+
+	rr := do(http.MethodGet, "/api/quiz/attempts", "")
+	var list struct {
+		Attempts []attemptSummary `json:"attempts"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode: %v (%s)", err, rr.Body.String())
+	}
+	if len(list.Attempts) != 1 || list.Attempts[0].ID != st.AttemptID || list.Attempts[0].Score != 1 || list.Attempts[0].Missed != 1 {
+		t.Fatalf("unexpected attempts %+v", list.Attempts)
+	}
+
+	rr = do(http.MethodGet, "/api/quiz/attempts/"+strconv.FormatInt(st.AttemptID, 10), "")
+	var detail struct {
+		Attempt models.QuizAttempt `json:"attempt"`
+		Misses  []missReview       `json:"misses"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &detail); err != nil {
+		t.Fatalf("decode: %v (%s)", err, rr.Body.String())
+	}
+	if len(detail.Attempt.Questions) != 2 || detail.Attempt.Questions[0].AnsweredAt.IsZero() {
+		t.Fatalf("expected both questions with timing, got %+v", detail.Attempt)
+	}
+	if len(detail.Misses) != 1 {
+		t.Fatalf("expected one miss, got %+v", detail.Misses)
+	}
+	miss := detail.Misses[0]
+	if miss.LessonTitle != missedTitle || miss.Lesson == nil || miss.Lesson.Explain != "Jitter spreads load." || len(miss.Lesson.Tips) != 1 {
+		t.Fatalf("expected the retries lesson explained, got %+v", miss)
+	}
+	if miss.Chosen[0] == miss.Correct[0] {
+		t.Fatalf("miss should record the wrong choice, got %+v", miss.AttemptQuestion)
+	}
+
+	if rr := do(http.MethodGet, "/api/quiz/attempts/999", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown attempt, got %d", rr.Code)
+	}
+}
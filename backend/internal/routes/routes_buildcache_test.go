@@ -0,0 +1,124 @@
+package routes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"avidlearner/internal/models"
+)
+
+func TestTrimBuildCacheRemovesLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	// Ten 1 KiB entries, entry i last used i hours ago, and a file of the go
+	// command's own at the top.
+	for i := range 10 {
+		sub := filepath.Join(dir, "0"+string(rune('0'+i)))
+		if err := os.MkdirAll(sub, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(sub, "entry-a")
+		if err := os.WriteFile(path, make([]byte, 1024), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		used := now.Add(-time.Duration(i) * time.Hour)
+		if err := os.Chtimes(path, used, used); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "trim.txt"), make([]byte, 4096), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := trimBuildCache(dir, 10*1024); err != nil || n != 0 {
+		t.Fatalf("expected a cache within its size left alone, removed %d: %v", n, err)
+	}
+	n, err := trimBuildCache(dir, 8*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Down to three quarters of 8 KiB: the four oldest entries go.
+	if n != 4 {
+		t.Fatalf("expected 4 entries removed, got %d", n)
+	}
+	for i := range 10 {
+		_, err := os.Stat(filepath.Join(dir, "0"+string(rune('0'+i)), "entry-a"))
+		if kept := err == nil; kept != (i < 6) {
+			t.Errorf("entry used %d hours ago: kept %v", i, kept)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "trim.txt")); err != nil {
+		t.Errorf("expected the go command's own files kept: %v", err)
+	}
+}
+
+func TestWorkspacePoolReusesPreparedWorkspaces(t *testing.T) {
+	t.Chdir(t.TempDir())
+	tests := filepath.Join("protests", "pooled", "challenge_test.go")
+	if err := os.MkdirAll(filepath.Dir(tests), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTests := func(body string) {
+		t.Helper()
+		if err := os.WriteFile(tests, []byte("//go:build ignore\n\npackage challenge\n"+body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTests("")
+	pool, err := newWorkspacePool(filepath.Join(t.TempDir(), "work"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := models.ProChallenge{ID: "pooled"}
+
+	first, releaseFirst, err := pool.acquire(ch, "package challenge // first\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, releaseSecond, err := pool.acquire(ch, "package challenge // second\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Fatal("expected concurrent submissions to get workspaces of their own")
+	}
+	if data, _ := os.ReadFile(filepath.Join(first, "challenge_test.go")); string(data) != "package challenge\n" {
+		t.Fatalf("expected the hidden tests without their build line, got %q", data)
+	}
+	if err := os.WriteFile(filepath.Join(second, "challenge.test"), []byte("stale"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	releaseFirst()
+	releaseSecond()
+
+	again, release, err := pool.acquire(ch, "package challenge // third\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != second {
+		t.Fatalf("expected an idle workspace reused, got %s", again)
+	}
+	if data, _ := os.ReadFile(filepath.Join(again, "challenge.go")); string(data) != "package challenge // third\n" {
+		t.Fatalf("expected only the submission replaced, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(again, "challenge.test")); !os.IsNotExist(err) {
+		t.Fatalf("expected the previous test binary removed, got %v", err)
+	}
+	release()
+
+	// Editing the hidden tests retires the prepared workspaces.
+	writeTests("\n// edited\n")
+	edited, release, err := pool.acquire(ch, "package challenge\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	if edited == first || edited == second {
+		t.Fatal("expected a new workspace for the edited tests")
+	}
+	if data, _ := os.ReadFile(filepath.Join(edited, "challenge_test.go")); string(data) != "package challenge\n\n// edited\n" {
+		t.Fatalf("expected the edited tests, got %q", data)
+	}
+}
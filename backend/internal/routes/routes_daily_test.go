@@ -0,0 +1,208 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"avidlearner/internal/lessons"
+	"avidlearner/internal/models"
+)
+
+func dailyTestLessons() []lessons.Lesson {
+	var ls []lessons.Lesson
+	for i, name := range []string{"Caching", "Retries", "Timeouts", "Queues", "Sharding", "Indexes", "Replicas", "Backoff"} {
+		ls = append(ls, lessons.Lesson{
+			ID: strings.ToLower(name), Title: name, Category: []string{"performance", "reliability"}[i%2],
+			Text: name + " in practice.", Explain: "Why " + name + " matters.",
+		})
+	}
+	return ls
+}
+
+func TestDailyQuizIsTheSameForEveryone(t *testing.T) {
+	updateLessonMap(dailyTestLessons())
+	a, b := dailyQuiz("2026-03-14"), dailyQuiz("2026-03-14")
+	if len(a) != dailyQuizSize || !reflect.DeepEqual(a, b) {
+		t.Fatalf("expected the same %d questions twice, got\n%+v\n%+v", dailyQuizSize, a, b)
+	}
+	// Reloading the same lessons in another order changes nothing.
+	ls := dailyTestLessons()
+	for i, j := 0, len(ls)-1; i < j; i, j = i+1, j-1 {
+		ls[i], ls[j] = ls[j], ls[i]
+	}
+	updateLessonMap(ls)
+	if c := dailyQuiz("2026-03-14"); !reflect.DeepEqual(a, c) {
+		t.Fatalf("lesson order should not change the daily quiz")
+	}
+	differs := false
+	for _, day := range []string{"2026-03-15", "2026-03-16", "2026-03-17"} {
+		differs = differs || !reflect.DeepEqual(a, dailyQuiz(day))
+	}
+	if !differs {
+		t.Fatal("expected other days to get other quizzes")
+	}
+}
+
+func TestDailyStreakFold(t *testing.T) {
+	var d models.DailyProgress
+	for _, day := range []string{"2026-03-01", "2026-03-02", "2026-03-03", "2026-03-03", "2026-03-05"} {
+		applyDailyResult(&d, models.LedgerEntry{Kind: models.LedgerDailyQuiz, Ref: day, Score: 4, Total: 5})
+		if day == "2026-03-03" && d.Streak != 3 {
+			t.Fatalf("expected a 3 day streak on %s, got %d", day, d.Streak)
+		}
+	}
+	if d.Streak != 1 || d.BestStreak != 3 || d.LastDone != "2026-03-05" || len(d.Scores) != 4 {
+		t.Fatalf("a missed day should restart the streak, got %+v", d)
+	}
+	now := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+	if got := dailyStreak(d, now); got != 1 {
+		t.Fatalf("yesterday's streak still counts today, got %d", got)
+	}
+	if got := dailyStreak(d, now.AddDate(0, 0, 1)); got != 0 {
+		t.Fatalf("expected the streak broken after a missed day, got %d", got)
+	}
+	applyDailyResult(&d, models.LedgerEntry{Kind: models.LedgerDailyQuiz, Ref: "2026-05-01"})
+	if _, ok := d.Scores["2026-03-01"]; ok || len(d.Scores) != 1 {
+		t.Fatalf("expected old scores pruned, got %v", d.Scores)
+	}
+	// A daily challenge the same day as the quiz keeps the streak; one the
+	// next day extends it.
+	applyDailyResult(&d, models.LedgerEntry{Kind: models.LedgerDailyChallenge, Ref: "2026-05-01", Score: 2, Total: 3})
+	applyDailyResult(&d, models.LedgerEntry{Kind: models.LedgerDailyChallenge, Ref: "2026-05-02", Score: 3, Total: 3})
+	if d.Streak != 2 || len(d.Challenges) != 2 || len(d.Scores) != 1 {
+		t.Fatalf("expected daily challenges to count toward the streak, got %+v", d)
+	}
+}
+
+func TestDailyQuizOneScoredAttempt(t *testing.T) {
+	user, token := setupLedgerTest(t)
+	sessions = newSessionManager(SessionOptions{})
+	updateLessonMap(dailyTestLessons())
+
+	do := func(h http.HandlerFunc, method, target string, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: "sid", Value: "daily-sid"})
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		h(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: %d %s", target, rr.Code, rr.Body.String())
+		}
+		return rr
+	}
+	play := func(wrong int) models.SessionState {
+		t.Helper()
+		var st models.SessionState
+		_ = json.Unmarshal(do(handleSession, http.MethodPost, "/api/session?stage=daily", "").Body.Bytes(), &st)
+		first := st
+		for st.Stage == "quiz" {
+			p := sessions.get("daily-sid").profile
+			cur := p.CurrentQuiz[p.QuizIndex]
+			answer := cur.CorrectIndex
+			if p.QuizIndex < wrong {
+				answer = (answer + 1) % len(cur.Options)
+			}
+			_ = json.Unmarshal(do(handleSession, http.MethodPost, "/api/session?stage=answer", `{"answerIndex":`+strconv.Itoa(answer)+`}`).Body.Bytes(), &st)
+		}
+		st.DailyScored = first.DailyScored
+		return st
+	}
+
+	st := play(1)
+	if !st.DailyScored || st.DailyStreak != 1 || st.XPEarned < 20 {
+		t.Fatalf("expected the first run scored with a 1 day streak, got %+v", st)
+	}
+	today := dailyKey(time.Now())
+	if got := getUserByID(user.ID).Profile.Daily.Scores[today]; got.Score != dailyQuizSize-1 || got.Total != dailyQuizSize {
+		t.Fatalf("unexpected daily score %+v", got)
+	}
+
+	// A second run the same day is practice and leaves the score alone.
+	if st := play(0); st.DailyScored || st.DailyStreak != 0 {
+		t.Fatalf("expected a practice run, got %+v", st)
+	}
+	if got := getUserByID(user.ID).Profile.Daily.Scores[today]; got.Score != dailyQuizSize-1 {
+		t.Fatalf("practice changed the score: %+v", got)
+	}
+
+	var info map[string]any
+	_ = json.Unmarshal(do(readsContent(handleDaily), http.MethodGet, "/api/daily", "").Body.Bytes(), &info)
+	if info["completed"] != true || info["streak"] != float64(1) || info["date"] != today {
+		t.Fatalf("unexpected daily info %v", info)
+	}
+
+	var board struct {
+		Date    string       `json:"date"`
+		Entries []dailyEntry `json:"entries"`
+	}
+	_ = json.Unmarshal(do(handleDailyLeaderboard, http.MethodGet, "/api/daily/leaderboard", "").Body.Bytes(), &board)
+	if board.Date != today || len(board.Entries) != 1 || board.Entries[0].Name != user.Username || board.Entries[0].Score != dailyQuizSize-1 || board.Entries[0].Streak != 1 {
+		t.Fatalf("unexpected daily leaderboard %+v", board)
+	}
+}
+
+func TestDailyChallengeFirstSubmissionIsScored(t *testing.T) {
+	user, token := setupLedgerTest(t)
+	sessions = newSessionManager(SessionOptions{})
+	list := []models.ProChallenge{{ID: "daily-a", Title: "A"}, {ID: "daily-b", Title: "B"}}
+	SetProChallenges(list, map[string]models.ProChallenge{"daily-a": list[0], "daily-b": list[1]})
+	today := dailyKey(time.Now())
+	pick, _ := dailyChallenge(today)
+	other := list[0]
+	if other.ID == pick.ID {
+		other = list[1]
+	}
+
+	failed := models.ChallengeTestResult{Total: 4, Failures: []models.TestFailure{{Name: "TestX"}}}
+	passed := models.ChallengeTestResult{Passed: true, Total: 4}
+	if resp := applyChallengeResult("", user.ID, other, passed); resp["daily"] != nil {
+		t.Fatalf("another challenge should not count as the daily one: %v", resp)
+	}
+	resp := applyChallengeResult("", user.ID, pick, failed)
+	daily, _ := resp["daily"].(map[string]any)
+	if daily["scored"] != true || daily["streak"] != 1 {
+		t.Fatalf("expected the first daily submission scored, got %v", resp)
+	}
+	// A pass later the same day is practice and leaves the score alone.
+	resp = applyChallengeResult("", user.ID, pick, passed)
+	if daily, _ := resp["daily"].(map[string]any); daily["scored"] != false {
+		t.Fatalf("expected a practice submission, got %v", resp)
+	}
+	d := getUserByID(user.ID).Profile.Daily
+	if got := d.Challenges[today]; got.Score != 3 || got.Total != 4 || d.Streak != 1 {
+		t.Fatalf("unexpected daily challenge record %+v", d)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/daily", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	readsContent(handleDaily)(rr, req)
+	var info map[string]any
+	_ = json.Unmarshal(rr.Body.Bytes(), &info)
+	if info["challengeCompleted"] != true || info["completed"] != false || info["streak"] != float64(1) {
+		t.Fatalf("unexpected daily info %v", info)
+	}
+
+	rr = httptest.NewRecorder()
+	handleDailyLeaderboard(rr, httptest.NewRequest(http.MethodGet, "/api/daily/leaderboard?kind=challenge", nil))
+	var board struct {
+		Kind    string       `json:"kind"`
+		Entries []dailyEntry `json:"entries"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &board)
+	if board.Kind != "challenge" || len(board.Entries) != 1 || board.Entries[0].Score != 3 || board.Entries[0].Mode != "daily-challenge" {
+		t.Fatalf("unexpected daily challenge leaderboard %+v", board)
+	}
+	rr = httptest.NewRecorder()
+	handleDailyLeaderboard(rr, httptest.NewRequest(http.MethodGet, "/api/daily/leaderboard", nil))
+	if err := json.Unmarshal(rr.Body.Bytes(), &board); err != nil || len(board.Entries) != 0 {
+		t.Fatalf("the quiz board should not list challenges: %s", rr.Body.String())
+	}
+}
@@ -0,0 +1,273 @@
+package routes
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"avidlearner/internal/models"
+	"avidlearner/internal/sandbox"
+)
+
+// useGrading starts a queue grading with run in place of the sandbox until
+// ctx is done or the test ends.
+func useGrading(t *testing.T, ctx context.Context, opts GradingOptions, run challengeRunner) *gradingQueue {
+	t.Helper()
+	r, err := sandbox.New(sandbox.Permissive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	useSandbox(t, r)
+	ctx, cancel := context.WithCancel(ctx)
+	q := newGradingQueue(opts, run)
+	q.start(ctx)
+	prev := grading
+	grading = q
+	t.Cleanup(func() {
+		cancel()
+		grading = prev
+	})
+	ch := models.ProChallenge{ID: "queued", Title: "Queued", Difficulty: "easy", Reward: models.ChallengeReward{Coins: 25, XP: 50}}
+	SetProChallenges([]models.ProChallenge{ch}, map[string]models.ProChallenge{ch.ID: ch})
+	return q
+}
+
+func submitForGrading(t *testing.T, sid, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/prochallenge/submit", strings.NewReader(`{"id":"queued","code":"package challenge"}`))
+	req.AddCookie(&http.Cookie{Name: "sid", Value: sid})
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rr := httptest.NewRecorder()
+	handleProChallengeSubmit(rr, req)
+	return rr
+}
+
+func jobID(t *testing.T, rr *httptest.ResponseRecorder) string {
+	t.Helper()
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		JobID     string `json:"jobId"`
+		Status    string `json:"status"`
+		EventsURL string `json:"eventsUrl"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.JobID == "" || (resp.Status != jobQueued && resp.Status != jobRunning) || resp.EventsURL != "/api/prochallenge/jobs/"+resp.JobID+"/events" {
+		t.Fatalf("unexpected submit response %s", rr.Body.String())
+	}
+	return resp.JobID
+}
+
+func waitForJob(t *testing.T, q *gradingQueue, id string, statuses ...string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if j, _ := q.lookup(id); j != nil {
+			if v := j.view(0); strings.Contains(strings.Join(statuses, " "), v.Status) {
+				return
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s never reached %v", id, statuses)
+}
+
+func TestGradingQueueLimitsAndRewardsOnce(t *testing.T) {
+	user, token := setupLedgerTest(t)
+	sessions = newSessionManager(SessionOptions{})
+	sessions.get("grader")
+	release := make(chan struct{})
+	q := useGrading(t, context.Background(), GradingOptions{Workers: 1, QueueDepth: 1}, func(ctx context.Context, ch models.ProChallenge, source string, live io.Writer) (models.ChallengeTestResult, error) {
+		<-release
+		return models.ChallengeTestResult{Passed: true, Total: 1, Stdout: "ok"}, nil
+	})
+
+	first := jobID(t, submitForGrading(t, "grader", token))
+	waitForJob(t, q, first, jobRunning)
+	second := jobID(t, submitForGrading(t, "grader", token))
+	if j, position := q.lookup(second); j == nil || position != 1 {
+		t.Fatalf("expected the second job first in line, got position %d", position)
+	}
+
+	// One job is running and one is waiting: the queue is full.
+	rr := submitForGrading(t, "grader", token)
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 from a full queue, got %d %s", rr.Code, rr.Body.String())
+	}
+	if secs, err := strconv.Atoi(rr.Header().Get("Retry-After")); err != nil || secs < 1 {
+		t.Fatalf("expected a Retry-After in seconds, got %q", rr.Header().Get("Retry-After"))
+	}
+
+	close(release)
+	waitForJob(t, q, first, jobDone)
+	waitForJob(t, q, second, jobDone)
+
+	// Polling a finished job does not reward it again.
+	for range 3 {
+		req := httptest.NewRequest(http.MethodGet, "/api/prochallenge/jobs/"+first, nil)
+		req.AddCookie(&http.Cookie{Name: "sid", Value: "grader"})
+		rr := httptest.NewRecorder()
+		handleGradingJob(rr, req)
+		var v gradingJobView
+		_ = json.Unmarshal(rr.Body.Bytes(), &v)
+		if rr.Code != http.StatusOK || v.Status != jobDone || v.Result["passed"] != true || v.Result["coinsEarned"] != float64(25) {
+			t.Fatalf("unexpected job status %d %s", rr.Code, rr.Body.String())
+		}
+	}
+	if got := getUserByID(user.ID).Profile.Coins; got != 50 {
+		t.Fatalf("expected two rewards of 25 coins, got %d", got)
+	}
+	if p, _ := sessions.lookup("grader"); p.Coins != 50 {
+		t.Fatalf("expected the session to match the account, got %d coins", p.Coins)
+	}
+	entries, err := dataStore.Ledger(user.ID, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	submits := 0
+	for _, e := range entries {
+		if e.Kind == models.LedgerChallengeSubmit {
+			submits++
+		}
+	}
+	if submits != 2 {
+		t.Fatalf("expected one ledger entry per job, got %d", submits)
+	}
+}
+
+func TestGradingJobEvents(t *testing.T) {
+	sessions = newSessionManager(SessionOptions{})
+	sessions.get("watcher")
+	release := make(chan struct{})
+	q := useGrading(t, context.Background(), GradingOptions{Workers: 1}, func(ctx context.Context, ch models.ProChallenge, source string, live io.Writer) (models.ChallengeTestResult, error) {
+		fmt.Fprintln(live, "=== RUN   TestLive")
+		<-release
+		fmt.Fprintln(live, "--- FAIL: TestLive (0.00s)")
+		return models.ChallengeTestResult{Total: 1, Failures: []models.TestFailure{{Name: "TestLive"}}}, nil
+	})
+	id := jobID(t, submitForGrading(t, "watcher", ""))
+	waitForJob(t, q, id, jobRunning)
+
+	srv := httptest.NewServer(http.HandlerFunc(handleGradingJob))
+	defer srv.Close()
+	get := func(sid string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/api/prochallenge/jobs/"+id+"/events", nil)
+		req.AddCookie(&http.Cookie{Name: "sid", Value: sid})
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if resp := get("someone-else"); resp.StatusCode != http.StatusNotFound {
+		resp.Body.Close()
+		t.Fatalf("expected another session to get 404, got %d", resp.StatusCode)
+	}
+
+	resp := get("watcher")
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %q", ct)
+	}
+	var events []string
+	var output strings.Builder
+	var done gradingJobView
+	sc := bufio.NewScanner(resp.Body)
+	event := ""
+	for sc.Scan() {
+		line := sc.Text()
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			event = name
+			continue
+		}
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		events = append(events, event)
+		switch event {
+		case "output":
+			var out struct{ Text string }
+			_ = json.Unmarshal([]byte(data), &out)
+			if output.Len() == 0 && strings.Contains(out.Text, "=== RUN   TestLive") {
+				close(release) // the output arrived while the tests were still running
+			}
+			output.WriteString(out.Text)
+		case "done":
+			_ = json.Unmarshal([]byte(data), &done)
+		}
+	}
+	if len(events) < 3 || events[0] != "status" || events[1] != "output" || events[len(events)-1] != "done" {
+		t.Fatalf("unexpected events %v", events)
+	}
+	if !strings.Contains(output.String(), "--- FAIL: TestLive") {
+		t.Fatalf("expected the whole test output streamed, got %q", output.String())
+	}
+	if done.Status != jobDone || done.Result["passed"] != false || done.Output != output.String() {
+		t.Fatalf("unexpected final job %+v", done)
+	}
+}
+
+func TestGradingShutdownFailsQueuedJobs(t *testing.T) {
+	sessions = newSessionManager(SessionOptions{})
+	sessions.get("late")
+	ctx, cancel := context.WithCancel(context.Background())
+	q := useGrading(t, ctx, GradingOptions{Workers: 1, QueueDepth: 2}, func(ctx context.Context, ch models.ProChallenge, source string, live io.Writer) (models.ChallengeTestResult, error) {
+		<-ctx.Done()
+		return models.ChallengeTestResult{}, ctx.Err()
+	})
+
+	running := jobID(t, submitForGrading(t, "late", ""))
+	waitForJob(t, q, running, jobRunning)
+	queued := jobID(t, submitForGrading(t, "late", ""))
+
+	srv := httptest.NewServer(http.HandlerFunc(handleGradingJob))
+	defer srv.Close()
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/api/prochallenge/jobs/"+queued+"/events", nil)
+	req.AddCookie(&http.Cookie{Name: "sid", Value: "late"})
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	cancel()
+	var done gradingJobView
+	sc := bufio.NewScanner(resp.Body)
+	event := ""
+	for sc.Scan() {
+		if name, ok := strings.CutPrefix(sc.Text(), "event: "); ok {
+			event = name
+		} else if data, ok := strings.CutPrefix(sc.Text(), "data: "); ok && event == "done" {
+			_ = json.Unmarshal([]byte(data), &done)
+		}
+	}
+	if done.Status != jobError || done.Error != errGradingStopped {
+		t.Fatalf("expected the queued job's stream to end with it failed, got %+v", done)
+	}
+	waitForJob(t, q, running, jobError)
+
+	// Submissions after shutdown are failed rather than left queued.
+	rr := submitForGrading(t, "late", "")
+	var late struct {
+		JobID  string `json:"jobId"`
+		Status string `json:"status"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &late)
+	if rr.Code != http.StatusAccepted || late.Status != jobError {
+		t.Fatalf("expected a late submission to be failed, got %d %s", rr.Code, rr.Body.String())
+	}
+}
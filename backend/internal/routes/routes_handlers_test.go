@@ -2,12 +2,15 @@ package routes
 
 import (
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
+	"avidlearner/internal/lessons"
 	"avidlearner/internal/models"
 )
 
@@ -99,3 +102,286 @@ func TestHandleRandom(t *testing.T) {
 		t.Fatalf("expected lesson, got empty")
 	}
 }
+
+func TestHandleLessonByID(t *testing.T) {
+	updateLessonMap([]lessons.Lesson{
+		{Title: "Circuit Breaker", Category: "resilience", Source: "local"},
+		{Title: "Circuit Breaker", Category: "resilience", Source: "devto", ID: "devto-abc"},
+	})
+	id := lessons.NewID("local", "Circuit Breaker")
+
+	rr := httptest.NewRecorder()
+	handleLessonByID(rr, httptest.NewRequest("GET", "/api/lessons/"+id, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rr.Code)
+	}
+	var got models.Lesson
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal got: %v", err)
+	}
+	if got.ID != id || got.Source != "local" {
+		t.Fatalf("expected the local lesson, got %+v", got)
+	}
+
+	rr = httptest.NewRecorder()
+	handleLessonByID(rr, httptest.NewRequest("GET", "/api/lessons/missing", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 got %d", rr.Code)
+	}
+}
+
+func TestMigrateLessonRefs(t *testing.T) {
+	updateLessonMap([]lessons.Lesson{
+		{Title: "Circuit Breaker", Category: "resilience", Source: "local"},
+		{Title: "Bulkheads", Category: "resilience", Source: "github"},
+	})
+	prev := dataStore
+	dataStore = nil
+	defer func() { dataStore = prev }()
+
+	usersByID = map[string]*models.User{"u1": {ID: "u1", Profile: models.UserProfile{
+		LessonsSeen: []string{"Circuit Breaker", "Retired Lesson"},
+		SavedLessons: []models.SavedLesson{
+			{Title: "Bulkheads", Category: "resilience", Source: "github"},
+			{Title: "Some Article", Category: "architecture", Source: "devto"},
+		},
+	}}}
+
+	migrated, unresolved := MigrateLessonRefs()
+	if migrated != 1 || unresolved != 1 {
+		t.Fatalf("expected 1 migrated user and 1 unresolved title, got %d/%d", migrated, unresolved)
+	}
+	p := usersByID["u1"].Profile
+	if p.LessonsSeen[0] != lessons.NewID("local", "Circuit Breaker") || p.LessonsSeen[1] != "Retired Lesson" {
+		t.Errorf("unexpected lessonsSeen %v", p.LessonsSeen)
+	}
+	if p.SavedLessons[0].ID != lessons.NewID("github", "Bulkheads") || p.SavedLessons[1].ID != lessons.NewID("devto", "Some Article") {
+		t.Errorf("unexpected saved lesson IDs %+v", p.SavedLessons)
+	}
+
+	if migrated, _ := MigrateLessonRefs(); migrated != 0 {
+		t.Errorf("migration must only run once, migrated %d again", migrated)
+	}
+}
+
+func TestHandleLessonSearch(t *testing.T) {
+	updateLessonMap([]lessons.Lesson{
+		{Title: "Idempotency Keys", Category: "api-design", Text: "Apply retried requests once."},
+		{Title: "Circuit Breaker", Category: "resilience", Text: "Stop calling failing services."},
+	})
+
+	rr := httptest.NewRecorder()
+	handleLessonSearch(rr, httptest.NewRequest("GET", "/api/lessons/search?q=idempotency", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rr.Code)
+	}
+	var resp struct {
+		Total   int `json:"total"`
+		Results []struct {
+			Lesson models.Lesson `json:"lesson"`
+		} `json:"results"`
+		Facets map[string]map[string]int `json:"facets"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal resp: %v", err)
+	}
+	if resp.Total != 1 || resp.Results[0].Lesson.Title != "Idempotency Keys" {
+		t.Fatalf("unexpected results %+v", resp)
+	}
+	if resp.Facets["category"]["api-design"] != 1 {
+		t.Errorf("unexpected facets %v", resp.Facets)
+	}
+
+	rr = httptest.NewRecorder()
+	handleLessonSearch(rr, httptest.NewRequest("GET", "/api/lessons/search", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for missing q, got %d", rr.Code)
+	}
+}
+
+func TestHandleLessonsPagingAndETag(t *testing.T) {
+	updateLessonMap([]lessons.Lesson{
+		{Title: "A", Category: "c1", Source: "local"},
+		{Title: "B", Category: "c1", Source: "devto"},
+		{Title: "C", Category: "c1"},
+		{Title: "D", Category: "c2", Source: "local"},
+	})
+
+	get := func(url, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", url, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rr := httptest.NewRecorder()
+		handleLessons(rr, req)
+		return rr
+	}
+	type page struct {
+		Lessons    []models.Lesson `json:"lessons"`
+		NextCursor string          `json:"nextCursor"`
+	}
+	decode := func(rr *httptest.ResponseRecorder) page {
+		var p page
+		if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		return p
+	}
+
+	var titles []string
+	cursor := ""
+	for i := 0; i < 3; i++ {
+		rr := get("/api/lessons?limit=2&category=c1&cursor="+cursor, "")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200 got %d: %s", rr.Code, rr.Body.String())
+		}
+		p := decode(rr)
+		for _, l := range p.Lessons {
+			titles = append(titles, l.Title)
+		}
+		if cursor = p.NextCursor; cursor == "" {
+			break
+		}
+	}
+	if len(titles) != 3 || titles[0] != "A" || titles[2] != "C" {
+		t.Fatalf("expected c1 lessons A, B, C across pages, got %v", titles)
+	}
+
+	if p := decode(get("/api/lessons?source=devto", "")); len(p.Lessons) != 1 || p.Lessons[0].Title != "B" {
+		t.Fatalf("expected only the devto lesson, got %+v", p.Lessons)
+	}
+	if p := decode(get("/api/lessons?source=local", "")); len(p.Lessons) != 3 {
+		t.Fatalf("expected the local lessons, including the one without a source, got %+v", p.Lessons)
+	}
+
+	rr := get("/api/lessons?view=categories", "")
+	var cats struct {
+		Categories []string       `json:"categories"`
+		Counts     map[string]int `json:"counts"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &cats); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(cats.Categories) != 2 || cats.Counts["c1"] != 3 {
+		t.Fatalf("unexpected categories view %+v", cats)
+	}
+
+	etag := rr.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected an ETag")
+	}
+	if rr := get("/api/lessons?view=categories", etag); rr.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for matching ETag, got %d", rr.Code)
+	}
+	updateLessonMap([]lessons.Lesson{{Title: "E", Category: "c3"}})
+	if rr := get("/api/lessons?view=categories", etag); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 after lessons changed, got %d", rr.Code)
+	}
+
+	if rr := get("/api/lessons?seen=unseen", ""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("seen filter should require auth, got %d", rr.Code)
+	}
+}
+
+func TestHandleAdminContent(t *testing.T) {
+	user, token := setupLedgerTest(t)
+	t.Cleanup(func() { SetAdmins(nil) })
+	ReportContentLoaded("lessons", "lessons.json", "abc123", 3)
+	ReportContentError("lessons", "lessons.json", errors.New("unexpected end of JSON input"))
+
+	get := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/content", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handleAdminContent(rr, req)
+		return rr
+	}
+
+	if rr := get(); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a non-admin, got %d", rr.Code)
+	}
+
+	SetAdmins([]string{strings.ToUpper(user.Username)})
+	rr := get()
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Files []ContentStatus `json:"files"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	var found bool
+	for _, st := range resp.Files {
+		if st.Name == "lessons" {
+			found = true
+			if st.Version != "abc123" || st.Items != 3 || st.Error == "" {
+				t.Fatalf("expected live version with the reload error, got %+v", st)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("lessons status missing: %+v", resp.Files)
+	}
+}
+
+func TestHandleCategoriesAppliesTaxonomy(t *testing.T) {
+	taxonomy, err := lessons.NewTaxonomy([]lessons.Category{
+		{Name: "architecture", Label: "Architecture", Icon: "🏛️"},
+		{Name: "api-design", Label: "API Design", Parent: "architecture", Aliases: []string{"apis"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetTaxonomy(taxonomy)
+	t.Cleanup(func() { SetTaxonomy(nil) })
+	updateLessonMap([]lessons.Lesson{
+		{Title: "Idempotency Keys", Category: "api-design"},
+		{Title: "Pagination", Category: "apis", Source: "devto"},
+		{Title: "Layers", Category: "architecture"},
+		{Title: "Misc", Category: "odd"},
+	})
+
+	if len(categories) != 3 || len(lessonsByCat["api-design"]) != 2 || lessonsByCat["apis"] != nil {
+		t.Fatalf("expected aliases to be re-filed, got %v", categories)
+	}
+
+	rr := httptest.NewRecorder()
+	handleCategories(rr, httptest.NewRequest(http.MethodGet, "/api/categories", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rr.Code)
+	}
+	var resp struct {
+		Categories []lessons.CategoryNode `json:"categories"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(resp.Categories) != 2 {
+		t.Fatalf("expected architecture and the undefined category at the top, got %+v", resp.Categories)
+	}
+	arch := resp.Categories[0]
+	if arch.Name != "architecture" || arch.Count != 1 || arch.Total != 3 || len(arch.Children) != 1 {
+		t.Fatalf("unexpected architecture node %+v", arch)
+	}
+	if child := arch.Children[0]; child.Label != "API Design" || child.Count != 2 {
+		t.Fatalf("unexpected child node %+v", child)
+	}
+	if odd := resp.Categories[1]; odd.Name != "odd" || odd.Label != "odd" || odd.Count != 1 {
+		t.Fatalf("unexpected undefined node %+v", odd)
+	}
+
+	// Category filters accept aliases.
+	rr = httptest.NewRecorder()
+	handleLessons(rr, httptest.NewRequest(http.MethodGet, "/api/lessons?category=APIs", nil))
+	var page struct {
+		Lessons []models.Lesson `json:"lessons"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(page.Lessons) != 2 {
+		t.Fatalf("expected both api-design lessons for the alias, got %+v", page.Lessons)
+	}
+}
@@ -273,9 +273,9 @@ func TestUniqueStrings(t *testing.T) {
 func TestPickLessonForProfile(t *testing.T) {
 	lessonsByCat = map[string][]models.Lesson{
 		"Go": {
-			{Title: "Lesson 1", Category: "Go", Text: "text"},
-			{Title: "Lesson 2", Category: "Go", Text: "text"},
-			{Title: "Lesson 3", Category: "Go", Text: "text"},
+			{ID: "go-1", Title: "Lesson 1", Category: "Go", Text: "text"},
+			{ID: "go-2", Title: "Lesson 2", Category: "Go", Text: "text"},
+			{ID: "go-3", Title: "Lesson 3", Category: "Go", Text: "text"},
 		},
 	}
 
@@ -291,14 +291,14 @@ func TestPickLessonForProfile(t *testing.T) {
 			t.Errorf("expected 1 recent lesson, got %d", len(p.RecentLessons))
 		}
 
-		if p.RecentLessons[0] != lesson.Title {
-			t.Errorf("expected recent lesson to be '%s', got '%s'", lesson.Title, p.RecentLessons[0])
+		if p.RecentLessons[0] != lesson.ID {
+			t.Errorf("expected recent lesson to be '%s', got '%s'", lesson.ID, p.RecentLessons[0])
 		}
 	})
 
 	t.Run("avoids recently seen lessons", func(t *testing.T) {
 		p := newProfile()
-		p.RecentLessons = []string{"Lesson 1", "Lesson 2"}
+		p.RecentLessons = []string{"go-1", "go-2"}
 
 		seen := make(map[string]int)
 		for i := 0; i < 10; i++ {
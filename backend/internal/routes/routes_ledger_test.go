@@ -0,0 +1,303 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"avidlearner/internal/lessons"
+	"avidlearner/internal/models"
+	"avidlearner/internal/progress"
+	"avidlearner/internal/store"
+)
+
+func setupLedgerTest(t *testing.T) (*models.User, string) {
+	t.Helper()
+	if err := SetAuthConfig("test-secret-0123456789", time.Hour); err != nil {
+		t.Fatalf("SetAuthConfig: %v", err)
+	}
+	dir := t.TempDir()
+	s := store.NewJSONStore(filepath.Join(dir, "users.json"), filepath.Join(dir, "leaderboard.json"), "")
+	prev := dataStore
+	dataStore = s
+	t.Cleanup(func() {
+		s.Close()
+		dataStore = prev
+	})
+	usersByID = map[string]*models.User{}
+	usersByName = map[string]*models.User{}
+
+	user := &models.User{ID: "u1", Username: "ledger", CreatedAt: time.Now(), LeaderboardOptIn: true}
+	ensureProfileDefaults(&user.Profile)
+	if err := addUser(user); err != nil {
+		t.Fatalf("addUser: %v", err)
+	}
+	token, err := authManager.IssueToken(user.ID, user.Username)
+	if err != nil {
+		t.Fatalf("IssueToken: %v", err)
+	}
+	return user, token
+}
+
+func TestLedgerDerivesProfileTotals(t *testing.T) {
+	user, _ := setupLedgerTest(t)
+
+	updateUserByID(user.ID, func(u *models.User) {
+		recordLedgerLocked(u, models.LedgerEntry{Kind: models.LedgerQuizAnswer, Coins: 10, Correct: true})
+		recordLedgerLocked(u, models.LedgerEntry{Kind: models.LedgerChallengeSubmit, Coins: 50, XP: 30, CodingScore: 30, Correct: true})
+		recordLedgerLocked(u, models.LedgerEntry{Kind: models.LedgerHintPurchase, Coins: -2})
+		recordLedgerLocked(u, models.LedgerEntry{Kind: models.LedgerTypingSession, TypingScore: 42})
+	})
+
+	got := getUserByID(user.ID).Profile
+	if got.Coins != 58 || got.XP != 30 || got.CodingScore != 30 || got.TypingBest != 42 || got.QuizStreak != 1 {
+		t.Fatalf("unexpected totals: %+v", got)
+	}
+
+	// Tamper with the stored profile; reloading must re-derive from the ledger.
+	tampered := *getUserByID(user.ID)
+	tampered.Profile.Coins = 999999
+	tampered.Profile.Stats.QuizCorrect = 500
+	if err := dataStore.PutUser(tampered); err != nil {
+		t.Fatalf("PutUser: %v", err)
+	}
+	if err := LoadUsers(); err != nil {
+		t.Fatalf("LoadUsers: %v", err)
+	}
+	got = getUserByID(user.ID).Profile
+	if got.Coins != 58 || got.Stats.QuizCorrect != 1 || got.Stats.CodingPassed != 1 || got.Stats.TypingSessions != 1 {
+		t.Fatalf("expected totals re-derived from ledger, got %+v", got)
+	}
+}
+
+func TestLoadUsersRecordsOpeningBalance(t *testing.T) {
+	user, _ := setupLedgerTest(t)
+
+	legacy := *user
+	legacy.Profile.Coins = 120
+	legacy.Profile.XP = 80
+	legacy.Profile.Stats.QuizzesTaken = 9
+	if err := dataStore.PutUser(legacy); err != nil {
+		t.Fatalf("PutUser: %v", err)
+	}
+	for range 2 {
+		if err := LoadUsers(); err != nil {
+			t.Fatalf("LoadUsers: %v", err)
+		}
+	}
+
+	entries, err := dataStore.Ledger(user.ID, 0, 0)
+	if err != nil {
+		t.Fatalf("Ledger: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Kind != models.LedgerOpeningBalance || entries[0].Coins != 120 {
+		t.Fatalf("expected a single opening balance, got %+v", entries)
+	}
+	got := getUserByID(user.ID).Profile
+	if got.Coins != 120 || got.XP != 80 || got.Stats.QuizzesTaken != 9 {
+		t.Fatalf("opening balance should preserve totals, got %+v", got)
+	}
+}
+
+func TestProfilePatchRejectsServerManagedFields(t *testing.T) {
+	user, token := setupLedgerTest(t)
+
+	patch := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/api/profile", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handleProfile(rr, req)
+		return rr
+	}
+
+	for _, body := range []string{`{"coins":1000}`, `{"xp":5}`, `{"stats":{"quizCorrect":3}}`, `{"typingBest":90}`} {
+		if rr := patch(body); rr.Code != http.StatusBadRequest {
+			t.Errorf("PATCH %s: expected 400, got %d", body, rr.Code)
+		}
+	}
+	if coins := getUserByID(user.ID).Profile.Coins; coins != 0 {
+		t.Fatalf("coins must not change through PATCH, got %d", coins)
+	}
+
+	rr := patch(`{"typingStreak":4,"leaderboardOptIn":false}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	updated := getUserByID(user.ID)
+	if updated.Profile.TypingStreak != 4 || updated.LeaderboardOptIn {
+		t.Fatalf("user-owned fields should update, got %+v", updated)
+	}
+}
+
+func TestHandleLedgerPages(t *testing.T) {
+	user, token := setupLedgerTest(t)
+	updateUserByID(user.ID, func(u *models.User) {
+		for range 3 {
+			recordLedgerLocked(u, models.LedgerEntry{Kind: models.LedgerQuizAnswer, Coins: 10, Correct: true})
+		}
+	})
+
+	get := func(query string) (entries []models.LedgerEntry, next int64) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/profile/ledger"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handleLedger(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp struct {
+			Entries    []models.LedgerEntry `json:"entries"`
+			NextBefore int64                `json:"nextBefore"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp.Entries, resp.NextBefore
+	}
+
+	first, next := get("?limit=2")
+	if len(first) != 2 || next == 0 {
+		t.Fatalf("expected a full first page with a cursor, got %d entries next=%d", len(first), next)
+	}
+	rest, next := get("?limit=2&before=" + strconv.FormatInt(next, 10))
+	if len(rest) != 1 || next != 0 {
+		t.Fatalf("expected the last entry and no cursor, got %d entries next=%d", len(rest), next)
+	}
+}
+
+func TestActivityXPAndLevelUp(t *testing.T) {
+	user, token := setupLedgerTest(t)
+	sessions = newSessionManager(SessionOptions{})
+	updateLessonMap([]lessons.Lesson{{ID: "caching", Title: "Caching", Category: "performance", Text: strings.Repeat("Cache hot reads. ", 20)}})
+	updateUserByID(user.ID, func(u *models.User) {
+		recordLedgerLocked(u, models.LedgerEntry{Kind: models.LedgerOpeningBalance, XP: 95})
+	})
+
+	post := func(h http.HandlerFunc, target, body string) map[string]any {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: "sid", Value: "xp-sid"})
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		h(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: %d %s", target, rr.Code, rr.Body.String())
+		}
+		var resp map[string]any
+		_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+		return resp
+	}
+
+	// Reading a lesson earns XP once.
+	resp := post(handleSession, "/api/session?stage=add", `{"id":"caching"}`)
+	if resp["xpEarned"] != float64(progress.Default.LessonRead) || resp["levelUp"] != nil {
+		t.Fatalf("expected lesson XP without a level up, got %v", resp)
+	}
+	if resp := post(handleSession, "/api/session?stage=add", `{"id":"caching"}`); resp["xpEarned"] != float64(0) {
+		t.Fatalf("re-adding a lesson should earn nothing, got %v", resp)
+	}
+
+	// A typing session crosses into level 2: the 340 characters of the text
+	// in a minute allow the 60 WPM claimed.
+	post(handleTypingStart, "/api/typing/start", `{"lessonId":"caching"}`)
+	sessions.get("xp-sid").profile.TypingStarted = time.Now().Add(-time.Minute)
+	resp = post(handleTypingScore, "/api/typing/score", `{"score":60,"chars":340}`)
+	up, _ := resp["levelUp"].(map[string]any)
+	if resp["xpEarned"] != float64(progress.Default.Typing(60)) || up == nil || up["to"] != float64(2) {
+		t.Fatalf("expected a level up to 2, got %v", resp)
+	}
+	got := getUserByID(user.ID).Profile
+	if got.XP != 95+progress.Default.LessonRead+progress.Default.Typing(60) || got.Level.Level != 2 || got.Level.Title != "Novice" {
+		t.Fatalf("unexpected level %+v at %d XP", got.Level, got.XP)
+	}
+
+	// The leaderboard shows the level the score was submitted at.
+	resp = post(handleLeaderboardSubmit, "/api/leaderboard/submit", `{"score":60,"mode":"typing"}`)
+	if resp["success"] != true {
+		t.Fatalf("submit failed: %v", resp)
+	}
+	if e := leaderboard[len(leaderboard)-1]; e.Level != 2 || e.Title != "Novice" {
+		t.Fatalf("expected the entry to carry level 2, got %+v", e)
+	}
+}
+
+func TestTypingScoreIsJudgedByServerClock(t *testing.T) {
+	user, token := setupLedgerTest(t)
+	sessions = newSessionManager(SessionOptions{})
+	updateLessonMap([]lessons.Lesson{{ID: "short", Title: "Short", Category: "performance", Text: strings.Repeat("x", 100)}})
+
+	post := func(h http.HandlerFunc, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: "sid", Value: "typist"})
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		h(rr, req)
+		return rr
+	}
+
+	// Without a started session nothing is credited.
+	if rr := post(handleTypingScore, `{"score":150,"chars":100}`); rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409 without a typing session, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := post(handleTypingStart, `{"lessonId":"missing"}`); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown lesson, got %d", rr.Code)
+	}
+
+	score := func(body string, started time.Duration) map[string]any {
+		t.Helper()
+		if rr := post(handleTypingStart, `{"lessonId":"short"}`); rr.Code != http.StatusOK {
+			t.Fatalf("start: %d %s", rr.Code, rr.Body.String())
+		}
+		sessions.get("typist").profile.TypingStarted = time.Now().Add(-started)
+		rr := post(handleTypingScore, body)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("score: %d %s", rr.Code, rr.Body.String())
+		}
+		var resp map[string]any
+		_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+		return resp
+	}
+
+	// No characters, too few of them, or a session shorter than
+	// typingMinSession earn nothing.
+	for _, tc := range []struct {
+		body    string
+		started time.Duration
+	}{
+		{`{"score":0,"chars":0}`, time.Minute},
+		{`{"score":60,"chars":10}`, time.Minute},
+		{`{"score":500,"chars":100}`, 0},
+	} {
+		if resp := score(tc.body, tc.started); resp["credited"] != float64(0) || resp["xpEarned"] != float64(0) {
+			t.Fatalf("%s after %v: expected nothing credited, got %v", tc.body, tc.started, resp)
+		}
+	}
+
+	// More characters than the text holds do not count: 100 characters in
+	// just over 30s is just under 40 WPM.
+	if resp := score(`{"score":500,"chars":5000}`, 30*time.Second); resp["credited"] != float64(39) || resp["xpEarned"] != float64(progress.Default.Typing(39)) {
+		t.Fatalf("expected 39 WPM credited, got %v", resp)
+	}
+
+	// The session is scored once.
+	if rr := post(handleTypingScore, `{"score":39,"chars":100}`); rr.Code != http.StatusConflict {
+		t.Fatalf("expected a second score to be refused, got %d", rr.Code)
+	}
+	if got := getUserByID(user.ID).Profile; got.XP != progress.Default.Typing(39) || got.TypingBest != 39 {
+		t.Fatalf("expected one credited session, got %d XP, best %d", got.XP, got.TypingBest)
+	}
+	entries, err := dataStore.Ledger(user.ID, 0, 0)
+	if err != nil {
+		t.Fatalf("Ledger: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected one typing_session entry, got %+v", entries)
+	}
+}
@@ -0,0 +1,165 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"avidlearner/internal/lessons"
+	"avidlearner/internal/models"
+)
+
+func TestMergeSessionProgress(t *testing.T) {
+	session := newProfile()
+	session.Coins = 30
+	session.XP = 20
+	session.CodingScore = 50
+	session.TypingScore = 70
+	session.Streak = 2
+	session.LessonsSeen = []string{"Circuit Breaker", "Idempotency Keys"}
+	session.HintIdx["fan-in-fan-out"] = 2
+	session.HintIdx["ctx-cancel-http"] = 1
+
+	user := &models.User{ID: "u1", Profile: models.UserProfile{
+		Coins:       10,
+		XP:          5,
+		CodingScore: 80,
+		TypingBest:  40,
+		QuizStreak:  5,
+		LessonsSeen: []string{"Circuit Breaker"},
+		HintIdx:     map[string]int{"ctx-cancel-http": 3},
+	}}
+
+	if !mergeSessionProgress("sid-1", session, user) {
+		t.Fatal("expected merge to happen")
+	}
+
+	got := user.Profile
+	if got.Coins != 40 || got.XP != 25 {
+		t.Errorf("coins/xp should be summed, got coins=%d xp=%d", got.Coins, got.XP)
+	}
+	if got.CodingScore != 80 || got.TypingBest != 70 || got.QuizStreak != 5 {
+		t.Errorf("best scores should be kept, got coding=%d typing=%d streak=%d", got.CodingScore, got.TypingBest, got.QuizStreak)
+	}
+	if len(got.LessonsSeen) != 2 || got.Stats.LessonsRead != 2 {
+		t.Errorf("lessons should be unioned, got %v", got.LessonsSeen)
+	}
+	if got.HintIdx["fan-in-fan-out"] != 2 || got.HintIdx["ctx-cancel-http"] != 3 {
+		t.Errorf("hint unlocks should keep the furthest index, got %v", got.HintIdx)
+	}
+	if session.MergedInto != "u1" || len(got.MergedSessions) != 1 {
+		t.Errorf("merge should be recorded on both sides")
+	}
+
+	if mergeSessionProgress("sid-1", session, user) {
+		t.Error("same session must not merge twice")
+	}
+	session.MergedInto = ""
+	if mergeSessionProgress("sid-1", session, user) {
+		t.Error("recorded fingerprint must block replay even if the session stamp is lost")
+	}
+	other := &models.User{ID: "u2"}
+	session.MergedInto = "u1"
+	if mergeSessionProgress("sid-1", session, other) {
+		t.Error("a merged session must not credit a second account")
+	}
+}
+
+func TestSignupAndLoginMergeSessionOnce(t *testing.T) {
+	if err := SetAuthConfig("test-secret-0123456789", time.Hour); err != nil {
+		t.Fatalf("SetAuthConfig: %v", err)
+	}
+	usersByID = map[string]*models.User{}
+	usersByName = map[string]*models.User{}
+	sessions = newSessionManager(SessionOptions{})
+
+	session := sessions.get("visitor").profile
+	session.Coins = 25
+	session.HintIdx["fan-in-fan-out"] = 1
+
+	post := func(handler http.HandlerFunc, body string) map[string]any {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: "sid", Value: "visitor"})
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp map[string]any
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp
+	}
+
+	resp := post(handleSignup, `{"username":"newbie","password":"password123","leaderboardOptIn":true}`)
+	if resp["sessionMerged"] != true {
+		t.Fatalf("expected signup to merge session, got %v", resp["sessionMerged"])
+	}
+	user := getUserByName("newbie")
+	if user.Profile.Coins != 25 || user.Profile.HintIdx["fan-in-fan-out"] != 1 {
+		t.Fatalf("expected anonymous progress on the account, got %+v", user.Profile)
+	}
+
+	resp = post(handleLogin, `{"username":"newbie","password":"password123"}`)
+	if resp["sessionMerged"] != false {
+		t.Fatal("login with an already merged session must not merge again")
+	}
+	if coins := getUserByName("newbie").Profile.Coins; coins != 25 {
+		t.Fatalf("expected coins to stay at 25 after re-login, got %d", coins)
+	}
+}
+
+func TestAlignedSessionDoesNotCreditAnotherAccount(t *testing.T) {
+	owner, token := setupLedgerTest(t)
+	sessions = newSessionManager(SessionOptions{})
+	updateLessonMap([]lessons.Lesson{{ID: "caching", Title: "Caching", Category: "performance"}})
+	updateUserByID(owner.ID, func(u *models.User) {
+		recordLedgerLocked(u, models.LedgerEntry{Kind: models.LedgerOpeningBalance, Coins: 500, XP: 300})
+	})
+
+	post := func(h http.HandlerFunc, target, sid, token, body string) map[string]any {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: "sid", Value: sid})
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		h(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: %d %s", target, rr.Code, rr.Body.String())
+		}
+		var resp map[string]any
+		_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+		return resp
+	}
+
+	// An authenticated request on a fresh cookie copies the owner's totals
+	// into the session; signing up with that cookie must not take them along.
+	post(handleSession, "/api/session?stage=add", "shared", token, `{"id":"caching"}`)
+	resp := post(handleSignup, "/api/auth/signup", "shared", "", `{"username":"second","password":"password123","leaderboardOptIn":true}`)
+	if resp["sessionMerged"] != false {
+		t.Fatalf("an aligned session must not be merged, got %v", resp["sessionMerged"])
+	}
+	second := getUserByName("second")
+	if second.Profile.Coins != 0 || second.Profile.XP != 0 || len(second.Profile.LessonsSeen) != 0 {
+		t.Fatalf("expected the new account to start empty, got %+v", second.Profile)
+	}
+
+	// Logging in on a cookie with no progress records nothing.
+	post(handleLogin, "/api/auth/login", "empty", "", `{"username":"second","password":"password123"}`)
+	entries, err := dataStore.Ledger(second.ID, 0, 0)
+	if err != nil {
+		t.Fatalf("Ledger: %v", err)
+	}
+	for _, e := range entries {
+		if e.Kind == models.LedgerSessionMerge {
+			t.Fatalf("an empty session must not write a session_merge entry, got %+v", e)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"avidlearner/internal/lessons"
+	"avidlearner/internal/models"
+)
+
+func TestGradeQuizAnswerByType(t *testing.T) {
+	l := models.Lesson{ID: "ctx", Title: "Context"}
+	opts := func(texts ...string) []models.QuestionOption {
+		out := make([]models.QuestionOption, len(texts))
+		for i, text := range texts {
+			out[i] = models.QuestionOption{Text: strings.TrimPrefix(text, "*"), Correct: strings.HasPrefix(text, "*"), Explain: "why " + text}
+		}
+		return out
+	}
+	indexOf := func(q models.QuizQuestion, text string) int {
+		return slices.Index(q.Options, text)
+	}
+
+	mc := authoredQuiz(l, models.Question{Prompt: "Which cancels?", Options: opts("*cancel()", "close()", "panic()")})
+	if ok, fb := gradeQuizAnswer(mc, quizAnswer{AnswerIndex: indexOf(mc, "cancel()")}); !ok || fb.Options[mc.CorrectIndex].Explain != "why *cancel()" {
+		t.Fatalf("multiple choice: expected correct with explanation, got %v %+v", ok, fb)
+	}
+	if ok, fb := gradeQuizAnswer(mc, quizAnswer{AnswerIndex: indexOf(mc, "close()")}); ok || !fb.Options[indexOf(mc, "close()")].Chosen {
+		t.Fatalf("multiple choice: expected wrong answer marked chosen, got %v %+v", ok, fb)
+	}
+
+	tf := authoredQuiz(l, models.Question{Type: "true-false", Prompt: "Contexts are mutable.", Options: opts("True", "*False")})
+	if tf.Options[0] != "True" || tf.CorrectIndex != 1 {
+		t.Fatalf("true/false should keep its order, got %+v", tf)
+	}
+	if ok, _ := gradeQuizAnswer(tf, quizAnswer{AnswerIndex: 1}); !ok {
+		t.Fatal("true/false: expected correct")
+	}
+
+	multi := authoredQuiz(l, models.Question{Type: "multi-select", Prompt: "Which carry deadlines?", Options: opts("*WithTimeout", "*WithDeadline", "WithValue")})
+	right := []int{indexOf(multi, "WithTimeout"), indexOf(multi, "WithDeadline")}
+	if ok, _ := gradeQuizAnswer(multi, quizAnswer{AnswerIndexes: right}); !ok {
+		t.Fatalf("multi-select: expected correct for %v", right)
+	}
+	if ok, _ := gradeQuizAnswer(multi, quizAnswer{AnswerIndexes: right[:1]}); ok {
+		t.Fatal("multi-select: a partial answer should be wrong")
+	}
+
+	ord := authoredQuiz(l, models.Question{Type: "ordering", Prompt: "Order the calls.", Options: opts("WithCancel", "defer cancel()", "<-ctx.Done()")})
+	order := []int{indexOf(ord, "WithCancel"), indexOf(ord, "defer cancel()"), indexOf(ord, "<-ctx.Done()")}
+	if ok, _ := gradeQuizAnswer(ord, quizAnswer{Order: order}); !ok {
+		t.Fatalf("ordering: expected %v to be correct, want %v", order, ord.Correct)
+	}
+	slices.Reverse(order)
+	if ok, _ := gradeQuizAnswer(ord, quizAnswer{Order: order}); ok {
+		t.Fatal("ordering: reversed order should be wrong")
+	}
+
+	fill := authoredQuiz(l, models.Question{Type: "fill-blank", Prompt: "ctx.___() reports cancellation.", Answers: []string{"Err"}, Options: opts("Done")})
+	if ok, _ := gradeQuizAnswer(fill, quizAnswer{AnswerText: "  err. "}); !ok {
+		t.Fatal("fill-blank: expected a case and space insensitive match")
+	}
+	if ok, fb := gradeQuizAnswer(fill, quizAnswer{AnswerText: "done"}); ok || len(fb.Options) != 1 || fb.Options[0].Explain != "why Done" {
+		t.Fatalf("fill-blank: expected the known wrong answer explained, got %v %+v", ok, fb)
+	}
+}
+
+func TestSessionQuizUsesAuthoredQuestions(t *testing.T) {
+	sessions = newSessionManager(SessionOptions{})
+	updateLessonMap([]lessons.Lesson{
+		{ID: "authored", Title: "Context", Category: "golang", Text: "Cancel work.", Explain: "Deadlines propagate.", Questions: []models.Question{{
+			Type: "fill-blank", Prompt: "ctx.___() reports why a context ended.", Answers: []string{"Err"},
+		}}},
+		{ID: "generated", Title: "Caching", Category: "performance", Text: "Cache hot reads.", Explain: "Reads dominate."},
+	})
+	b, _ := json.Marshal(lessonsByID["authored"])
+	if strings.Contains(string(b), "questions") {
+		t.Fatalf("authored questions must not be served with the lesson: %s", b)
+	}
+
+	session := func(method, stage, body string) models.SessionState {
+		t.Helper()
+		req := httptest.NewRequest(method, "/api/session?stage="+stage, strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: "sid", Value: "quiz-sid"})
+		rr := httptest.NewRecorder()
+		handleSession(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s %s: %d %s", method, stage, rr.Code, rr.Body.String())
+		}
+		var st models.SessionState
+		_ = json.Unmarshal(rr.Body.Bytes(), &st)
+		return st
+	}
+
+	sessions.get("quiz-sid").profile.LessonsSeen = []string{"authored", "generated"}
+	st := session(http.MethodPost, "startQuiz", "")
+	for st.Stage == "quiz" {
+		var body string
+		switch st.QuestionType {
+		case models.QuestionFillBlank:
+			if len(st.Options) != 0 {
+				t.Fatalf("fill-blank should not show options, got %v", st.Options)
+			}
+			body = `{"answerText":"err"}`
+		case models.QuestionMultipleChoice:
+			if len(st.Options) != 4 {
+				t.Fatalf("generated question should have 4 options, got %v", st.Options)
+			}
+			body = `{"answerIndex":` + strconv.Itoa(slices.Index(st.Options, "Reads dominate.")) + `}`
+		default:
+			t.Fatalf("unexpected question %+v", st)
+		}
+		typ := st.QuestionType
+		st = session(http.MethodPost, "answer", body)
+		if !st.Correct || st.Feedback == nil || st.Feedback.Type != typ {
+			t.Fatalf("expected %s answer graded correct with feedback, got %+v", typ, st)
+		}
+	}
+	if p := sessions.get("quiz-sid").profile; p.QuizScore != 2 {
+		t.Fatalf("expected both answers scored, got %d", p.QuizScore)
+	}
+}
+
+func TestTimedQuizEnforcesDeadlines(t *testing.T) {
+	sessions = newSessionManager(SessionOptions{})
+	updateLessonMap([]lessons.Lesson{
+		{ID: "caching", Title: "Caching", Category: "performance", Text: "Cache hot reads.", Explain: "Reads dominate."},
+		{ID: "retries", Title: "Retries", Category: "reliability", Text: "Retry with backoff.", Explain: "Jitter spreads load."},
+		{ID: "timeouts", Title: "Timeouts", Category: "reliability", Text: "Bound remote calls.", Explain: "Deadlines stop pileups."},
+	})
+	session := func(stage, body string) models.SessionState {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/session?"+stage, strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: "sid", Value: "timed-sid"})
+		rr := httptest.NewRecorder()
+		handleSession(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: %d %s", stage, rr.Code, rr.Body.String())
+		}
+		var st models.SessionState
+		_ = json.Unmarshal(rr.Body.Bytes(), &st)
+		return st
+	}
+	p := sessions.get("timed-sid").profile
+	p.LessonsSeen = []string{"caching", "retries", "timeouts"}
+	answer := func() string {
+		return `{"answerIndex":` + strconv.Itoa(p.CurrentQuiz[p.QuizIndex].CorrectIndex) + `}`
+	}
+
+	st := session("stage=startQuiz&timed=true", "")
+	if !st.Timed || st.QuestionTimeLeftMs <= 0 || st.QuestionTimeLeftMs > timedQuestionLimit.Milliseconds() ||
+		st.QuizTimeLeftMs <= 0 || st.QuizTimeLeftMs > (3*timedQuizPerQuestion).Milliseconds() {
+		t.Fatalf("expected countdowns on a timed quiz, got %+v", st)
+	}
+
+	// A prompt answer scores close to the maximum.
+	st = session("stage=answer", answer())
+	if !st.Correct || st.Points < timedMaxPoints-1 || p.QuizScore != st.Points {
+		t.Fatalf("expected a fast answer to earn speed points, got %+v (score %d)", st, p.QuizScore)
+	}
+
+	// Pausing the client does not stop the server's clock: a right answer
+	// after the question deadline scores nothing.
+	p.QuestionAsked = p.QuestionAsked.Add(-timedQuestionLimit - 2*timedAnswerGrace)
+	score := p.QuizScore
+	st = session("stage=answer", answer())
+	if st.Correct || !st.Late || st.Points != 0 || p.QuizScore != score || !st.More {
+		t.Fatalf("expected a late answer scored zero, got %+v", st)
+	}
+
+	// Past the quiz deadline the quiz ends.
+	p.QuizDeadline = time.Now().Add(-2 * timedAnswerGrace)
+	st = session("stage=answer", answer())
+	if st.More || !st.Late || len(p.CurrentQuiz) != 0 || p.Timed {
+		t.Fatalf("expected the quiz to end at its deadline, got %+v", st)
+	}
+	if timedPoints(0) != 1 || timedPoints(timedQuestionLimit) != timedMaxPoints {
+		t.Fatalf("points should run from %d down to 1", timedMaxPoints)
+	}
+
+	// Speed points are ranked on their own board, not against untimed scores.
+	submit := func(mode string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/leaderboard/submit", strings.NewReader(`{"name":"Ada","score":1,"mode":"`+mode+`"}`))
+		req.AddCookie(&http.Cookie{Name: "sid", Value: "timed-sid"})
+		rr := httptest.NewRecorder()
+		handleLeaderboardSubmit(rr, req)
+		return rr.Code
+	}
+	if code := submit("quiz"); code != http.StatusBadRequest {
+		t.Fatalf("a timed score on the quiz board should be refused, got %d", code)
+	}
+	if code := submit("quiz-timed"); code != http.StatusOK {
+		t.Fatalf("expected the timed score accepted on quiz-timed, got %d", code)
+	}
+}
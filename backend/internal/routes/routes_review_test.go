@@ -0,0 +1,100 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"avidlearner/internal/lessons"
+	"avidlearner/internal/models"
+)
+
+func TestReviewScheduleFromQuizAnswers(t *testing.T) {
+	user, token := setupLedgerTest(t)
+	sessions = newSessionManager(SessionOptions{})
+	updateLessonMap([]lessons.Lesson{
+		{ID: "overdue", Title: "Caching", Category: "performance", Text: "Cache hot reads.", Explain: "Reads dominate."},
+		{ID: "fresh", Title: "Retries", Category: "reliability", Text: "Retry with backoff.", Explain: "Jitter spreads load."},
+		{ID: "lapsed", Title: "Indexes", Category: "databases", Text: "Index lookups.", Explain: "B-trees."},
+	})
+
+	now := time.Now()
+	updateUserByID(user.ID, func(u *models.User) {
+		recordLedgerLocked(u, models.LedgerEntry{Kind: models.LedgerQuizAnswer, Ref: "overdue", Correct: true, At: now.Add(-72 * time.Hour)})
+		recordLedgerLocked(u, models.LedgerEntry{Kind: models.LedgerQuizAnswer, Ref: "fresh", Correct: true, At: now})
+		recordLedgerLocked(u, models.LedgerEntry{Kind: models.LedgerQuizAnswer, Ref: "lapsed", Correct: false, At: now.Add(-25 * time.Hour)})
+	})
+	if item := getUserByID(user.ID).Profile.Reviews["lapsed"]; item.Lapses != 1 || item.Interval != 1 {
+		t.Fatalf("wrong answer should schedule a lapse, got %+v", item)
+	}
+
+	// The schedule is re-derived from the ledger on load.
+	if err := LoadUsers(); err != nil {
+		t.Fatalf("LoadUsers: %v", err)
+	}
+	user = getUserByID(user.ID)
+	if len(user.Profile.Reviews) != 3 {
+		t.Fatalf("expected reviews rebuilt from the ledger, got %+v", user.Profile.Reviews)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/review/due", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	handleReviewDue(rr, req)
+	var resp struct {
+		Total     int         `json:"total"`
+		Scheduled int         `json:"scheduled"`
+		Due       []DueReview `json:"due"`
+		NextDue   *time.Time  `json:"nextDue"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v (%s)", err, rr.Body.String())
+	}
+	if resp.Total != 2 || resp.Scheduled != 3 || resp.NextDue == nil {
+		t.Fatalf("unexpected due list %+v", resp)
+	}
+	if resp.Due[0].LessonID != "overdue" || resp.Due[1].LessonID != "lapsed" {
+		t.Fatalf("expected most overdue first, got %+v", resp.Due)
+	}
+
+	session := func(method, stage, body string) models.SessionState {
+		t.Helper()
+		req := httptest.NewRequest(method, "/api/session?stage="+stage, strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: "sid", Value: "review-sid"})
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handleSession(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s %s: %d %s", method, stage, rr.Code, rr.Body.String())
+		}
+		var st models.SessionState
+		_ = json.Unmarshal(rr.Body.Bytes(), &st)
+		return st
+	}
+
+	p := sessions.get("review-sid").profile
+	p.LessonsSeen = []string{"fresh"}
+	st := session(http.MethodPost, "review", "")
+	if st.Stage != "quiz" || st.Total != 2 {
+		t.Fatalf("review should ask only the due lessons, got %+v", st)
+	}
+	for i := 0; i < 2; i++ {
+		correct := p.CurrentQuiz[p.QuizIndex].CorrectIndex
+		session(http.MethodPost, "answer", `{"answerIndex":`+strconv.Itoa(correct)+`}`)
+	}
+	if len(p.LessonsSeen) != 1 {
+		t.Fatalf("a review quiz must keep the study list, got %v", p.LessonsSeen)
+	}
+
+	due, _ := dueReviews(getUserByID(user.ID), time.Now())
+	if len(due) != 0 {
+		t.Fatalf("answered reviews should be rescheduled, still due: %+v", due)
+	}
+	if item := getUserByID(user.ID).Profile.Reviews["overdue"]; item.Repetitions != 2 || item.Interval != 6 {
+		t.Fatalf("second correct review should move to a 6 day interval, got %+v", item)
+	}
+}
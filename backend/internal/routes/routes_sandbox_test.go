@@ -0,0 +1,222 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"avidlearner/internal/models"
+	"avidlearner/internal/sandbox"
+)
+
+func useSandbox(t *testing.T, r *sandbox.Runner) {
+	t.Helper()
+	prev := sandboxRunner
+	SetSandbox(r)
+	t.Cleanup(func() { SetSandbox(prev) })
+}
+
+// useIsolatedSandbox grades with the host's full isolation, skipping the
+// test where it or the go toolchain is unavailable.
+func useIsolatedSandbox(t *testing.T) {
+	t.Helper()
+	r, err := sandbox.New(sandbox.Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Isolation().Namespaces {
+		t.Skipf("no namespace isolation on this host: %s", r.Isolation().Reason)
+	}
+	if _, err := goRoot(); err != nil {
+		t.Skip(err)
+	}
+	useSandbox(t, r)
+	pool, err := newWorkspacePool(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := challengeWorkspaces
+	challengeWorkspaces = func() (*workspacePool, error) { return pool, nil }
+	t.Cleanup(func() { challengeWorkspaces = prev })
+	t.Chdir(filepath.Join("..", "..")) // the hidden tests are under backend/protests
+}
+
+func TestChallengeSubmissionIsSandboxed(t *testing.T) {
+	useIsolatedSandbox(t)
+
+	users := filepath.Join(t.TempDir(), "users.json")
+	if err := os.WriteFile(users, []byte(`[{"username":"admin"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	source := fmt.Sprintf(`package challenge
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+func init() {
+	_, err := os.ReadFile(%q)
+	fmt.Println("read users.json:", err)
+	_, err = net.Dial("tcp", %q)
+	fmt.Println("dial:", err)
+}
+
+func Normalize(s string) string { return strings.TrimSpace(s) }
+`, users, l.Addr().String())
+
+	res, err := runChallengeTests(context.Background(), models.ProChallenge{ID: "clean-string-normalizer"}, source, nil)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !strings.Contains(res.Stdout, "read users.json:") {
+		t.Fatalf("submission did not run: %+v", res)
+	}
+	if strings.Contains(res.Stdout, "read users.json: <nil>") {
+		t.Errorf("submission read users.json:\n%s", res.Stdout)
+	}
+	if strings.Contains(res.Stdout, "dial: <nil>") {
+		t.Errorf("submission opened a socket to the host:\n%s", res.Stdout)
+	}
+	if res.Passed || len(res.Failures) == 0 {
+		t.Errorf("expected the hidden tests to fail, got %+v", res)
+	}
+}
+
+func TestChallengeResultsAreStructured(t *testing.T) {
+	useIsolatedSandbox(t)
+	ch := models.ProChallenge{ID: "clean-string-normalizer"}
+
+	// Only TestNormalizeIdempotent passes when nothing is normalized.
+	res, err := runChallengeTests(context.Background(), ch, "package challenge\n\nfunc Normalize(s string) string { return s }\n", nil)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	statuses := map[string]string{}
+	for _, tc := range res.Tests {
+		statuses[tc.Name] = tc.Status
+	}
+	want := map[string]string{
+		"TestNormalizeProducesCleanSentences": "fail",
+		"TestNormalizeCollapsesWhitespace":    "fail",
+		"TestNormalizeIdempotent":             "pass",
+	}
+	if res.Passed || res.Total != 3 || fmt.Sprint(statuses) != fmt.Sprint(want) {
+		t.Fatalf("unexpected results: total %d, tests %v", res.Total, statuses)
+	}
+	if len(res.Failures) != 2 || !strings.Contains(res.Failures[0].Output, "unexpected normalization result") {
+		t.Fatalf("expected the two failing tests with their logs, got %+v", res.Failures)
+	}
+
+	// A compile error points into the submission.
+	res, err = runChallengeTests(context.Background(), ch, "package challenge\n\nfunc Normalize(s string) string {\n\treturn 1\n}\n", nil)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if res.Passed || len(res.Diagnostics) == 0 {
+		t.Fatalf("expected build diagnostics, got %+v", res)
+	}
+	if d := res.Diagnostics[0]; d.File != "challenge.go" || d.Line != 4 || !strings.Contains(d.Message, "cannot use 1") {
+		t.Fatalf("unexpected diagnostic %+v", d)
+	}
+}
+
+func TestConcurrentGradingPinsGoMaxProcs(t *testing.T) {
+	useIsolatedSandbox(t)
+	ch := models.ProChallenge{ID: "clean-string-normalizer"}
+	// Every sandbox shares the sandbox user's thread limit, so each holds
+	// its Go programs to challengeProcs whatever the host's core count.
+	src := fmt.Sprintf("package challenge\n\nimport \"runtime\"\n\nfunc init() {\n\tif n := runtime.GOMAXPROCS(0); n != %d {\n\t\tpanic(n)\n\t}\n}\n\nfunc Normalize(s string) string { return s }\n", challengeProcs)
+
+	errs := make(chan error, 4)
+	for range cap(errs) {
+		go func() {
+			res, err := runChallengeTests(context.Background(), ch, src, nil)
+			if err == nil && res.Total != 3 {
+				err = fmt.Errorf("expected 3 tests run, got %+v", res)
+			}
+			errs <- err
+		}()
+	}
+	for range cap(errs) {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestChallengeWorkspaceIsReadOnlyToTests(t *testing.T) {
+	useIsolatedSandbox(t)
+	ch := models.ProChallenge{ID: "clean-string-normalizer"}
+
+	// The first submission tries to replace the hidden tests next to its
+	// binary with ones that always pass.
+	tamper := `package challenge
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func init() {
+	err := os.WriteFile(filepath.Join(filepath.Dir(os.Args[0]), "challenge_test.go"), []byte("package challenge\n"), 0o644)
+	fmt.Println("replace tests:", err)
+}
+
+func Normalize(s string) string { return s }
+`
+	res, err := runChallengeTests(context.Background(), ch, tamper, nil)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !strings.Contains(res.Stdout, "replace tests:") || strings.Contains(res.Stdout, "replace tests: <nil>") {
+		t.Fatalf("expected the workspace to be read-only, got:\n%s", res.Stdout)
+	}
+	if res.CompileTime <= 0 || res.RunTime <= 0 {
+		t.Fatalf("expected compile and run times, got %v and %v", res.CompileTime, res.RunTime)
+	}
+
+	// The next one reuses the workspace and is graded by the real tests.
+	res, err = runChallengeTests(context.Background(), ch, "package challenge\n\nfunc Normalize(s string) string { return s }\n", nil)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if res.Passed || res.Total != 3 {
+		t.Fatalf("expected the hidden tests to grade the second submission, got %+v", res)
+	}
+}
+
+func TestChallengeSubmitWithoutSandbox(t *testing.T) {
+	strict, err := sandbox.New(sandbox.Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strict.Isolation().Namespaces {
+		strict = nil // nil refuses like a host without namespaces
+	}
+	useSandbox(t, strict)
+	ch := models.ProChallenge{ID: "clean-string-normalizer", Title: "Normalize"}
+	SetProChallenges([]models.ProChallenge{ch}, map[string]models.ProChallenge{ch.ID: ch})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/prochallenge/submit", strings.NewReader(`{"id":"clean-string-normalizer","code":"package challenge"}`))
+	rr := httptest.NewRecorder()
+	handleProChallengeSubmit(rr, req)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without a sandbox, got %d %s", rr.Code, rr.Body.String())
+	}
+}
@@ -1,11 +1,17 @@
 package routes
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"avidlearner/internal/models"
+	"avidlearner/internal/store"
 )
 
 func TestWithSession(t *testing.T) {
@@ -59,7 +65,7 @@ func TestWithSession(t *testing.T) {
 		req.AddCookie(&http.Cookie{Name: "sid", Value: "existing-session-id"})
 		rr := httptest.NewRecorder()
 
-		sessions["existing-session-id"] = newProfile()
+		sessions.put("existing-session-id", newProfile())
 
 		handler.ServeHTTP(rr, req)
 
@@ -71,7 +77,7 @@ func TestWithSession(t *testing.T) {
 
 func TestGetProfile(t *testing.T) {
 	// Clear sessions
-	sessions = map[string]*models.Profile{}
+	sessions = newSessionManager(SessionOptions{})
 
 	t.Run("returns new profile when no cookie", func(t *testing.T) {
 		req := httptest.NewRequest("GET", "/", nil)
@@ -97,7 +103,7 @@ func TestGetProfile(t *testing.T) {
 		expectedProfile := newProfile()
 		expectedProfile.Coins = 100
 		expectedProfile.XP = 50
-		sessions["test-session"] = expectedProfile
+		sessions.put("test-session", expectedProfile)
 
 		p := getProfile(req)
 
@@ -125,7 +131,7 @@ func TestGetProfile(t *testing.T) {
 		}
 
 		// Should create and store new profile
-		if _, ok := sessions["unknown-session"]; !ok {
+		if _, ok := sessions.lookup("unknown-session"); !ok {
 			t.Error("expected profile to be stored in sessions")
 		}
 	})
@@ -166,3 +172,179 @@ func TestNewProfile(t *testing.T) {
 		t.Error("expected nil RecentLessons slice initially")
 	}
 }
+
+func TestSessionManagerExpiry(t *testing.T) {
+	now := time.Now()
+	m := newSessionManager(SessionOptions{IdleTTL: time.Hour, AbsoluteTTL: 4 * time.Hour})
+	m.now = func() time.Time { return now }
+
+	m.get("idle").profile.Coins = 5
+	m.get("active").profile.Coins = 7
+
+	for i := 0; i < 4; i++ {
+		now = now.Add(50 * time.Minute)
+		m.get("active")
+	}
+
+	if removed := m.reap(); removed != 1 {
+		t.Fatalf("expected 1 idle session reaped, got %d", removed)
+	}
+	if _, ok := m.lookup("idle"); ok {
+		t.Error("idle session should have been evicted")
+	}
+	if p, ok := m.lookup("active"); !ok || p.Coins != 7 {
+		t.Fatal("active session should survive idle reaping")
+	}
+
+	now = now.Add(time.Hour)
+	if p := m.get("active").profile; p.Coins != 0 {
+		t.Errorf("expected a fresh profile after absolute expiry, got %d coins", p.Coins)
+	}
+}
+
+func TestSessionManagerCapEvictsLeastRecentlyUsed(t *testing.T) {
+	m := newSessionManager(SessionOptions{MaxSessions: 2})
+	m.get("a")
+	m.get("b")
+	m.get("a")
+	m.get("c")
+
+	if m.len() != 2 {
+		t.Fatalf("expected 2 sessions, got %d", m.len())
+	}
+	if _, ok := m.lookup("b"); ok {
+		t.Error("least recently used session should have been evicted")
+	}
+	if _, ok := m.lookup("a"); !ok {
+		t.Error("recently used session should be kept")
+	}
+}
+
+func TestSessionManagerPersistence(t *testing.T) {
+	dir := t.TempDir()
+	newStore := func() store.Store {
+		return store.NewJSONStore(filepath.Join(dir, "users.json"), filepath.Join(dir, "leaderboard.json"), filepath.Join(dir, "sessions.json"))
+	}
+
+	s := newStore()
+	m := newSessionManager(SessionOptions{Persist: true})
+	if err := m.load(s); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	e := m.get("sid-1")
+	e.profile.Coins = 30
+	e.profile.Streak = 4
+	e.profile.HintIdx["fan-in-fan-out"] = 2
+	e.dirty = true
+	m.get("sid-2")
+	m.delete("sid-2")
+
+	if err := m.persist(); err != nil {
+		t.Fatalf("persist: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	restored := newSessionManager(SessionOptions{Persist: true})
+	if err := restored.load(newStore()); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	p, ok := restored.lookup("sid-1")
+	if !ok {
+		t.Fatal("expected session to survive restart")
+	}
+	if p.Coins != 30 || p.Streak != 4 || p.HintIdx["fan-in-fan-out"] != 2 {
+		t.Errorf("unexpected restored profile: %+v", p)
+	}
+	if _, ok := restored.lookup("sid-2"); ok {
+		t.Error("deleted session should not be restored")
+	}
+}
+
+func TestSessionManagerConcurrentAccess(t *testing.T) {
+	m := newSessionManager(SessionOptions{MaxSessions: 50})
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				e := m.get(fmt.Sprintf("sid-%d", (i*j)%80))
+				e.mu.Lock()
+				e.profile.Coins++
+				e.mu.Unlock()
+				m.reap()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if m.len() > 50 {
+		t.Fatalf("session cap exceeded: %d", m.len())
+	}
+}
+
+func TestSignupThroughSessionLockWithPersistence(t *testing.T) {
+	if err := SetAuthConfig("test-secret-0123456789", time.Hour); err != nil {
+		t.Fatalf("SetAuthConfig: %v", err)
+	}
+	dir := t.TempDir()
+	s := store.NewJSONStore(filepath.Join(dir, "users.json"), filepath.Join(dir, "leaderboard.json"), filepath.Join(dir, "sessions.json"))
+	prev := dataStore
+	dataStore = s
+	t.Cleanup(func() {
+		s.Close()
+		dataStore = prev
+	})
+	usersByID = map[string]*models.User{}
+	usersByName = map[string]*models.User{}
+	sessions = newSessionManager(SessionOptions{Persist: true})
+	if err := sessions.load(s); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	sessions.get("visitor").profile.Coins = 10
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/signup", strings.NewReader(`{"username":"persisted","password":"password123","leaderboardOptIn":true}`))
+		req.AddCookie(&http.Cookie{Name: "sid", Value: "visitor"})
+		rr := httptest.NewRecorder()
+		cors(handleSignup)(rr, req)
+		done <- rr
+	}()
+	select {
+	case rr := <-done:
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("signup hung holding its session lock")
+	}
+
+	// A flush while a request holds a session skips it instead of waiting,
+	// and writes it once the request is done.
+	e := sessions.get("visitor")
+	e.mu.Lock()
+	e.dirty = true
+	flushed := make(chan error)
+	go func() { flushed <- FlushStore() }()
+	select {
+	case err := <-flushed:
+		if err != nil {
+			t.Fatalf("flush: %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("flush waited for a busy session")
+	}
+	e.mu.Unlock()
+	if err := FlushStore(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	saved, err := s.Sessions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := saved["visitor"]; !ok || e.dirty {
+		t.Fatalf("expected the session written after its request, got %v", saved)
+	}
+}
@@ -0,0 +1,184 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"avidlearner/internal/lessons"
+	"avidlearner/internal/models"
+	"avidlearner/internal/tracks"
+)
+
+func TestTrackEnrollNextAndComplete(t *testing.T) {
+	user, token := setupLedgerTest(t)
+	updateLessonMap([]lessons.Lesson{
+		{ID: "l-goroutines", Title: "Goroutines", Category: "golang"},
+		{ID: "l-context", Title: "Context", Category: "golang"},
+	})
+	SetProChallenges([]models.ProChallenge{{ID: "fan-in-fan-out", Title: "Fan-In/Fan-Out"}},
+		map[string]models.ProChallenge{"fan-in-fan-out": {ID: "fan-in-fan-out", Title: "Fan-In/Fan-Out"}})
+	track := tracks.Track{ID: "go", Title: "Go Concurrency", Steps: []tracks.Step{
+		{ID: "goroutines", Lesson: "l-goroutines"},
+		{ID: "context", Lesson: "l-context", Requires: []string{"goroutines"}},
+		{ID: "fan-in-fan-out", Challenge: "fan-in-fan-out", Requires: []string{"context"}},
+	}}
+	SetTracks([]tracks.Track{track})
+	t.Cleanup(func() {
+		SetTracks(nil)
+		SetProChallenges(nil, nil)
+	})
+
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handleTrack(rr, req)
+		return rr
+	}
+	type trackState struct {
+		Track struct {
+			Completed int `json:"completed"`
+		} `json:"track"`
+		Steps []tracks.StepState `json:"steps"`
+	}
+
+	if rr := call(http.MethodGet, "/api/tracks/go/next", ""); rr.Code != http.StatusConflict {
+		t.Fatalf("next before enrolling should be 409, got %d", rr.Code)
+	}
+	if rr := call(http.MethodPost, "/api/tracks/go/enroll", ""); rr.Code != http.StatusOK {
+		t.Fatalf("enroll: %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr := call(http.MethodGet, "/api/tracks/go/next", "")
+	var next struct {
+		Step   tracks.StepState `json:"step"`
+		Lesson *models.Lesson   `json:"lesson"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &next); err != nil {
+		t.Fatal(err)
+	}
+	if next.Step.ID != "goroutines" || next.Lesson == nil || next.Lesson.Title != "Goroutines" {
+		t.Fatalf("unexpected next step %s", rr.Body.String())
+	}
+
+	rr = call(http.MethodPost, "/api/tracks/go/complete", `{"step":"context"}`)
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "goroutines") {
+		t.Fatalf("locked step should be refused with its missing prerequisite, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := call(http.MethodPost, "/api/tracks/go/complete", `{"step":"fan-in-fan-out"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("challenge steps cannot be marked by hand, got %d", rr.Code)
+	}
+	for _, step := range []string{"goroutines", "context"} {
+		if rr := call(http.MethodPost, "/api/tracks/go/complete", `{"step":"`+step+`"}`); rr.Code != http.StatusOK {
+			t.Fatalf("complete %s: %d %s", step, rr.Code, rr.Body.String())
+		}
+	}
+
+	var st trackState
+	_ = json.Unmarshal(call(http.MethodGet, "/api/tracks/go", "").Body.Bytes(), &st)
+	if st.Track.Completed != 2 || st.Steps[2].Status != tracks.StatusAvailable || st.Steps[2].Title != "Fan-In/Fan-Out" {
+		t.Fatalf("challenge step should now be available, got %+v", st)
+	}
+
+	// A passing submission completes the challenge step and the track.
+	var advanced []string
+	updateUserByID(user.ID, func(u *models.User) {
+		advanced = completeChallengeStepsLocked(u, lookupTracks(), "fan-in-fan-out", time.Now())
+	})
+	if len(advanced) != 1 || advanced[0] != "go" {
+		t.Fatalf("expected the go track to advance, got %v", advanced)
+	}
+	if tp := getUserByID(user.ID).Profile.Tracks["go"]; tp.CompletedAt == nil {
+		t.Fatalf("track should be complete, got %+v", tp)
+	}
+	rr = call(http.MethodGet, "/api/tracks/go/next", "")
+	if !strings.Contains(rr.Body.String(), `"done":true`) {
+		t.Fatalf("expected done, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/tracks", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	handleTracks(rr, req)
+	if !strings.Contains(rr.Body.String(), `"enrolled":true`) || !strings.Contains(rr.Body.String(), `"completed":3`) {
+		t.Fatalf("unexpected track list %s", rr.Body.String())
+	}
+}
+
+func TestTrackLocksContentForEnrolledUsers(t *testing.T) {
+	_, token := setupLedgerTest(t)
+	sessions = newSessionManager(SessionOptions{})
+	updateLessonMap([]lessons.Lesson{
+		{ID: "l-basics", Title: "Basics", Category: "golang"},
+		{ID: "l-advanced", Title: "Advanced", Category: "golang"},
+	})
+	ch := models.ProChallenge{ID: "capstone", Title: "Capstone", Difficulty: "advanced"}
+	SetProChallenges([]models.ProChallenge{ch}, map[string]models.ProChallenge{ch.ID: ch})
+	SetTracks([]tracks.Track{{ID: "go", Title: "Go", Steps: []tracks.Step{
+		{ID: "basics", Lesson: "l-basics"},
+		{ID: "advanced", Lesson: "l-advanced", Requires: []string{"basics"}},
+		{ID: "capstone", Challenge: "capstone", Requires: []string{"advanced"}},
+	}}})
+	t.Cleanup(func() {
+		SetTracks(nil)
+		SetProChallenges(nil, nil)
+	})
+
+	call := func(h http.HandlerFunc, method, path, body, bearer string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: "sid", Value: "learner"})
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		rr := httptest.NewRecorder()
+		h(rr, req)
+		return rr
+	}
+	locked := func(rr *httptest.ResponseRecorder, step, missing string) {
+		t.Helper()
+		var resp struct {
+			Locked []trackLock `json:"locked"`
+		}
+		_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+		if rr.Code != http.StatusForbidden || len(resp.Locked) != 1 || resp.Locked[0].Step != step || strings.Join(resp.Locked[0].Missing, ",") != missing {
+			t.Fatalf("expected %s locked by %s, got %d %s", step, missing, rr.Code, rr.Body.String())
+		}
+	}
+
+	// Tracks only lock content for users enrolled in them.
+	if rr := call(handleLessonByID, http.MethodGet, "/api/lessons/l-advanced", "", token); rr.Code != http.StatusOK {
+		t.Fatalf("expected an open lesson before enrolling, got %d", rr.Code)
+	}
+	if rr := call(handleTrack, http.MethodPost, "/api/tracks/go/enroll", "", token); rr.Code != http.StatusOK {
+		t.Fatalf("enroll: %d %s", rr.Code, rr.Body.String())
+	}
+
+	locked(call(handleLessonByID, http.MethodGet, "/api/lessons/l-advanced", "", token), "advanced", "basics")
+	if rr := call(handleLessonByID, http.MethodGet, "/api/lessons/l-basics", "", token); rr.Code != http.StatusOK {
+		t.Fatalf("expected the first step open, got %d", rr.Code)
+	}
+	if rr := call(handleLessonByID, http.MethodGet, "/api/lessons/l-advanced", "", ""); rr.Code != http.StatusOK {
+		t.Fatalf("expected anonymous callers unaffected, got %d", rr.Code)
+	}
+	locked(call(handleProChallenge, http.MethodGet, "/api/prochallenge?difficulty=any", "", token), "capstone", "advanced")
+	locked(call(handleProChallengeHint, http.MethodPost, "/api/prochallenge/hint", `{"id":"capstone"}`, token), "capstone", "advanced")
+	locked(call(handleProChallengeSubmit, http.MethodPost, "/api/prochallenge/submit", `{"id":"capstone","code":"package challenge"}`, token), "capstone", "advanced")
+
+	for _, step := range []string{"basics", "advanced"} {
+		if rr := call(handleTrack, http.MethodPost, "/api/tracks/go/complete", `{"step":"`+step+`"}`, token); rr.Code != http.StatusOK {
+			t.Fatalf("complete %s: %d %s", step, rr.Code, rr.Body.String())
+		}
+	}
+	if rr := call(handleProChallenge, http.MethodGet, "/api/prochallenge?difficulty=any", "", token); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"capstone"`) {
+		t.Fatalf("expected the challenge open once its prerequisites are done, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := call(handleProChallengeHint, http.MethodPost, "/api/prochallenge/hint", `{"id":"capstone"}`, token); rr.Code == http.StatusForbidden {
+		t.Fatalf("expected hints once unlocked, got %s", rr.Body.String())
+	}
+}
@@ -0,0 +1,98 @@
+package routes
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"avidlearner/internal/sandbox"
+)
+
+// sandboxRunner runs the go toolchain and test binaries for pro challenge
+// submissions. Submissions are refused while it is nil or unavailable.
+var sandboxRunner *sandbox.Runner
+
+// SetSandbox sets the runner for pro challenge submissions.
+func SetSandbox(r *sandbox.Runner) {
+	sandboxRunner = r
+}
+
+// goRoot is the host's Go installation, mounted read-only into the sandbox
+// that compiles submissions. It is asked of the go command on PATH once.
+var goRoot = sync.OnceValues(func() (string, error) {
+	out, err := exec.Command("go", "env", "GOROOT").Output()
+	if err != nil {
+		return "", fmt.Errorf("find the go toolchain: %w", err)
+	}
+	return filepath.EvalSymlinks(strings.TrimSpace(string(out)))
+})
+
+// challengeCacheDir is the build cache shared by sandboxed compiles. It is
+// only ever mounted writable for the go command, never for a test binary, so
+// a submission cannot plant entries other builds would pick up.
+var challengeCacheDir = sync.OnceValues(func() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	dir := filepath.Join(base, "avidlearner", "sandbox-gocache")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return dir, nil
+})
+
+// challengeTest2JSON is the test2json tool, which runs a test binary and
+// turns its output into go test -json events. Toolchains no longer ship it
+// prebuilt, so it is built from the host's GOROOT once per server run.
+var challengeTest2JSON = sync.OnceValues(func() (string, error) {
+	goroot, err := goRoot()
+	if err != nil {
+		return "", err
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	dir := filepath.Join(base, "avidlearner", "sandbox-tools")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	dst := filepath.Join(dir, "test2json")
+	cmd := exec.Command(filepath.Join(goroot, "bin", "go"), "build", "-o", dst, "cmd/test2json")
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=0", "GOTOOLCHAIN=local", "GOFLAGS=")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("build test2json: %w: %s", err, out)
+	}
+	return dst, nil
+})
+
+// challengeBuildEnv is the whole environment of a sandboxed go command: no
+// network, no toolchain downloads, no cgo, nothing from the server's own
+// environment and no more than challengeProcs packages built at once.
+func challengeBuildEnv(goroot, cache, dir string) []string {
+	return []string{
+		"PATH=" + filepath.Join(goroot, "bin") + ":/usr/bin:/bin",
+		"HOME=" + dir,
+		"TMPDIR=/tmp",
+		"GOROOT=" + goroot,
+		"GOCACHE=" + cache,
+		"GOPATH=" + filepath.Join(dir, ".gopath"),
+		"GOENV=off",
+		"GOFLAGS=-p=" + strconv.Itoa(challengeProcs),
+		"GOMAXPROCS=" + strconv.Itoa(challengeProcs),
+		"GOTOOLCHAIN=local",
+		"GOPROXY=off",
+		"GOSUMDB=off",
+		"CGO_ENABLED=0",
+	}
+}
+
+// challengeTestEnv is the whole environment of a sandboxed test binary.
+func challengeTestEnv(dir string) []string {
+	return []string{"PATH=/usr/bin:/bin", "HOME=" + dir, "TMPDIR=/tmp", "GOMAXPROCS=" + strconv.Itoa(challengeProcs)}
+}
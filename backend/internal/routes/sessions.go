@@ -0,0 +1,292 @@
+package routes
+
+import (
+	"container/list"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"avidlearner/internal/models"
+	"avidlearner/internal/store"
+)
+
+const (
+	defaultSessionIdleTTL     = 7 * 24 * time.Hour
+	defaultSessionAbsoluteTTL = 30 * 24 * time.Hour
+	defaultMaxSessions        = 50000
+)
+
+// SessionOptions bounds how long and how many anonymous sessions are kept.
+type SessionOptions struct {
+	IdleTTL     time.Duration // evict after this long without a request
+	AbsoluteTTL time.Duration // evict this long after creation regardless of activity
+	MaxSessions int           // evict least recently used sessions beyond this
+	Persist     bool          // save sessions through the store so they survive restarts
+}
+
+// sessionEntry is one anonymous visitor. mu serialises API requests for the
+// session so handlers can mutate profile without further locking.
+type sessionEntry struct {
+	id       string
+	mu       sync.Mutex
+	profile  *models.Profile
+	created  time.Time
+	lastSeen time.Time
+	dirty    bool
+	elem     *list.Element
+}
+
+// sessionManager is a concurrency-safe, size-capped LRU of anonymous
+// sessions keyed by the sid cookie.
+type sessionManager struct {
+	mu      sync.Mutex
+	opts    SessionOptions
+	entries map[string]*sessionEntry
+	lru     *list.List // front = most recently used
+	deleted map[string]struct{}
+	store   store.Store
+	now     func() time.Time
+}
+
+func newSessionManager(opts SessionOptions) *sessionManager {
+	if opts.IdleTTL <= 0 {
+		opts.IdleTTL = defaultSessionIdleTTL
+	}
+	if opts.AbsoluteTTL <= 0 {
+		opts.AbsoluteTTL = defaultSessionAbsoluteTTL
+	}
+	if opts.MaxSessions <= 0 {
+		opts.MaxSessions = defaultMaxSessions
+	}
+	return &sessionManager{
+		opts:    opts,
+		entries: map[string]*sessionEntry{},
+		lru:     list.New(),
+		deleted: map[string]struct{}{},
+		now:     time.Now,
+	}
+}
+
+// get returns the session for id, creating it when missing or expired.
+func (m *sessionManager) get(id string) *sessionEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if e, ok := m.entries[id]; ok {
+		if !m.expiredLocked(e, now) {
+			e.lastSeen = now
+			m.lru.MoveToFront(e.elem)
+			return e
+		}
+		m.removeLocked(e)
+	}
+	return m.insertLocked(id, newProfile(), now, now)
+}
+
+// put stores profile under id, replacing any existing session.
+func (m *sessionManager) put(id string, profile *models.Profile) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.entries[id]; ok {
+		m.removeLocked(e)
+	}
+	now := m.now()
+	e := m.insertLocked(id, profile, now, now)
+	e.dirty = true
+}
+
+// lookup returns the profile for id without creating or touching it.
+func (m *sessionManager) lookup(id string) (*models.Profile, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[id]
+	if !ok || m.expiredLocked(e, m.now()) {
+		return nil, false
+	}
+	return e.profile, true
+}
+
+// update runs fn on the profile of session id under the session's lock, as
+// an API request for it would, and reports whether the session exists. It is
+// for work finishing after the request that started it, such as grading.
+func (m *sessionManager) update(id string, fn func(*models.Profile)) bool {
+	m.mu.Lock()
+	e, ok := m.entries[id]
+	if ok && m.expiredLocked(e, m.now()) {
+		ok = false
+	}
+	m.mu.Unlock()
+	if !ok {
+		return false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.dirty = true
+	fn(e.profile)
+	return true
+}
+
+func (m *sessionManager) delete(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.entries[id]; ok {
+		m.removeLocked(e)
+	}
+}
+
+func (m *sessionManager) len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries)
+}
+
+func (m *sessionManager) insertLocked(id string, profile *models.Profile, created, lastSeen time.Time) *sessionEntry {
+	for len(m.entries) >= m.opts.MaxSessions {
+		oldest := m.lru.Back()
+		if oldest == nil {
+			break
+		}
+		m.removeLocked(oldest.Value.(*sessionEntry))
+	}
+	e := &sessionEntry{id: id, profile: profile, created: created, lastSeen: lastSeen}
+	e.elem = m.lru.PushFront(e)
+	m.entries[id] = e
+	delete(m.deleted, id)
+	return e
+}
+
+func (m *sessionManager) removeLocked(e *sessionEntry) {
+	m.lru.Remove(e.elem)
+	delete(m.entries, e.id)
+	m.deleted[e.id] = struct{}{}
+}
+
+func (m *sessionManager) expiredLocked(e *sessionEntry, now time.Time) bool {
+	return now.Sub(e.lastSeen) > m.opts.IdleTTL || now.Sub(e.created) > m.opts.AbsoluteTTL
+}
+
+// reap evicts expired sessions and returns how many were removed.
+func (m *sessionManager) reap() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	removed := 0
+	for el := m.lru.Back(); el != nil; {
+		prev := el.Prev()
+		e := el.Value.(*sessionEntry)
+		if m.expiredLocked(e, now) {
+			m.removeLocked(e)
+			removed++
+		}
+		el = prev
+	}
+	return removed
+}
+
+// load restores persisted sessions from s and remembers s for persist.
+func (m *sessionManager) load(s store.Store) error {
+	saved, err := s.Sessions()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.store = s
+	now := m.now()
+	for id, p := range saved {
+		profile := p
+		if profile.HintIdx == nil {
+			profile.HintIdx = map[string]int{}
+		}
+		e := m.insertLocked(id, &profile, now, now)
+		// Restored sessions have no creation time on record, so the absolute
+		// lifetime restarts; idle expiry still applies from here.
+		e.dirty = false
+	}
+	m.deleted = map[string]struct{}{}
+	return nil
+}
+
+// persist writes sessions touched since the last call and removes evicted
+// ones from the store. It is a no-op unless load was called. Sessions with a
+// request in flight are skipped rather than waited for: they stay dirty and
+// are written by the next call, so a slow request never holds up a flush.
+func (m *sessionManager) persist() error {
+	m.mu.Lock()
+	s := m.store
+	if s == nil {
+		m.mu.Unlock()
+		return nil
+	}
+	live := make([]*sessionEntry, 0, len(m.entries))
+	for _, e := range m.entries {
+		live = append(live, e)
+	}
+	deleted := m.deleted
+	m.deleted = map[string]struct{}{}
+	m.mu.Unlock()
+
+	for id := range deleted {
+		if err := s.DeleteSession(id); err != nil {
+			return err
+		}
+	}
+	for _, e := range live {
+		if !e.mu.TryLock() {
+			continue
+		}
+		if !e.dirty {
+			e.mu.Unlock()
+			continue
+		}
+		err := s.PutSession(e.id, *e.profile)
+		if err == nil {
+			e.dirty = false
+		}
+		e.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withSessionLock holds the session's lock for the duration of an API
+// request and marks it dirty for persistence afterwards.
+func withSessionLock(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := r.Cookie("sid")
+		if err != nil || c.Value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		e := sessions.get(c.Value)
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.dirty = true
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ConfigureSessions replaces the session manager. When opts.Persist is set,
+// sessions saved in s are restored and later writes go back to s.
+func ConfigureSessions(opts SessionOptions, s store.Store) error {
+	m := newSessionManager(opts)
+	if opts.Persist && s != nil {
+		if err := m.load(s); err != nil {
+			return err
+		}
+		log.Printf("Restored %d anonymous sessions", m.len())
+	}
+	sessions = m
+	return nil
+}
+
+// ReapSessions evicts expired anonymous sessions.
+func ReapSessions() int {
+	return sessions.reap()
+}
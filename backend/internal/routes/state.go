@@ -6,18 +6,87 @@ import (
 
 	"avidlearner/internal/models"
 	"avidlearner/internal/lessons"
+	"avidlearner/internal/search"
+	"avidlearner/internal/store"
+	"avidlearner/internal/tracks"
 )
 
-const lessonRepeatWindow = 100
+const (
+	lessonRepeatWindow = 100
+	leaderboardLimit   = 1000
+	searchLimitDefault = 20
+	searchLimitMax     = 100
+	hintCost           = 2  // coins charged per pro challenge hint
+	reviewQuizMax      = 20 // questions in one review quiz
+	reviewDueMax       = 200
+	distractorPoolSize = 6 // similar answers kept per lesson; a question uses 3
+
+	// Timed quizzes: each question must be answered within timedQuestionLimit
+	// and the whole quiz within timedQuizPerQuestion per question, so a slow
+	// start cannot be made up by skipping ahead. timedAnswerGrace covers the
+	// request's trip to the server.
+	timedQuestionLimit   = 20 * time.Second
+	timedQuizPerQuestion = 15 * time.Second
+	timedAnswerGrace     = time.Second
+	timedMaxPoints       = 10 // for an instant correct answer; 1 at the deadline
+
+	// A typing score is credited at most the WPM the server's clock allows
+	// for the text's length. Sessions shorter than typingMinSession, or with
+	// fewer than typingMinChars correct characters, earn nothing.
+	typingMinSession = 10 * time.Second
+	typingMinChars   = 25
+
+	dailyQuizSize   = 5
+	dailyScoresKept = 30 // days of daily quiz scores kept on a profile
+
+	// Pro challenge submissions are compiled, then tested, in the sandbox.
+	// The test timeout covers only running the tests, which is what a
+	// submission controls; the build limit just stops a stuck compile. The
+	// build cache is prewarmed with up to challengePrewarmTimeout at startup
+	// and trimmed every challengeCacheTrimEvery.
+	challengeBuildTimeout   = time.Minute
+	challengeTestTimeout    = 5 * time.Second
+	challengePrewarmTimeout = 5 * time.Minute
+	challengeCacheTrimEvery = 10 * time.Minute
+
+	// Sandboxed programs all run as the same user, so every grading worker
+	// shares one RLIMIT_NPROC budget of threads. The go command, the
+	// compilers it starts and test binaries are held to challengeProcs
+	// threads of Go code and packages built at once, so a busy machine's
+	// core count does not use that budget up.
+	challengeProcs = 2
+
+	// Grading jobs are kept gradingJobTTL after they finish, with at most
+	// gradingOutputMax bytes of live output and as much again in their test
+	// report; idle event streams get a comment every gradingKeepAlive so
+	// proxies keep them open.
+	gradingJobTTL    = 10 * time.Minute
+	gradingOutputMax = 64 << 10
+	gradingKeepAlive = 15 * time.Second
+)
 
 // ---------- Globals ----------
 var (
-	lessonsByCat      map[string][]models.Lesson
-	categories        []string
-	sessions          = map[string]*models.Profile{} // sid -> profile
-	proChallenges     []models.ProChallenge
-	proChallengesByID map[string]models.ProChallenge
-	leaderboard       []models.LeaderboardEntry // in-memory leaderboard
+	// contentMu guards the lesson map and pro challenge globals below so a
+	// reload swaps them as a unit; see readsContent.
+	contentMu          sync.RWMutex
+	lessonsByCat       map[string][]models.Lesson
+	lessonsByID        map[string]models.Lesson
+	lessonQuestions    map[string][]models.Question // lesson ID -> authored questions
+	lessonsSorted      []models.Lesson              // category/title/ID order, for paging
+	lessonsHash        string                       // content hash of lessonsSorted
+	lessonIndex        *search.Index                // rebuilt with the lesson map
+	lessonDistractors  map[string][]string          // lesson ID -> wrong answers, most similar first
+	categories         []string
+	categoryTree       []lessons.CategoryNode                // categories nested by the taxonomy
+	lessonTaxonomy     *lessons.Taxonomy                     // nil: categories are used as-is
+	sessions           = newSessionManager(SessionOptions{}) // sid -> profile
+	proChallenges      []models.ProChallenge
+	proChallengesByID  map[string]models.ProChallenge
+	learningTracks     []tracks.Track
+	learningTracksByID map[string]tracks.Track
+	leaderboard        []models.LeaderboardEntry // in-memory leaderboard
+	dataStore          store.Store               // durable users/leaderboard/sessions
 
 	newsCache   = map[string]models.NewsCacheEntry{}
 	newsCacheMu sync.RWMutex
@@ -48,17 +117,30 @@ func LoadProChallenges(path string) ([]models.ProChallenge, map[string]models.Pr
 	return loadProChallenges(path)
 }
 
-func LoadLeaderboard(path string) error {
-	return loadLeaderboard(path)
+func LoadLeaderboard() error {
+	return loadLeaderboard()
+}
+
+func SetStore(s store.Store) {
+	dataStore = s
 }
 
-func SaveLeaderboard(path string) error {
-	return saveLeaderboard(path)
+// FlushStore persists touched sessions and flushes buffered store writes.
+func FlushStore() error {
+	if err := sessions.persist(); err != nil {
+		return err
+	}
+	if dataStore == nil {
+		return nil
+	}
+	return dataStore.Flush()
 }
 
 func SetProChallenges(list []models.ProChallenge, byID map[string]models.ProChallenge) {
+	contentMu.Lock()
 	proChallenges = list
 	proChallengesByID = byID
+	contentMu.Unlock()
 }
 
 func SetLeaderboard(entries []models.LeaderboardEntry) {
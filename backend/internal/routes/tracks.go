@@ -0,0 +1,332 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"avidlearner/internal/models"
+	"avidlearner/internal/tracks"
+)
+
+// SetTracks installs the learning tracks.
+func SetTracks(list []tracks.Track) {
+	byID := make(map[string]tracks.Track, len(list))
+	for _, t := range list {
+		byID[t.ID] = t
+	}
+	contentMu.Lock()
+	learningTracks = list
+	learningTracksByID = byID
+	contentMu.Unlock()
+}
+
+// LoadTracks reads the learning tracks file.
+func LoadTracks(path string) ([]tracks.Track, error) {
+	return tracks.Load(path)
+}
+
+// trackProgress copies u's progress in trackID; ok is false when u is not
+// enrolled.
+func trackProgress(u *models.User, trackID string) (models.TrackProgress, bool) {
+	usersMu.RLock()
+	defer usersMu.RUnlock()
+	tp, ok := u.Profile.Tracks[trackID]
+	if !ok {
+		return models.TrackProgress{}, false
+	}
+	completed := make(map[string]time.Time, len(tp.Completed))
+	for id, at := range tp.Completed {
+		completed[id] = at
+	}
+	tp.Completed = completed
+	return tp, true
+}
+
+// completeTrackStepLocked marks step done in u's progress through t, and the
+// track itself once every step is. Callers hold usersMu and have checked the
+// step is unlocked.
+func completeTrackStepLocked(u *models.User, t tracks.Track, stepID string, at time.Time) {
+	tp := u.Profile.Tracks[t.ID]
+	if tp.Completed == nil {
+		tp.Completed = map[string]time.Time{}
+	}
+	if _, done := tp.Completed[stepID]; done {
+		return
+	}
+	tp.Completed[stepID] = at
+	if tp.CompletedAt == nil && t.Done(tp.Completed) {
+		tp.CompletedAt = &at
+	}
+	u.Profile.Tracks[t.ID] = tp
+	u.Profile.UpdatedAt = at
+}
+
+// completeChallengeStepsLocked completes every unlocked step for challengeID
+// in the tracks u is enrolled in and returns the IDs of the tracks that
+// advanced. Callers hold usersMu; list is a copy taken before locking it.
+func completeChallengeStepsLocked(u *models.User, list []tracks.Track, challengeID string, at time.Time) []string {
+	var advanced []string
+	for _, t := range list {
+		tp, enrolled := u.Profile.Tracks[t.ID]
+		if !enrolled {
+			continue
+		}
+		for _, s := range t.Steps {
+			if s.Challenge != challengeID {
+				continue
+			}
+			if _, done := tp.Completed[s.ID]; done || len(t.Missing(s, tp.Completed)) > 0 {
+				continue
+			}
+			completeTrackStepLocked(u, t, s.ID, at)
+			tp = u.Profile.Tracks[t.ID]
+			advanced = append(advanced, t.ID)
+		}
+	}
+	return advanced
+}
+
+// trackLock is a track step keeping a lesson or challenge locked, with the
+// prerequisites still to complete.
+type trackLock struct {
+	Track   string   `json:"track"`
+	Step    string   `json:"step"`
+	Missing []string `json:"missing"`
+}
+
+// trackLocks reports what keeps the lesson or challenge ref locked for the
+// signed-in caller: its steps in the tracks they are enrolled in. It is nil,
+// and the content open, when one of those steps is available or completed,
+// when no enrolled track has it, and for anonymous callers. list is the
+// tracks as read by the caller under contentMu.
+func trackLocks(r *http.Request, list []tracks.Track, kind, ref string) []trackLock {
+	if bearerToken(r) == "" {
+		return nil
+	}
+	user, err := authUserFromRequest(r)
+	if err != nil {
+		return nil
+	}
+	usersMu.RLock()
+	defer usersMu.RUnlock()
+	var locks []trackLock
+	for _, t := range list {
+		tp, enrolled := user.Profile.Tracks[t.ID]
+		if !enrolled {
+			continue
+		}
+		for _, s := range t.Steps {
+			if s.Kind() != kind || s.Ref() != ref {
+				continue
+			}
+			if _, done := tp.Completed[s.ID]; done {
+				return nil
+			}
+			missing := t.Missing(s, tp.Completed)
+			if len(missing) == 0 {
+				return nil
+			}
+			locks = append(locks, trackLock{Track: t.ID, Step: s.ID, Missing: missing})
+		}
+	}
+	return locks
+}
+
+// writeTrackLocked refuses locked content with the steps to complete first.
+func writeTrackLocked(w http.ResponseWriter, locks []trackLock) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusForbidden)
+	_ = json.NewEncoder(w).Encode(map[string]any{"error": "locked until its track prerequisites are completed", "locked": locks})
+}
+
+func lookupTracks() []tracks.Track {
+	contentMu.RLock()
+	defer contentMu.RUnlock()
+	return learningTracks
+}
+
+type trackSummary struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Steps       int        `json:"steps"`
+	Enrolled    bool       `json:"enrolled"`
+	Completed   int        `json:"completed"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// handleTracks lists the learning tracks, with the caller's progress when
+// signed in.
+//
+//	GET /api/tracks -> { tracks: [{ id, title, description, steps, enrolled, completed, completedAt }] }
+func handleTracks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	user, _ := authUserFromRequest(r)
+
+	out := make([]trackSummary, 0, len(learningTracks))
+	for _, t := range learningTracks {
+		sum := trackSummary{ID: t.ID, Title: t.Title, Description: t.Description, Steps: len(t.Steps)}
+		if user != nil {
+			if tp, ok := trackProgress(user, t.ID); ok {
+				sum.Enrolled = true
+				sum.Completed = len(tp.Completed)
+				sum.CompletedAt = tp.CompletedAt
+			}
+		}
+		out = append(out, sum)
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"tracks": out})
+}
+
+// handleTrack serves one track.
+//
+//	GET  /api/tracks/{id}          -> { track, steps: [{ id, kind, title, status, missing, completedAt }], enrolledAt }
+//	POST /api/tracks/{id}/enroll   -> same, after enrolling (signed in; idempotent)
+//	GET  /api/tracks/{id}/next     -> { step, lesson | challenge } or { done: true } (enrolled)
+//	POST /api/tracks/{id}/complete -> body { step } marks a lesson step read (enrolled)
+//
+// Steps stay locked until the steps they require are completed. Challenge
+// steps are completed by a passing /api/prochallenge/submit, not here.
+func handleTrack(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	id, action, _ := strings.Cut(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/tracks/"), "/"), "/")
+	t, ok := learningTracksByID[id]
+	if !ok {
+		http.Error(w, `{"error":"track not found"}`, http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		var tp models.TrackProgress
+		enrolled := false
+		if user, err := authUserFromRequest(r); err == nil {
+			tp, enrolled = trackProgress(user, t.ID)
+		}
+		writeTrackState(w, t, tp, enrolled)
+
+	case action == "enroll" && r.Method == http.MethodPost:
+		user, err := requireAuthUser(w, r)
+		if err != nil {
+			return
+		}
+		updateUserByID(user.ID, func(u *models.User) {
+			if u.Profile.Tracks == nil {
+				u.Profile.Tracks = map[string]models.TrackProgress{}
+			}
+			if _, enrolled := u.Profile.Tracks[t.ID]; !enrolled {
+				now := time.Now()
+				u.Profile.Tracks[t.ID] = models.TrackProgress{EnrolledAt: now, Completed: map[string]time.Time{}}
+				u.Profile.UpdatedAt = now
+			}
+		})
+		tp, _ := trackProgress(user, t.ID)
+		writeTrackState(w, t, tp, true)
+
+	case action == "next" && r.Method == http.MethodGet:
+		_, tp, ok := requireEnrolled(w, r, t)
+		if !ok {
+			return
+		}
+		step, ok := t.Next(tp.Completed)
+		if !ok {
+			_ = json.NewEncoder(w).Encode(map[string]any{"track": t.ID, "done": true, "completedAt": tp.CompletedAt})
+			return
+		}
+		resp := map[string]any{
+			"track": t.ID,
+			"step":  withStepTitle(tracks.StepState{Step: step, Kind: step.Kind(), Status: tracks.StatusAvailable}),
+		}
+		if step.Kind() == tracks.KindChallenge {
+			if ch, ok := proChallengesByID[step.Challenge]; ok {
+				resp["challenge"] = ch
+			}
+		} else if l := findLessonByID(step.Lesson); l != nil {
+			resp["lesson"] = l
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+
+	case action == "complete" && r.Method == http.MethodPost:
+		user, tp, ok := requireEnrolled(w, r, t)
+		if !ok {
+			return
+		}
+		var body struct {
+			Step string `json:"step"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+			return
+		}
+		step, ok := t.Step(body.Step)
+		if !ok {
+			http.Error(w, `{"error":"step not found"}`, http.StatusNotFound)
+			return
+		}
+		if step.Kind() == tracks.KindChallenge {
+			http.Error(w, `{"error":"challenge steps are completed by passing the challenge"}`, http.StatusBadRequest)
+			return
+		}
+		if missing := t.Missing(step, tp.Completed); len(missing) > 0 {
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(map[string]any{"error": "step is locked", "missing": missing})
+			return
+		}
+		updateUserByID(user.ID, func(u *models.User) {
+			completeTrackStepLocked(u, t, step.ID, time.Now())
+		})
+		tp, _ = trackProgress(user, t.ID)
+		writeTrackState(w, t, tp, true)
+
+	case action == "" || action == "enroll" || action == "next" || action == "complete":
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+
+	default:
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	}
+}
+
+func requireEnrolled(w http.ResponseWriter, r *http.Request, t tracks.Track) (*models.User, models.TrackProgress, bool) {
+	user, err := requireAuthUser(w, r)
+	if err != nil {
+		return nil, models.TrackProgress{}, false
+	}
+	tp, ok := trackProgress(user, t.ID)
+	if !ok {
+		http.Error(w, `{"error":"not enrolled in this track"}`, http.StatusConflict)
+		return nil, models.TrackProgress{}, false
+	}
+	return user, tp, true
+}
+
+// withStepTitle fills in the title of the step's lesson or challenge.
+// Callers hold contentMu for reading.
+func withStepTitle(st tracks.StepState) tracks.StepState {
+	if st.Kind == tracks.KindChallenge {
+		st.Title = proChallengesByID[st.Challenge].Title
+	} else if l := findLessonByID(st.Lesson); l != nil {
+		st.Title = l.Title
+	}
+	return st
+}
+
+func writeTrackState(w http.ResponseWriter, t tracks.Track, tp models.TrackProgress, enrolled bool) {
+	steps := t.States(tp.Completed)
+	for i := range steps {
+		steps[i] = withStepTitle(steps[i])
+	}
+	resp := map[string]any{
+		"track": trackSummary{ID: t.ID, Title: t.Title, Description: t.Description, Steps: len(t.Steps), Enrolled: enrolled, Completed: len(tp.Completed), CompletedAt: tp.CompletedAt},
+		"steps": steps,
+	}
+	if enrolled {
+		resp["enrolledAt"] = tp.EnrolledAt
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
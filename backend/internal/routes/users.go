@@ -1,45 +1,37 @@
 package routes
 
 import (
-	"encoding/json"
 	"errors"
-	"os"
-	"path/filepath"
-	"sort"
+	"fmt"
+	"log"
 	"strings"
 	"sync"
 	"time"
 
 	"avidlearner/internal/models"
+	"avidlearner/internal/progress"
 )
 
 var (
 	usersMu     sync.RWMutex
 	usersByID   = map[string]*models.User{}
 	usersByName = map[string]*models.User{}
-	usersDirty  bool
-	usersPath   string
 )
 
-func LoadUsers(path string) error {
+// LoadUsers rebuilds the in-memory user index from the configured store and
+// re-derives each profile's totals from its ledger.
+func LoadUsers() error {
 	usersMu.Lock()
 	defer usersMu.Unlock()
 
-	usersPath = path
 	usersByID = map[string]*models.User{}
 	usersByName = map[string]*models.User{}
-	usersDirty = false
 
-	b, err := os.ReadFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
-		return err
+	if dataStore == nil {
+		return errors.New("store not configured")
 	}
-
-	var list []models.User
-	if err := json.Unmarshal(b, &list); err != nil {
+	list, err := dataStore.Users()
+	if err != nil {
 		return err
 	}
 
@@ -52,61 +44,22 @@ func LoadUsers(path string) error {
 		usersByID[u.ID] = u
 		usersByName[normalized] = u
 		ensureProfileDefaults(&u.Profile)
+		if err := reconcileLedgerLocked(u); err != nil {
+			return fmt.Errorf("ledger for user %s: %w", u.ID, err)
+		}
 	}
 
 	return nil
 }
 
-func SaveUsers(path string) error {
-	if strings.TrimSpace(path) == "" {
-		return errors.New("users file path not set")
-	}
-	usersMu.RLock()
-	if !usersDirty {
-		usersMu.RUnlock()
-		return nil
-	}
-
-	snapshot := make([]models.User, 0, len(usersByID))
-	for _, u := range usersByID {
-		snapshot = append(snapshot, *u)
-	}
-	usersMu.RUnlock()
-
-	sort.Slice(snapshot, func(i, j int) bool {
-		return snapshot[i].CreatedAt.Before(snapshot[j].CreatedAt)
-	})
-
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-		return err
-	}
-
-	b, err := json.MarshalIndent(snapshot, "", "  ")
-	if err != nil {
-		return err
+// persistUserLocked writes u through to the store. Callers must hold usersMu.
+func persistUserLocked(u *models.User) {
+	if dataStore == nil || u == nil {
+		return
 	}
-
-	if err := os.WriteFile(path, b, 0o644); err != nil {
-		return err
+	if err := dataStore.PutUser(*u); err != nil {
+		log.Printf("Error persisting user %s: %v", u.ID, err)
 	}
-
-	usersMu.Lock()
-	usersDirty = false
-	usersMu.Unlock()
-
-	return nil
-}
-
-func usersPathOrDefault() string {
-	usersMu.RLock()
-	defer usersMu.RUnlock()
-	return usersPath
-}
-
-func markUsersDirty() {
-	usersMu.Lock()
-	usersDirty = true
-	usersMu.Unlock()
 }
 
 func normalizeUsername(username string) string {
@@ -142,7 +95,7 @@ func addUser(u *models.User) error {
 	}
 	usersByID[u.ID] = u
 	usersByName[normalized] = u
-	usersDirty = true
+	persistUserLocked(u)
 	return nil
 }
 
@@ -157,7 +110,7 @@ func updateUserByID(id string, fn func(*models.User)) {
 		return
 	}
 	fn(u)
-	usersDirty = true
+	persistUserLocked(u)
 }
 
 func ensureProfileDefaults(profile *models.UserProfile) {
@@ -170,7 +123,11 @@ func ensureProfileDefaults(profile *models.UserProfile) {
 	if profile.SavedLessons == nil {
 		profile.SavedLessons = []models.SavedLesson{}
 	}
+	if profile.HintIdx == nil {
+		profile.HintIdx = map[string]int{}
+	}
 	if profile.UpdatedAt.IsZero() {
 		profile.UpdatedAt = time.Now()
 	}
+	profile.Level = progress.LevelFor(profile.XP)
 }
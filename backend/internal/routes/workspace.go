@@ -0,0 +1,113 @@
+package routes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"avidlearner/internal/models"
+)
+
+// challengeModule is the go.mod of every grading workspace.
+const challengeModule = "module example.com/protmp\n\ngo 1.24\n"
+
+// workspacePool keeps prepared modules for grading: a workspace holds go.mod
+// and a challenge's hidden tests, written once, so a submission only
+// replaces challenge.go and the go command finds everything else unchanged.
+//
+// The go command runs in a workspace, but test binaries only ever see it
+// read-only, so nothing a submission does reaches the next build there.
+type workspacePool struct {
+	root string
+
+	mu   sync.Mutex
+	idle map[string][]string // workspace key -> workspaces not in use
+	n    int                 // workspaces prepared, for their names
+}
+
+func newWorkspacePool(root string) (*workspacePool, error) {
+	// Workspaces are prepared afresh by every server run rather than trusting
+	// what an earlier one left behind.
+	if err := os.RemoveAll(root); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &workspacePool{root: root, idle: map[string][]string{}}, nil
+}
+
+// challengeWorkspaces is the pool runChallengeTests grades in.
+var challengeWorkspaces = sync.OnceValues(func() (*workspacePool, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	return newWorkspacePool(filepath.Join(base, "avidlearner", "sandbox-work"))
+})
+
+// acquire returns a workspace holding ch's hidden tests and source as
+// challenge.go, and release to hand it back when grading is done. A
+// workspace in use is never handed out twice: concurrent submissions to one
+// challenge get one each. Workspaces are keyed by the hidden tests' contents,
+// so editing them takes effect with the next submission.
+func (p *workspacePool) acquire(ch models.ProChallenge, source string) (dir string, release func(), err error) {
+	testSrc, err := resolveChallengeTestPath(ch.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	tests, err := readChallengeTest(testSrc)
+	if err != nil {
+		return "", nil, err
+	}
+	sum := sha256.Sum256(tests)
+	key := ch.ID + "-" + hex.EncodeToString(sum[:6])
+
+	p.mu.Lock()
+	if free := p.idle[key]; len(free) > 0 {
+		dir = free[len(free)-1]
+		p.idle[key] = free[:len(free)-1]
+	} else {
+		p.n++
+		dir = filepath.Join(p.root, key, strconv.Itoa(p.n))
+	}
+	p.mu.Unlock()
+
+	if err := p.prepare(dir, tests, source); err != nil {
+		// Whatever state it was left in, it is not reused.
+		_ = os.RemoveAll(dir)
+		return "", nil, err
+	}
+	release = func() {
+		p.mu.Lock()
+		p.idle[key] = append(p.idle[key], dir)
+		p.mu.Unlock()
+	}
+	return dir, release, nil
+}
+
+// prepare writes the workspace's files, when it is new, and the submission.
+// The previous submission's test binary is removed so a failed build cannot
+// leave it to be run.
+func (p *workspacePool) prepare(dir string, tests []byte, source string) error {
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); os.IsNotExist(err) {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, "challenge_test.go"), tests, 0o644); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(challengeModule), 0o644); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(dir, "challenge.test")); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "challenge.go"), []byte(source), 0o644)
+}
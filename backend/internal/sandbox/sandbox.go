@@ -0,0 +1,204 @@
+// Package sandbox runs untrusted programs, such as the go toolchain and test
+// binaries built from pro challenge submissions, in a separate unprivileged
+// process.
+//
+// On Linux the process gets its own user, mount, network, PID, IPC and UTS
+// namespaces: a read-only root holding only the system directories and the
+// mounts the caller asks for, a private /tmp, no network, resource limits, no
+// capabilities and, where the kernel supports it, a seccomp filter that
+// refuses sockets and namespace or mount syscalls. The setup runs in a helper:
+// the current executable re-run with a marker argument, dispatched from this
+// package's init before main or TestMain.
+//
+// Mode decides what happens when the namespaces are not available: Strict
+// refuses to run anything and Permissive falls back to resource limits (and
+// seccomp when available) without filesystem or network isolation.
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Mode is the fallback policy when the host cannot isolate a process.
+type Mode string
+
+const (
+	// Strict requires namespace isolation; Run fails with ErrUnavailable
+	// without it.
+	Strict Mode = "strict"
+	// Permissive runs without namespaces when they are unavailable, keeping
+	// only the resource limits and seccomp. For development hosts only.
+	Permissive Mode = "permissive"
+)
+
+// ParseMode reads a mode name; empty means Strict.
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(strings.ToLower(strings.TrimSpace(s))); m {
+	case "":
+		return Strict, nil
+	case Strict, Permissive:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown sandbox mode %q (want %s or %s)", s, Strict, Permissive)
+	}
+}
+
+// ErrUnavailable is returned by Run when the mode requires isolation the
+// host cannot provide.
+var ErrUnavailable = errors.New("sandbox unavailable")
+
+// Limits are the resource limits of a sandboxed process. Zero fields take
+// the DefaultLimits value.
+type Limits struct {
+	CPU       time.Duration // CPU time (RLIMIT_CPU)
+	Memory    int64         // address space in bytes (RLIMIT_AS)
+	FileSize  int64         // largest file written in bytes (RLIMIT_FSIZE)
+	Processes int           // processes and threads (RLIMIT_NPROC)
+	OpenFiles int           // open descriptors (RLIMIT_NOFILE)
+	TmpSize   int64         // size of the private /tmp in bytes
+}
+
+// DefaultLimits fit compiling and running a small Go package.
+var DefaultLimits = Limits{
+	CPU:       30 * time.Second,
+	Memory:    2 << 30,
+	FileSize:  64 << 20,
+	Processes: 256,
+	OpenFiles: 256,
+	TmpSize:   256 << 20,
+}
+
+func (l Limits) withDefaults() Limits {
+	if l.CPU <= 0 {
+		l.CPU = DefaultLimits.CPU
+	}
+	if l.Memory <= 0 {
+		l.Memory = DefaultLimits.Memory
+	}
+	if l.FileSize <= 0 {
+		l.FileSize = DefaultLimits.FileSize
+	}
+	if l.Processes <= 0 {
+		l.Processes = DefaultLimits.Processes
+	}
+	if l.OpenFiles <= 0 {
+		l.OpenFiles = DefaultLimits.OpenFiles
+	}
+	if l.TmpSize <= 0 {
+		l.TmpSize = DefaultLimits.TmpSize
+	}
+	return l
+}
+
+// Mount makes a host path visible inside the sandbox at the same path.
+type Mount struct {
+	Path     string
+	Writable bool
+}
+
+// Command is one program to run in the sandbox. Paths are absolute and are
+// the same inside and outside.
+type Command struct {
+	Path string   // program to run
+	Args []string // including Args[0]
+	Env  []string // the complete environment; nothing is inherited
+	// Dir is the working directory. It is mounted writable and handed to
+	// the sandbox user.
+	Dir string
+	// Mounts are further host paths to expose, read-only unless Writable.
+	// Writable mounts are handed to the sandbox user too.
+	Mounts []Mount
+	Limits Limits
+	// Loopback gives the program a private loopback interface and lets it
+	// open IP sockets on it, for tests that start an httptest server. It
+	// still has no route out of the sandbox.
+	Loopback bool
+	Stdout   io.Writer
+	Stderr   io.Writer
+}
+
+// Isolation is what the host supports, found by New.
+type Isolation struct {
+	Namespaces bool   // user, mount, network, PID, IPC and UTS namespaces
+	Seccomp    bool   // the syscall filter
+	Reason     string // why namespaces are unavailable
+}
+
+func (iso Isolation) String() string {
+	var parts []string
+	if iso.Namespaces {
+		parts = append(parts, "namespaces")
+	}
+	if iso.Seccomp {
+		parts = append(parts, "seccomp")
+	}
+	parts = append(parts, "rlimits")
+	s := strings.Join(parts, "+")
+	if iso.Reason != "" {
+		s += " (no namespaces: " + iso.Reason + ")"
+	}
+	return s
+}
+
+// Runner starts sandboxed commands.
+type Runner struct {
+	mode Mode
+	iso  Isolation
+}
+
+// New probes what isolation the host supports, by starting one sandboxed
+// helper, and returns a Runner applying mode to the result.
+func New(mode Mode) (*Runner, error) {
+	mode, err := ParseMode(string(mode))
+	if err != nil {
+		return nil, err
+	}
+	return &Runner{mode: mode, iso: probe()}, nil
+}
+
+// Mode is the fallback policy r was created with.
+func (r *Runner) Mode() Mode { return r.mode }
+
+// Isolation is what r found the host supports.
+func (r *Runner) Isolation() Isolation { return r.iso }
+
+// Available reports whether Run can start commands.
+func (r *Runner) Available() bool {
+	return r != nil && (r.iso.Namespaces || r.mode == Permissive)
+}
+
+// Run runs c and waits for it. The process and everything it started are
+// killed when ctx is done. A non-zero exit is an *exec.ExitError, as with
+// os/exec.
+func (r *Runner) Run(ctx context.Context, c Command) error {
+	if !r.Available() {
+		reason := "no runner"
+		if r != nil {
+			reason = r.iso.Reason
+		}
+		return fmt.Errorf("%w: %s", ErrUnavailable, reason)
+	}
+	if c.Stdout == nil {
+		c.Stdout = io.Discard
+	}
+	if c.Stderr == nil {
+		c.Stderr = io.Discard
+	}
+	c.Limits = c.Limits.withDefaults()
+	return r.run(ctx, c)
+}
+
+// helperArg marks a re-run of the current executable as the sandbox helper.
+const helperArg = "avidlearner-sandbox-helper"
+
+func init() {
+	if len(os.Args) == 3 && os.Args[1] == helperArg {
+		os.Exit(helperMain(os.Args[2]))
+	}
+}
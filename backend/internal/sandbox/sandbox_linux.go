@@ -0,0 +1,515 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// nobody is the host user sandboxed programs run as when the server runs as
+// root. Otherwise they keep the server's user, mapped to root inside the
+// user namespace but without any capabilities.
+const nobody = 65534
+
+// systemDirs are exposed read-only to every namespaced sandbox when present.
+var systemDirs = []string{"/bin", "/lib", "/lib32", "/lib64", "/libx32", "/sbin", "/usr"}
+
+// devices are bound into the sandbox's private /dev.
+var devices = []string{"/dev/null", "/dev/zero", "/dev/random", "/dev/urandom"}
+
+const probeTimeout = 10 * time.Second
+
+// spec is what the helper is asked to do; it is passed as its argument.
+type spec struct {
+	Path     string
+	Args     []string
+	Env      []string
+	Dir      string
+	Root     string // empty directory to build the new root on; "" without namespaces
+	Mounts   []Mount
+	Limits   Limits
+	UID, GID int // user to switch to before exec, or -1
+	Loopback bool
+	Seccomp  bool
+	// Check sets everything up, then reports on stdout and exits instead of
+	// running Path.
+	Check bool
+}
+
+// checkReport is what a Check helper prints.
+type checkReport struct {
+	Seccomp bool `json:"seccomp"`
+}
+
+// probe starts Check helpers: one with namespaces and, when that fails, one
+// without to see whether seccomp still works.
+func probe() Isolation {
+	var iso Isolation
+	rep, err := check(true)
+	if err == nil {
+		iso.Namespaces, iso.Seccomp = true, rep.Seccomp
+		return iso
+	}
+	iso.Reason = err.Error()
+	if rep, err := check(false); err == nil {
+		iso.Seccomp = rep.Seccomp
+	}
+	return iso
+}
+
+func check(namespaces bool) (checkReport, error) {
+	var rep checkReport
+	dir, err := os.MkdirTemp("", "avid-sandbox-probe-*")
+	if err != nil {
+		return rep, err
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	var out, errOut bytes.Buffer
+	c := Command{Dir: dir, Limits: DefaultLimits, Stdout: &out, Stderr: &errOut}
+	if err := start(ctx, c, namespaces, seccompSupported, true); err != nil {
+		if msg := strings.TrimSpace(errOut.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return rep, err
+	}
+	if err := json.Unmarshal(out.Bytes(), &rep); err != nil {
+		return rep, fmt.Errorf("read probe report: %w", err)
+	}
+	return rep, nil
+}
+
+func (r *Runner) run(ctx context.Context, c Command) error {
+	return start(ctx, c, r.iso.Namespaces, r.iso.Seccomp, false)
+}
+
+// start runs the helper for c and waits for it. The helper reports a setup
+// failure on a pipe that closes when it execs c.Path, so a failed setup is
+// told apart from c failing.
+func start(ctx context.Context, c Command, namespaces, seccomp, check bool) error {
+	if !filepath.IsAbs(c.Dir) || (!check && !filepath.IsAbs(c.Path)) {
+		return fmt.Errorf("sandbox paths must be absolute: %q in %q", c.Path, c.Dir)
+	}
+	s := spec{
+		Path:     c.Path,
+		Args:     c.Args,
+		Env:      c.Env,
+		Dir:      c.Dir,
+		Limits:   c.Limits,
+		UID:      -1,
+		GID:      -1,
+		Loopback: c.Loopback,
+		Seccomp:  seccomp,
+		Check:    check,
+	}
+	writable := []string{c.Dir}
+	for _, m := range c.Mounts {
+		if !filepath.IsAbs(m.Path) {
+			return fmt.Errorf("sandbox mount must be absolute: %q", m.Path)
+		}
+		if m.Writable {
+			writable = append(writable, m.Path)
+		}
+	}
+
+	attr := &syscall.SysProcAttr{Pdeathsig: syscall.SIGKILL}
+	uid, gid := os.Getuid(), os.Getgid()
+	if uid == 0 {
+		s.UID, s.GID = nobody, nobody
+		for _, dir := range writable {
+			if err := os.Chown(dir, nobody, nobody); err != nil {
+				return fmt.Errorf("hand %s to the sandbox user: %w", dir, err)
+			}
+		}
+	}
+	if namespaces {
+		root, err := os.MkdirTemp("", "avid-sandbox-root-*")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(root)
+		s.Root = root
+		for _, dir := range systemDirs {
+			if _, err := os.Lstat(dir); err == nil {
+				s.Mounts = append(s.Mounts, Mount{Path: dir})
+			}
+		}
+		s.Mounts = append(s.Mounts, c.Mounts...)
+		s.Mounts = append(s.Mounts, Mount{Path: c.Dir, Writable: true})
+
+		attr.Cloneflags = syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWNET |
+			syscall.CLONE_NEWPID | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS
+		if uid == 0 {
+			// Root inside stays root outside while the helper builds the
+			// mounts; it then switches to nobody, mapped to itself.
+			ids := []syscall.SysProcIDMap{{ContainerID: 0, HostID: 0, Size: 1}, {ContainerID: nobody, HostID: nobody, Size: 1}}
+			attr.UidMappings, attr.GidMappings = ids, ids
+			attr.GidMappingsEnableSetgroups = true
+		} else {
+			attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: uid, Size: 1}}
+			attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: gid, Size: 1}}
+		}
+	} else {
+		attr.Setpgid = true
+	}
+
+	arg, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	statusR, statusW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer statusR.Close()
+
+	cmd := exec.CommandContext(ctx, "/proc/self/exe", helperArg, string(arg))
+	cmd.Env = []string{}
+	cmd.Stdout, cmd.Stderr = c.Stdout, c.Stderr
+	cmd.ExtraFiles = []*os.File{statusW}
+	cmd.SysProcAttr = attr
+	cmd.WaitDelay = time.Second
+	if !namespaces {
+		// Without a PID namespace, kill the process group so nothing the
+		// program started outlives it.
+		cmd.Cancel = func() error { return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) }
+	}
+	err = cmd.Start()
+	statusW.Close()
+	if err != nil {
+		return fmt.Errorf("start sandbox: %w", err)
+	}
+	msg, _ := io.ReadAll(statusR)
+	err = cmd.Wait()
+	if len(msg) > 0 {
+		return fmt.Errorf("sandbox setup: %s", msg)
+	}
+	return err
+}
+
+// helperMain runs in the re-executed helper: it builds the sandbox around
+// itself, then execs the program. Everything runs on one locked thread
+// because capabilities, the user switch and seccomp are per thread and must
+// hold on the thread that calls exec.
+func helperMain(arg string) int {
+	runtime.LockOSThread()
+	status := os.NewFile(3, "sandbox-status")
+	fail := func(err error) int {
+		fmt.Fprint(status, err)
+		return 126
+	}
+	syscall.CloseOnExec(3)
+
+	var s spec
+	if err := json.Unmarshal([]byte(arg), &s); err != nil {
+		return fail(fmt.Errorf("bad spec: %w", err))
+	}
+	if s.Root != "" {
+		if err := s.enterRoot(); err != nil {
+			return fail(err)
+		}
+	}
+	if err := syscall.Chdir(s.Dir); err != nil {
+		return fail(fmt.Errorf("chdir %s: %w", s.Dir, err))
+	}
+	if err := s.limit(); err != nil {
+		return fail(err)
+	}
+	if err := s.dropPrivileges(); err != nil {
+		return fail(err)
+	}
+	var rep checkReport
+	if s.Seccomp {
+		err := installSeccomp(s.Loopback)
+		if err != nil && !s.Check {
+			return fail(fmt.Errorf("seccomp: %w", err))
+		}
+		rep.Seccomp = err == nil
+	}
+	if s.Check {
+		if err := json.NewEncoder(os.Stdout).Encode(rep); err != nil {
+			return fail(err)
+		}
+		return 0
+	}
+	err := syscall.Exec(s.Path, s.Args, s.Env)
+	return fail(fmt.Errorf("exec %s: %w", s.Path, err))
+}
+
+// enterRoot builds the new root on a tmpfs at s.Root and pivots into it:
+// the system directories and mounts bound in read-only unless writable,
+// private /tmp and /dev, and the root itself read-only.
+func (s *spec) enterRoot() error {
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+	root := s.Root
+	if err := mountTmpfs(root, "mode=0755,size=1m", 0); err != nil {
+		return err
+	}
+	if err := mountTmpfs(filepath.Join(root, "tmp"), fmt.Sprintf("mode=1777,size=%d", s.Limits.TmpSize), 0); err != nil {
+		return err
+	}
+	if err := mountTmpfs(filepath.Join(root, "dev"), "mode=0755,size=64k", syscall.MS_NOEXEC); err != nil {
+		return err
+	}
+	for _, dev := range devices {
+		if _, err := os.Stat(dev); err != nil {
+			continue
+		}
+		if err := bindMount(dev, filepath.Join(root, dev), true, true); err != nil {
+			return err
+		}
+	}
+	// /proc is best effort: it cannot be mounted where the host hides parts
+	// of its own, and neither the go command nor test binaries need it.
+	proc := filepath.Join(root, "proc")
+	if err := os.Mkdir(proc, 0o555); err == nil {
+		_ = syscall.Mount("proc", proc, "proc", syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC, "")
+	}
+
+	// Parents before children, so a mount inside another lands on top.
+	mounts := slices.Clone(s.Mounts)
+	slices.SortStableFunc(mounts, func(a, b Mount) int { return strings.Compare(a.Path, b.Path) })
+	for _, m := range mounts {
+		if err := bindMount(m.Path, filepath.Join(root, m.Path), m.Writable, false); err != nil {
+			return err
+		}
+	}
+
+	if err := syscall.Chdir(root); err != nil {
+		return err
+	}
+	if err := syscall.PivotRoot(".", "."); err != nil {
+		return fmt.Errorf("pivot_root: %w", err)
+	}
+	if err := syscall.Unmount(".", syscall.MNT_DETACH); err != nil {
+		return fmt.Errorf("detach old root: %w", err)
+	}
+	if err := syscall.Chdir("/"); err != nil {
+		return err
+	}
+	if err := syscall.Mount("", "/", "", syscall.MS_REMOUNT|syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NODEV, ""); err != nil {
+		return fmt.Errorf("remount root read-only: %w", err)
+	}
+	_ = syscall.Sethostname([]byte("sandbox"))
+	if s.Loopback {
+		if err := loopbackUp(); err != nil {
+			return fmt.Errorf("bring up loopback: %w", err)
+		}
+	}
+	return nil
+}
+
+func mountTmpfs(dir, opts string, flags uintptr) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if err := syscall.Mount("tmpfs", dir, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV|flags, opts); err != nil {
+		return fmt.Errorf("mount tmpfs on %s: %w", dir, err)
+	}
+	return nil
+}
+
+// bindMount binds src at dst. A symlink, such as /bin on merged-/usr
+// systems, is recreated instead. The flags the host locks on src's mount
+// are kept, since a user namespace may not clear them.
+func bindMount(src, dst string, writable, device bool) error {
+	fi, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	}
+	if fi.IsDir() {
+		err = os.MkdirAll(dst, 0o755)
+	} else if _, err = os.Stat(dst); errors.Is(err, os.ErrNotExist) {
+		err = os.WriteFile(dst, nil, 0o644)
+	}
+	if err != nil {
+		return err
+	}
+	if err := syscall.Mount(src, dst, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+		return fmt.Errorf("bind %s: %w", src, err)
+	}
+
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dst, &st); err != nil {
+		return err
+	}
+	flags := uintptr(syscall.MS_BIND | syscall.MS_REMOUNT | syscall.MS_NOSUID)
+	for stFlag, msFlag := range map[int64]uintptr{
+		stRdonly:     syscall.MS_RDONLY,
+		stNodev:      syscall.MS_NODEV,
+		stNoexec:     syscall.MS_NOEXEC,
+		stNoatime:    syscall.MS_NOATIME,
+		stNodiratime: syscall.MS_NODIRATIME,
+		stRelatime:   syscall.MS_RELATIME,
+	} {
+		if int64(st.Flags)&stFlag != 0 {
+			flags |= msFlag
+		}
+	}
+	if !device {
+		flags |= syscall.MS_NODEV
+	}
+	if !writable {
+		flags |= syscall.MS_RDONLY
+	}
+	if err := syscall.Mount("", dst, "", flags, ""); err != nil {
+		return fmt.Errorf("remount %s: %w", src, err)
+	}
+	return nil
+}
+
+// statfs(2) mount flags.
+const (
+	stRdonly     = 0x1
+	stNodev      = 0x4
+	stNoexec     = 0x8
+	stNoatime    = 0x400
+	stNodiratime = 0x800
+	stRelatime   = 0x1000
+)
+
+// loopbackUp brings up lo in the sandbox's network namespace.
+func loopbackUp() error {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(fd)
+	var ifr [40]byte // struct ifreq: name, then flags
+	copy(ifr[:], "lo")
+	if err := ioctl(fd, syscall.SIOCGIFFLAGS, unsafe.Pointer(&ifr)); err != nil {
+		return err
+	}
+	flags := binary.NativeEndian.Uint16(ifr[syscall.IFNAMSIZ:]) | syscall.IFF_UP
+	binary.NativeEndian.PutUint16(ifr[syscall.IFNAMSIZ:], flags)
+	return ioctl(fd, syscall.SIOCSIFFLAGS, unsafe.Pointer(&ifr))
+}
+
+func ioctl(fd int, req uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), req, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+const rlimitNproc = 6 // RLIMIT_NPROC; missing from package syscall
+
+// limit sets the resource limits. A limit never rises above the hard limit
+// the helper inherited.
+func (s *spec) limit() error {
+	l := s.Limits
+	for _, rl := range []struct {
+		name  string
+		res   int
+		value uint64
+	}{
+		{"cpu", syscall.RLIMIT_CPU, uint64(max(l.CPU/time.Second, 1))},
+		{"memory", syscall.RLIMIT_AS, uint64(l.Memory)},
+		{"file size", syscall.RLIMIT_FSIZE, uint64(l.FileSize)},
+		{"processes", rlimitNproc, uint64(l.Processes)},
+		{"open files", syscall.RLIMIT_NOFILE, uint64(l.OpenFiles)},
+		{"core", syscall.RLIMIT_CORE, 0},
+	} {
+		var cur syscall.Rlimit
+		if err := syscall.Getrlimit(rl.res, &cur); err != nil {
+			return fmt.Errorf("get %s limit: %w", rl.name, err)
+		}
+		v := min(rl.value, cur.Max)
+		if err := syscall.Setrlimit(rl.res, &syscall.Rlimit{Cur: v, Max: v}); err != nil {
+			return fmt.Errorf("set %s limit: %w", rl.name, err)
+		}
+	}
+	return nil
+}
+
+// prctl(2) options and securebits.
+const (
+	prSetPdeathsig        = 1
+	prSetSeccomp          = 22
+	prCapbsetDrop         = 24
+	prSetSecurebits       = 28
+	prSetNoNewPrivs       = 38
+	prCapAmbient          = 47
+	prCapAmbientClearAll  = 4
+	secbitNoroot          = 1 << 0
+	secbitNorootLocked    = 1 << 1
+	secbitKeepCapsLocked  = 1 << 5
+	seccompModeFilter     = 2
+	lastCapabilityToCheck = 63
+)
+
+// dropPrivileges empties the capability sets for good, switches to s.UID
+// when set and forbids gaining privileges through exec.
+func (s *spec) dropPrivileges() error {
+	if s.Root != "" || syscall.Getuid() == 0 {
+		// With SECBIT_NOROOT a uid 0 program gains no capabilities by exec.
+		if err := prctl(prSetSecurebits, secbitNoroot|secbitNorootLocked|secbitKeepCapsLocked); err != nil {
+			return fmt.Errorf("set securebits: %w", err)
+		}
+		for c := uintptr(0); c <= lastCapabilityToCheck; c++ {
+			if err := prctl(prCapbsetDrop, c); errors.Is(err, syscall.EINVAL) {
+				break
+			} else if err != nil {
+				return fmt.Errorf("drop capability %d: %w", c, err)
+			}
+		}
+		if err := prctl(prCapAmbient, prCapAmbientClearAll); err != nil && !errors.Is(err, syscall.EINVAL) {
+			return fmt.Errorf("clear ambient capabilities: %w", err)
+		}
+	}
+	if s.UID >= 0 {
+		// Raw syscalls change only this thread, which is the one that execs.
+		if _, _, errno := syscall.RawSyscall(syscall.SYS_SETGROUPS, 0, 0, 0); errno != 0 {
+			return fmt.Errorf("setgroups: %w", errno)
+		}
+		if _, _, errno := syscall.RawSyscall(syscall.SYS_SETRESGID, uintptr(s.GID), uintptr(s.GID), uintptr(s.GID)); errno != 0 {
+			return fmt.Errorf("setresgid: %w", errno)
+		}
+		if _, _, errno := syscall.RawSyscall(syscall.SYS_SETRESUID, uintptr(s.UID), uintptr(s.UID), uintptr(s.UID)); errno != 0 {
+			return fmt.Errorf("setresuid: %w", errno)
+		}
+	}
+	if err := prctl(prSetNoNewPrivs, 1); err != nil {
+		return fmt.Errorf("set no_new_privs: %w", err)
+	}
+	// Changing user clears the parent-death signal.
+	return prctl(prSetPdeathsig, uintptr(syscall.SIGKILL))
+}
+
+func prctl(option uintptr, args ...uintptr) error {
+	var a [4]uintptr
+	copy(a[:], args)
+	if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, option, a[0], a[1], a[2], a[3], 0); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// namespacedRunner skips the test when the host cannot create namespaces,
+// as in some containers.
+func namespacedRunner(t *testing.T) *Runner {
+	t.Helper()
+	r, err := New(Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Isolation().Namespaces {
+		t.Skipf("no namespace isolation on this host: %s", r.Isolation().Reason)
+	}
+	t.Logf("isolation: %s", r.Isolation())
+	return r
+}
+
+// buildEscape compiles testdata/escape.go into a fresh work directory.
+func buildEscape(t *testing.T) (dir, bin string) {
+	t.Helper()
+	dir = t.TempDir()
+	bin = filepath.Join(dir, "escape")
+	src, err := filepath.Abs(filepath.Join("testdata", "escape.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("go", "build", "-o", bin, src)
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=0")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("build escape: %v\n%s", err, out)
+	}
+	return dir, bin
+}
+
+func runEscape(t *testing.T, r *Runner, c Command, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	c.Args = append([]string{c.Path}, args...)
+	c.Stdout, c.Stderr = &out, &out
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	err := r.Run(ctx, c)
+	return strings.TrimSpace(out.String()), err
+}
+
+func TestSandboxCannotReadHostFiles(t *testing.T) {
+	r := namespacedRunner(t)
+	dir, bin := buildEscape(t)
+
+	users := filepath.Join(t.TempDir(), "users.json")
+	if err := os.WriteFile(users, []byte(`[{"username":"admin","passwordHash":"secret"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	source, err := filepath.Abs("sandbox.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Command{Path: bin, Dir: dir}
+	for _, path := range []string{users, source, "/etc/passwd"} {
+		if out, err := runEscape(t, r, c, "read", path); err == nil {
+			t.Errorf("read %s from the sandbox: %s", path, out)
+		}
+	}
+	if out, err := runEscape(t, r, c, "write", "/usr/escape"); err == nil {
+		t.Errorf("wrote outside the work directory: %s", out)
+	}
+	if out, err := runEscape(t, r, c, "write", filepath.Join(dir, "result.txt")); err != nil {
+		t.Fatalf("write in the work directory: %v: %s", err, out)
+	}
+	if out, err := runEscape(t, r, c, "read", filepath.Join(dir, "result.txt")); err != nil {
+		t.Fatalf("read back from the work directory: %v: %s", err, out)
+	}
+}
+
+func TestSandboxCannotOpenSockets(t *testing.T) {
+	r := namespacedRunner(t)
+	dir, bin := buildEscape(t)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			c.Close()
+		}
+	}()
+
+	c := Command{Path: bin, Dir: dir}
+	if out, err := runEscape(t, r, c, "dial", l.Addr().String()); err == nil {
+		t.Fatalf("dialled the host from the sandbox: %s", out)
+	} else if r.Isolation().Seccomp && !strings.Contains(out, "socket") {
+		t.Errorf("expected socket() to be refused, got %q", out)
+	}
+	if out, err := runEscape(t, r, c, "listen"); err == nil {
+		t.Fatalf("opened a socket without loopback: %s", out)
+	}
+
+	// Loopback allows a test server inside, but still no way out.
+	c.Loopback = true
+	if out, err := runEscape(t, r, c, "listen"); err != nil {
+		t.Fatalf("loopback listen: %v: %s", err, out)
+	}
+	if out, err := runEscape(t, r, c, "dial", l.Addr().String()); err == nil {
+		t.Fatalf("dialled the host with loopback: %s", out)
+	}
+}
+
+func TestSandboxLimitsCPU(t *testing.T) {
+	r := namespacedRunner(t)
+	dir, bin := buildEscape(t)
+
+	start := time.Now()
+	_, err := runEscape(t, r, Command{Path: bin, Dir: dir, Limits: Limits{CPU: time.Second}}, "spin")
+	var exit *exec.ExitError
+	if !errors.As(err, &exit) {
+		t.Fatalf("expected the spinning program to be killed, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("CPU limit took %v to apply", elapsed)
+	}
+}
@@ -0,0 +1,32 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+func probe() Isolation {
+	return Isolation{Reason: "namespaces need Linux"}
+}
+
+// run starts c as a plain child process: Permissive mode on a platform
+// without namespaces keeps only the timeout and the scrubbed environment.
+func (r *Runner) run(ctx context.Context, c Command) error {
+	cmd := exec.CommandContext(ctx, c.Path)
+	cmd.Args = c.Args
+	cmd.Env = append([]string{}, c.Env...)
+	cmd.Dir = c.Dir
+	cmd.Stdout, cmd.Stderr = c.Stdout, c.Stderr
+	cmd.WaitDelay = time.Second
+	return cmd.Run()
+}
+
+func helperMain(string) int {
+	fmt.Fprintln(os.Stderr, "sandbox helper needs Linux")
+	return 126
+}
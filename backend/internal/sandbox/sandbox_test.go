@@ -0,0 +1,34 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseMode(t *testing.T) {
+	for in, want := range map[string]Mode{"": Strict, "strict": Strict, " Permissive ": Permissive} {
+		if got, err := ParseMode(in); err != nil || got != want {
+			t.Errorf("ParseMode(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseMode("off"); err == nil {
+		t.Error("expected an unknown mode to be rejected")
+	}
+}
+
+func TestStrictModeRefusesWithoutIsolation(t *testing.T) {
+	r := &Runner{mode: Strict, iso: Isolation{Reason: "no user namespaces"}}
+	if r.Available() {
+		t.Fatal("strict runner without namespaces reports available")
+	}
+	err := r.Run(context.Background(), Command{Path: "/bin/true", Args: []string{"true"}, Dir: t.TempDir()})
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable, got %v", err)
+	}
+
+	var none *Runner
+	if err := none.Run(context.Background(), Command{}); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable from a nil runner, got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"errors"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// seccomp return actions and struct seccomp_data offsets.
+const (
+	seccompRetKillProcess = 0x80000000
+	seccompRetErrno       = 0x00050000
+	seccompRetAllow       = 0x7fff0000
+
+	seccompDataNr   = 0
+	seccompDataArch = 4
+	seccompDataArg0 = 16 // low half of args[0]; amd64 and arm64 are little-endian
+)
+
+// cloneNamespaces are the clone flags that create namespaces.
+const cloneNamespaces = syscall.CLONE_NEWNS | syscall.CLONE_NEWUTS | syscall.CLONE_NEWIPC |
+	syscall.CLONE_NEWUSER | syscall.CLONE_NEWPID | syscall.CLONE_NEWNET | syscall.CLONE_NEWCGROUP
+
+// installSeccomp filters the syscalls of this thread and everything it
+// execs. It must run after no_new_privs is set.
+func installSeccomp(loopback bool) error {
+	if !seccompSupported {
+		return errors.New("no syscall table for " + runtime.GOARCH)
+	}
+	filter := seccompFilter(loopback)
+	prog := syscall.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	return prctl(prSetSeccomp, seccompModeFilter, uintptr(unsafe.Pointer(&prog)))
+}
+
+// seccompFilter is the BPF program: kill on a foreign architecture, refuse
+// the syscalls in deniedSyscalls, clone with namespace flags and clone3 (so
+// callers fall back to clone), and sockets other than AF_UNIX, or AF_INET and
+// AF_INET6 too with loopback. Everything else is allowed.
+func seccompFilter(loopback bool) []syscall.SockFilter {
+	stmt := func(code uint16, k uint32) syscall.SockFilter {
+		return syscall.SockFilter{Code: code, K: k}
+	}
+	jump := func(code uint16, k uint32, jt, jf uint8) syscall.SockFilter {
+		return syscall.SockFilter{Code: code, Jt: jt, Jf: jf, K: k}
+	}
+	deny := func(errno syscall.Errno) syscall.SockFilter {
+		return stmt(syscall.BPF_RET|syscall.BPF_K, seccompRetErrno|uint32(errno))
+	}
+	allow := stmt(syscall.BPF_RET|syscall.BPF_K, seccompRetAllow)
+	const (
+		load = syscall.BPF_LD | syscall.BPF_W | syscall.BPF_ABS
+		jeq  = syscall.BPF_JMP | syscall.BPF_JEQ | syscall.BPF_K
+		jge  = syscall.BPF_JMP | syscall.BPF_JGE | syscall.BPF_K
+		jset = syscall.BPF_JMP | syscall.BPF_JSET | syscall.BPF_K
+	)
+
+	f := []syscall.SockFilter{
+		stmt(load, seccompDataArch),
+		jump(jeq, auditArch, 1, 0),
+		stmt(syscall.BPF_RET|syscall.BPF_K, seccompRetKillProcess),
+		stmt(load, seccompDataNr),
+	}
+	if x32SyscallBit != 0 {
+		f = append(f, jump(jge, x32SyscallBit, 0, 1), deny(syscall.ENOSYS))
+	}
+	for _, nr := range deniedSyscalls {
+		f = append(f, jump(jeq, nr, 0, 1), deny(syscall.EPERM))
+	}
+	f = append(f, jump(jeq, sysClone3, 0, 1), deny(syscall.ENOSYS))
+	f = append(f,
+		jump(jeq, sysClone, 0, 4),
+		stmt(load, seccompDataArg0),
+		jump(jset, cloneNamespaces, 0, 1),
+		deny(syscall.EPERM),
+		allow,
+	)
+
+	families := []uint32{syscall.AF_UNIX}
+	if loopback {
+		families = append(families, syscall.AF_INET, syscall.AF_INET6)
+	}
+	n := len(families)
+	f = append(f, jump(jeq, sysSocket, 0, uint8(n+2)), stmt(load, seccompDataArg0))
+	for i, family := range families {
+		f = append(f, jump(jeq, family, uint8(n-i), 0))
+	}
+	return append(f, deny(syscall.EPERM), allow)
+}
@@ -0,0 +1,54 @@
+package sandbox
+
+const (
+	seccompSupported = true
+	auditArch        = 0xc000003e // AUDIT_ARCH_X86_64
+	x32SyscallBit    = 0x40000000
+
+	sysSocket = 41
+	sysClone  = 56
+	sysClone3 = 435
+)
+
+// deniedSyscalls change mounts, namespaces, the kernel or other processes,
+// or reach kernel interfaces a test never needs.
+var deniedSyscalls = []uint32{
+	101, // ptrace
+	155, // pivot_root
+	161, // chroot
+	163, // acct
+	164, // settimeofday
+	165, // mount
+	166, // umount2
+	167, // swapon
+	168, // swapoff
+	169, // reboot
+	175, // init_module
+	176, // delete_module
+	227, // clock_settime
+	246, // kexec_load
+	248, // add_key
+	249, // request_key
+	250, // keyctl
+	272, // unshare
+	298, // perf_event_open
+	303, // name_to_handle_at
+	304, // open_by_handle_at
+	308, // setns
+	310, // process_vm_readv
+	311, // process_vm_writev
+	313, // finit_module
+	320, // kexec_file_load
+	321, // bpf
+	323, // userfaultfd
+	425, // io_uring_setup
+	426, // io_uring_enter
+	427, // io_uring_register
+	428, // open_tree
+	429, // move_mount
+	430, // fsopen
+	431, // fsconfig
+	432, // fsmount
+	433, // fspick
+	442, // mount_setattr
+}
@@ -0,0 +1,54 @@
+package sandbox
+
+const (
+	seccompSupported = true
+	auditArch        = 0xc00000b7 // AUDIT_ARCH_AARCH64
+	x32SyscallBit    = 0
+
+	sysSocket = 198
+	sysClone  = 220
+	sysClone3 = 435
+)
+
+// deniedSyscalls change mounts, namespaces, the kernel or other processes,
+// or reach kernel interfaces a test never needs.
+var deniedSyscalls = []uint32{
+	39,  // umount2
+	40,  // mount
+	41,  // pivot_root
+	51,  // chroot
+	89,  // acct
+	97,  // unshare
+	104, // kexec_load
+	105, // init_module
+	106, // delete_module
+	112, // clock_settime
+	117, // ptrace
+	142, // reboot
+	170, // settimeofday
+	217, // add_key
+	218, // request_key
+	219, // keyctl
+	224, // swapon
+	225, // swapoff
+	241, // perf_event_open
+	264, // name_to_handle_at
+	265, // open_by_handle_at
+	268, // setns
+	270, // process_vm_readv
+	271, // process_vm_writev
+	273, // finit_module
+	280, // bpf
+	282, // userfaultfd
+	294, // kexec_file_load
+	425, // io_uring_setup
+	426, // io_uring_enter
+	427, // io_uring_register
+	428, // open_tree
+	429, // move_mount
+	430, // fsopen
+	431, // fsconfig
+	432, // fsmount
+	433, // fspick
+	442, // mount_setattr
+}
@@ -0,0 +1,17 @@
+//go:build linux && !amd64 && !arm64
+
+package sandbox
+
+// There is no syscall table for this architecture, so the sandbox runs
+// without the seccomp filter.
+const (
+	seccompSupported = false
+	auditArch        = 0
+	x32SyscallBit    = 0
+
+	sysSocket = 0
+	sysClone  = 0
+	sysClone3 = 0
+)
+
+var deniedSyscalls []uint32
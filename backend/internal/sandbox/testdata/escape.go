@@ -0,0 +1,44 @@
+// escape tries one thing a sandboxed submission must not be able to do, or
+// a harmless one it must, and prints "ok" or the error.
+//
+//	escape read PATH | write PATH | dial ADDR | listen | spin
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+func main() {
+	var err error
+	switch os.Args[1] {
+	case "read":
+		_, err = os.ReadFile(os.Args[2])
+	case "write":
+		err = os.WriteFile(os.Args[2], []byte("x"), 0o644)
+	case "dial":
+		var c net.Conn
+		if c, err = net.DialTimeout("tcp", os.Args[2], time.Second); err == nil {
+			c.Close()
+		}
+	case "listen":
+		var l net.Listener
+		if l, err = net.Listen("tcp", "127.0.0.1:0"); err == nil {
+			var c net.Conn
+			if c, err = net.Dial("tcp", l.Addr().String()); err == nil {
+				c.Close()
+			}
+			l.Close()
+		}
+	case "spin":
+		for {
+		}
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Println("ok")
+}
@@ -0,0 +1,156 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"avidlearner/internal/models"
+)
+
+const (
+	// sameCategoryBonus is added to the TF-IDF cosine of lessons in the same
+	// category, so they are always preferred over lexically close lessons
+	// from elsewhere.
+	sameCategoryBonus = 1.0
+	// nearDuplicateJaccard is the term overlap at which two answers are too
+	// alike to appear as separate options.
+	nearDuplicateJaccard = 0.6
+)
+
+// AnswerText is the text a generated question uses as a lesson's correct
+// answer: its explanation, or its text when that is empty.
+func AnswerText(l models.Lesson) string {
+	if s := strings.TrimSpace(l.Explain); s != "" {
+		return s
+	}
+	return strings.TrimSpace(l.Text)
+}
+
+// DistractorPools returns, for every lesson ID, up to size answers of other
+// lessons that make plausible wrong options: same-category lessons first,
+// then by TF-IDF cosine over title, text and explanation. Answers that are
+// near-duplicates of the lesson's own answer, or of an answer already in the
+// pool, are skipped.
+func DistractorPools(lessons []models.Lesson, size int) map[string][]string {
+	n := len(lessons)
+	answers := make([]string, n)
+	answerTerms := make([]map[string]struct{}, n)
+	tfs := make([]map[string]float64, n)
+	df := map[string]int{}
+	for i, l := range lessons {
+		answers[i] = AnswerText(l)
+		answerTerms[i] = termSet(answers[i])
+		tf := map[string]float64{}
+		for _, t := range tokenize(l.Title + " " + l.Text + " " + l.Explain) {
+			tf[t]++
+		}
+		for t := range tf {
+			df[t]++
+		}
+		tfs[i] = tf
+	}
+	vecs := make([]map[string]float64, n)
+	for i, tf := range tfs {
+		v := make(map[string]float64, len(tf))
+		var norm float64
+		for t, c := range tf {
+			w := (1 + math.Log(c)) * math.Log(float64(n+1)/float64(df[t]))
+			v[t] = w
+			norm += w * w
+		}
+		if norm > 0 {
+			norm = math.Sqrt(norm)
+			for t := range v {
+				v[t] /= norm
+			}
+		}
+		vecs[i] = v
+	}
+
+	type candidate struct {
+		doc   int
+		score float64
+	}
+	pools := make(map[string][]string, n)
+	cands := make([]candidate, 0, n)
+	for i, l := range lessons {
+		if answers[i] == "" {
+			continue
+		}
+		cands = cands[:0]
+		for j, m := range lessons {
+			if j == i || answers[j] == "" {
+				continue
+			}
+			score := cosine(vecs[i], vecs[j])
+			if m.Category == l.Category {
+				score += sameCategoryBonus
+			}
+			cands = append(cands, candidate{j, score})
+		}
+		sort.Slice(cands, func(a, b int) bool {
+			if cands[a].score != cands[b].score {
+				return cands[a].score > cands[b].score
+			}
+			return cands[a].doc < cands[b].doc
+		})
+
+		var pool []string
+		picked := []int{i}
+		for _, c := range cands {
+			if len(pool) == size {
+				break
+			}
+			dup := false
+			for _, p := range picked {
+				if nearDuplicate(answers[p], answers[c.doc], answerTerms[p], answerTerms[c.doc]) {
+					dup = true
+					break
+				}
+			}
+			if !dup {
+				pool = append(pool, answers[c.doc])
+				picked = append(picked, c.doc)
+			}
+		}
+		pools[l.ID] = pool
+	}
+	return pools
+}
+
+func termSet(text string) map[string]struct{} {
+	set := map[string]struct{}{}
+	for _, t := range tokenize(text) {
+		set[t] = struct{}{}
+	}
+	return set
+}
+
+// nearDuplicate compares two answers by the Jaccard overlap of their terms.
+func nearDuplicate(a, b string, at, bt map[string]struct{}) bool {
+	if strings.EqualFold(a, b) {
+		return true
+	}
+	if len(at) == 0 || len(bt) == 0 {
+		return false
+	}
+	shared := 0
+	for t := range at {
+		if _, ok := bt[t]; ok {
+			shared++
+		}
+	}
+	return float64(shared)/float64(len(at)+len(bt)-shared) >= nearDuplicateJaccard
+}
+
+func cosine(a, b map[string]float64) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	var dot float64
+	for t, w := range a {
+		dot += w * b[t]
+	}
+	return dot
+}
@@ -0,0 +1,61 @@
+package search
+
+import (
+	"testing"
+
+	"avidlearner/internal/models"
+)
+
+func TestDistractorPoolsPreferSimilarLessons(t *testing.T) {
+	lessons := []models.Lesson{
+		{ID: "pods", Title: "Pod Disruption Budgets", Category: "kubernetes",
+			Text: "Limit how many pods a voluntary disruption can evict.", Explain: "A PDB keeps a minimum number of pods available during node drains."},
+		{ID: "probes", Title: "Readiness Probes", Category: "kubernetes",
+			Text: "Readiness probes gate traffic to pods.", Explain: "A failing readiness probe removes the pod from service endpoints."},
+		{ID: "hpa", Title: "Horizontal Pod Autoscaler", Category: "kubernetes",
+			Text: "Scale pods on metrics.", Explain: "The HPA adjusts replica counts from CPU or custom metrics."},
+		{ID: "pdb-copy", Title: "PDB Basics", Category: "kubernetes",
+			Text: "Budgets for disruptions.", Explain: "A PDB keeps a minimum number of pods available during drains."},
+		{ID: "drain", Title: "Node Drains", Category: "devops",
+			Text: "Drain nodes before maintenance so pods are evicted gracefully.", Explain: "kubectl drain cordons the node and evicts its pods."},
+		{ID: "naming", Title: "Clean Code: Naming", Category: "clean-code",
+			Text: "Names should reveal intent.", Explain: "Prefer precise names over comments."},
+		{ID: "empty", Title: "No Answer", Category: "kubernetes"},
+	}
+
+	pools := DistractorPools(lessons, 3)
+	got := pools["pods"]
+	if len(got) != 3 {
+		t.Fatalf("expected 3 distractors, got %q", got)
+	}
+	// Same-category lessons first, then the lexically close devops lesson;
+	// never the near-duplicate PDB answer or the unrelated clean-code one.
+	sameCategory := map[string]bool{lessons[1].Explain: true, lessons[2].Explain: true}
+	if !sameCategory[got[0]] || !sameCategory[got[1]] || got[0] == got[1] {
+		t.Fatalf("expected the other kubernetes answers first, got %q", got)
+	}
+	if got[2] != lessons[4].Explain {
+		t.Fatalf("expected the node drain answer next, got %q", got)
+	}
+	if _, ok := pools["empty"]; ok {
+		t.Error("a lesson without an answer should have no pool")
+	}
+	for _, d := range pools["probes"] {
+		if d == "" {
+			t.Error("empty answers should never be offered")
+		}
+	}
+}
+
+func TestDistractorPoolsSkipNearDuplicates(t *testing.T) {
+	lessons := []models.Lesson{
+		{ID: "a", Title: "Retries", Category: "reliability", Explain: "Retry with exponential backoff and jitter."},
+		{ID: "b", Title: "Backoff", Category: "reliability", Explain: "Retry with exponential backoff plus jitter."},
+		{ID: "c", Title: "Timeouts", Category: "reliability", Explain: "Bound every remote call with a deadline."},
+		{ID: "d", Title: "Deadlines", Category: "reliability", Explain: "Bound every remote call with a deadline!"},
+	}
+	pools := DistractorPools(lessons, 5)
+	if got := pools["a"]; len(got) != 1 || got[0] != lessons[2].Explain {
+		t.Fatalf("expected the near-duplicate answer and the repeated distractor skipped, got %q", got)
+	}
+}
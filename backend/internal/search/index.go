@@ -0,0 +1,348 @@
+// Package search provides an in-memory full-text index over lessons with
+// BM25 ranking, highlighted snippets and category/source facets, and
+// TF-IDF similarity for picking quiz distractors.
+package search
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"avidlearner/internal/models"
+)
+
+// BM25 parameters.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	// prefixWeight discounts matches where a query term is only a prefix of
+	// the indexed term, so "idempot" finds "idempotency" but exact hits rank
+	// first.
+	prefixWeight  = 0.5
+	minPrefixLen  = 3
+	snippetRadius = 80
+)
+
+// Field weights: a hit in the title counts for more than one in the tips.
+var fieldWeights = [...]float64{
+	fieldTitle:    3,
+	fieldText:     1.5,
+	fieldExplain:  1,
+	fieldUseCases: 0.75,
+	fieldTips:     0.75,
+}
+
+const (
+	fieldTitle = iota
+	fieldText
+	fieldExplain
+	fieldUseCases
+	fieldTips
+	numFields
+)
+
+var stopwords = map[string]struct{}{
+	"a": {}, "an": {}, "and": {}, "are": {}, "as": {}, "at": {}, "be": {}, "by": {},
+	"for": {}, "from": {}, "how": {}, "in": {}, "is": {}, "it": {}, "of": {}, "on": {},
+	"or": {}, "that": {}, "the": {}, "this": {}, "to": {}, "was": {}, "what": {},
+	"when": {}, "with": {}, "you": {}, "your": {},
+}
+
+type posting struct {
+	doc int
+	tf  float64 // field-weighted term frequency
+}
+
+// Index is an immutable inverted index. Build a new one when lessons change.
+type Index struct {
+	docs     []models.Lesson
+	lengths  []float64 // field-weighted document lengths
+	avgLen   float64
+	postings map[string][]posting
+	terms    []string // sorted, for prefix lookups
+}
+
+// Query narrows a search.
+type Query struct {
+	Text     string
+	Category string // optional exact filter
+	Source   string // optional exact filter
+	Limit    int    // <= 0 means no limit
+}
+
+// Hit is one ranked result.
+type Hit struct {
+	Lesson  models.Lesson `json:"lesson"`
+	Score   float64       `json:"score"`
+	Title   string        `json:"title"`   // HTML-escaped, matches wrapped in <mark>
+	Snippet string        `json:"snippet"` // HTML-escaped, matches wrapped in <mark>
+}
+
+// Result is a page of hits plus facet counts over every match.
+type Result struct {
+	Total  int                       `json:"total"`
+	Hits   []Hit                     `json:"results"`
+	Facets map[string]map[string]int `json:"facets"`
+}
+
+// NewIndex indexes title, text, explain, use cases and tips of every lesson.
+func NewIndex(lessons []models.Lesson) *Index {
+	idx := &Index{
+		docs:     lessons,
+		lengths:  make([]float64, len(lessons)),
+		postings: map[string][]posting{},
+	}
+	var total float64
+	for i, l := range lessons {
+		freqs := map[string]float64{}
+		for field, text := range lessonFields(l) {
+			for _, tok := range tokenize(text) {
+				freqs[tok] += fieldWeights[field]
+				idx.lengths[i] += fieldWeights[field]
+			}
+		}
+		for term, tf := range freqs {
+			idx.postings[term] = append(idx.postings[term], posting{doc: i, tf: tf})
+		}
+		total += idx.lengths[i]
+	}
+	if len(lessons) > 0 {
+		idx.avgLen = total / float64(len(lessons))
+	}
+	idx.terms = make([]string, 0, len(idx.postings))
+	for term := range idx.postings {
+		idx.terms = append(idx.terms, term)
+	}
+	sort.Strings(idx.terms)
+	return idx
+}
+
+// Len reports how many lessons are indexed.
+func (idx *Index) Len() int {
+	if idx == nil {
+		return 0
+	}
+	return len(idx.docs)
+}
+
+// Search ranks lessons matching any query term with BM25.
+func (idx *Index) Search(q Query) Result {
+	res := Result{Facets: map[string]map[string]int{"category": {}, "source": {}}}
+	if idx == nil || len(idx.docs) == 0 {
+		return res
+	}
+	terms := uniqueTerms(tokenize(q.Text))
+	if len(terms) == 0 {
+		return res
+	}
+
+	scores := map[int]float64{}
+	n := float64(len(idx.docs))
+	for _, term := range terms {
+		for expanded, weight := range idx.expand(term) {
+			list := idx.postings[expanded]
+			df := float64(len(list))
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			for _, p := range list {
+				norm := 1 - bm25B + bm25B*idx.lengths[p.doc]/idx.avgLen
+				scores[p.doc] += weight * idf * p.tf * (bm25K1 + 1) / (p.tf + bm25K1*norm)
+			}
+		}
+	}
+
+	hits := make([]int, 0, len(scores))
+	for doc := range scores {
+		l := idx.docs[doc]
+		res.Facets["category"][l.Category]++
+		res.Facets["source"][sourceOrLocal(l.Source)]++
+		if q.Category != "" && !strings.EqualFold(l.Category, q.Category) {
+			continue
+		}
+		if q.Source != "" && !strings.EqualFold(sourceOrLocal(l.Source), q.Source) {
+			continue
+		}
+		hits = append(hits, doc)
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if scores[hits[i]] != scores[hits[j]] {
+			return scores[hits[i]] > scores[hits[j]]
+		}
+		return idx.docs[hits[i]].Title < idx.docs[hits[j]].Title
+	})
+
+	res.Total = len(hits)
+	if q.Limit > 0 && len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+	res.Hits = make([]Hit, 0, len(hits))
+	for _, doc := range hits {
+		l := idx.docs[doc]
+		res.Hits = append(res.Hits, Hit{
+			Lesson:  l,
+			Score:   math.Round(scores[doc]*1000) / 1000,
+			Title:   highlight(l.Title, terms),
+			Snippet: snippet(l, terms),
+		})
+	}
+	return res
+}
+
+// expand maps a query term to the indexed terms it matches and their weight.
+func (idx *Index) expand(term string) map[string]float64 {
+	out := map[string]float64{}
+	if _, ok := idx.postings[term]; ok {
+		out[term] = 1
+	}
+	if len(term) < minPrefixLen {
+		return out
+	}
+	i := sort.SearchStrings(idx.terms, term)
+	for ; i < len(idx.terms) && strings.HasPrefix(idx.terms[i], term); i++ {
+		if idx.terms[i] != term {
+			out[idx.terms[i]] = prefixWeight
+		}
+	}
+	return out
+}
+
+func lessonFields(l models.Lesson) [numFields]string {
+	return [numFields]string{
+		fieldTitle:    l.Title,
+		fieldText:     l.Text,
+		fieldExplain:  l.Explain,
+		fieldUseCases: strings.Join(l.UseCases, " "),
+		fieldTips:     strings.Join(l.Tips, " "),
+	}
+}
+
+func sourceOrLocal(source string) string {
+	if source == "" {
+		return "local"
+	}
+	return source
+}
+
+// tokenize lowercases text and splits it on anything that is not a letter or
+// digit, dropping stopwords.
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := fields[:0]
+	for _, f := range fields {
+		if _, stop := stopwords[f]; stop {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+func uniqueTerms(terms []string) []string {
+	seen := map[string]struct{}{}
+	out := terms[:0]
+	for _, t := range terms {
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		out = append(out, t)
+	}
+	return out
+}
+
+// snippet returns a window of the lesson's text (or explanation) around the
+// first query match, falling back to the start of the text.
+func snippet(l models.Lesson, terms []string) string {
+	for _, text := range []string{l.Text, l.Explain, strings.Join(l.UseCases, " · "), strings.Join(l.Tips, " · ")} {
+		if start, _, ok := firstMatch(text, terms); ok {
+			return highlight(window(text, start), terms)
+		}
+	}
+	return highlight(window(l.Text, 0), terms)
+}
+
+// window cuts text to about snippetRadius bytes either side of pos, on word
+// boundaries, adding ellipses where it was cut.
+func window(text string, pos int) string {
+	from := max(pos-snippetRadius, 0)
+	to := min(pos+snippetRadius, len(text))
+	if from > 0 {
+		if i := strings.IndexByte(text[from:pos], ' '); i >= 0 {
+			from += i + 1
+		}
+	}
+	if to < len(text) {
+		if i := strings.LastIndexByte(text[pos:to], ' '); i > 0 {
+			to = pos + i
+		}
+	}
+	for from > 0 && from < len(text) && !utf8.RuneStart(text[from]) {
+		from++
+	}
+	for to < len(text) && to > from && !utf8.RuneStart(text[to]) {
+		to--
+	}
+	out := text[from:to]
+	if from > 0 {
+		out = "…" + out
+	}
+	if to < len(text) {
+		out += "…"
+	}
+	return out
+}
+
+// firstMatch finds the earliest word in text that a query term matches,
+// exactly or as a prefix.
+func firstMatch(text string, terms []string) (start, end int, ok bool) {
+	start = -1
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if inWord && start < 0 {
+			start = i
+		}
+		if !inWord && start >= 0 {
+			if matchesTerm(text[start:i], terms) {
+				return start, i, true
+			}
+			start = -1
+		}
+	}
+	if start >= 0 && matchesTerm(text[start:], terms) {
+		return start, len(text), true
+	}
+	return 0, 0, false
+}
+
+func matchesTerm(word string, terms []string) bool {
+	word = strings.ToLower(word)
+	for _, t := range terms {
+		if word == t || (len(t) >= minPrefixLen && strings.HasPrefix(word, t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// highlight HTML-escapes text and wraps every matching word in <mark>.
+func highlight(text string, terms []string) string {
+	var b strings.Builder
+	for len(text) > 0 {
+		start, end, ok := firstMatch(text, terms)
+		if !ok {
+			b.WriteString(html.EscapeString(text))
+			break
+		}
+		b.WriteString(html.EscapeString(text[:start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[start:end]))
+		b.WriteString("</mark>")
+		text = text[end:]
+	}
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package search
+
+import (
+	"strings"
+	"testing"
+
+	"avidlearner/internal/models"
+)
+
+var testLessons = []models.Lesson{
+	{ID: "1", Title: "Idempotency Keys", Category: "api-design", Source: "local",
+		Text: "Clients send a unique key so retried requests are applied once.", Tips: []string{"Store keys with a TTL"}},
+	{ID: "2", Title: "Retries with Backoff", Category: "resilience", Source: "local",
+		Text: "Retry failed calls with exponential backoff and jitter.", Explain: "Only retry idempotent operations."},
+	{ID: "3", Title: "Circuit Breaker", Category: "resilience", Source: "devto",
+		Text: "Stop calling a failing dependency until it recovers."},
+}
+
+func TestSearchRanksTitleMatchesFirst(t *testing.T) {
+	res := NewIndex(testLessons).Search(Query{Text: "idempotency"})
+	if res.Total != 1 || res.Hits[0].Lesson.ID != "1" {
+		t.Fatalf("expected only the idempotency lesson, got %+v", res.Hits)
+	}
+	if !strings.Contains(res.Hits[0].Title, "<mark>Idempotency</mark>") {
+		t.Errorf("expected highlighted title, got %q", res.Hits[0].Title)
+	}
+}
+
+func TestSearchPrefixMatching(t *testing.T) {
+	res := NewIndex(testLessons).Search(Query{Text: "idempot"})
+	if res.Total != 2 {
+		t.Fatalf("expected prefix to match idempotency and idempotent, got %d", res.Total)
+	}
+	if res.Hits[0].Lesson.ID != "1" {
+		t.Errorf("expected title match to rank first, got %s", res.Hits[0].Lesson.Title)
+	}
+	if !strings.Contains(res.Hits[1].Snippet, "<mark>idempotent</mark>") {
+		t.Errorf("expected snippet from the explanation, got %q", res.Hits[1].Snippet)
+	}
+}
+
+func TestSearchFacetsAndFilters(t *testing.T) {
+	idx := NewIndex(testLessons)
+	res := idx.Search(Query{Text: "retry failing", Category: "resilience", Limit: 1})
+	if res.Total != 2 || len(res.Hits) != 1 {
+		t.Fatalf("expected 2 filtered matches and 1 hit, got total=%d hits=%d", res.Total, len(res.Hits))
+	}
+	if res.Facets["category"]["resilience"] != 2 || res.Facets["source"]["devto"] != 1 {
+		t.Errorf("unexpected facets %v", res.Facets)
+	}
+
+	res = idx.Search(Query{Text: "retry failing", Source: "devto"})
+	if res.Total != 1 || res.Hits[0].Lesson.ID != "3" {
+		t.Fatalf("expected source filter to keep the devto lesson, got %+v", res.Hits)
+	}
+}
+
+func TestSearchEscapesHTML(t *testing.T) {
+	idx := NewIndex([]models.Lesson{{ID: "x", Title: "<script> tags", Text: "Escape <b>markup</b> in markup."}})
+	res := idx.Search(Query{Text: "markup"})
+	if res.Total != 1 {
+		t.Fatalf("expected a match, got %d", res.Total)
+	}
+	if strings.Contains(res.Hits[0].Snippet, "<b>") || !strings.Contains(res.Hits[0].Snippet, "&lt;b&gt;<mark>markup</mark>") {
+		t.Errorf("expected escaped snippet, got %q", res.Hits[0].Snippet)
+	}
+}
+
+func TestSearchEmptyQuery(t *testing.T) {
+	if res := NewIndex(testLessons).Search(Query{Text: "the of"}); res.Total != 0 {
+		t.Errorf("stopword-only query should match nothing, got %d", res.Total)
+	}
+	var idx *Index
+	if res := idx.Search(Query{Text: "anything"}); res.Total != 0 {
+		t.Errorf("nil index should match nothing")
+	}
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// backupGenerations is how many previous versions of a snapshot file are kept
+// as <path>.1 (newest) through <path>.N (oldest).
+const backupGenerations = 3
+
+// writeFileAtomic replaces path with data without ever exposing a partially
+// written file: data goes to a synced temp file in the same directory which is
+// then renamed over path. The previous contents are rotated into the backup
+// generations first.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, 0o644); err != nil {
+		return err
+	}
+
+	if err := rotateBackups(path); err != nil {
+		return fmt.Errorf("rotate backups: %w", err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// rotateBackups shifts <path>.1..N-1 up one generation and copies the current
+// file to <path>.1. The live file is copied rather than moved so it is never
+// missing if we crash before the new snapshot is renamed into place.
+func rotateBackups(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for i := backupGenerations - 1; i >= 1; i-- {
+		from := backupPath(path, i)
+		if _, err := os.Stat(from); err != nil {
+			continue
+		}
+		if err := os.Rename(from, backupPath(path, i+1)); err != nil {
+			return err
+		}
+	}
+	return copyFile(path, backupPath(path, 1))
+}
+
+func backupPath(path string, generation int) string {
+	return fmt.Sprintf("%s.%d", path, generation)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// syncDir flushes directory metadata so a completed rename survives a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	// Some platforms (notably Windows) cannot fsync directories; the rename
+	// itself has already succeeded, so a failure here is not fatal.
+	_ = d.Sync()
+	return nil
+}
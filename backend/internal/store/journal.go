@@ -0,0 +1,125 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"avidlearner/internal/models"
+)
+
+const (
+	opPutUser        = "putUser"
+	opAddLeaderboard = "addLeaderboard"
+	opLedger         = "ledger"
+	opQuizAttempt    = "quiz_attempt"
+)
+
+// journalRecord is one mutation appended to a write-ahead journal.
+type journalRecord struct {
+	Op     string                   `json:"op"`
+	User   *models.User             `json:"user,omitempty"`
+	Entry  *models.LeaderboardEntry `json:"entry,omitempty"`
+	Limit  int                      `json:"limit,omitempty"`
+	Ledger *models.LedgerEntry      `json:"ledger,omitempty"`
+	Quiz   *models.QuizAttempt      `json:"quiz,omitempty"`
+}
+
+// journal is an append-only, fsynced log of mutations made since the last
+// snapshot. It is replayed on load and truncated once a snapshot is written.
+type journal struct {
+	path string
+	f    *os.File
+}
+
+func newJournal(snapshotPath string) *journal {
+	return &journal{path: snapshotPath + ".journal"}
+}
+
+func (j *journal) append(rec journalRecord) error {
+	if j.f == nil {
+		f, err := os.OpenFile(j.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		j.f = f
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := j.f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	return j.f.Sync()
+}
+
+// truncate discards all records; called after a snapshot has been written.
+func (j *journal) truncate() error {
+	if j.f != nil {
+		if err := j.f.Close(); err != nil {
+			return err
+		}
+		j.f = nil
+	}
+	if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (j *journal) close() error {
+	if j.f == nil {
+		return nil
+	}
+	err := j.f.Close()
+	j.f = nil
+	return err
+}
+
+// records reads every journaled mutation. A torn final line from a crash
+// mid-append is dropped; corruption anywhere else is an error.
+func (j *journal) records() ([]journalRecord, error) {
+	b, err := os.ReadFile(j.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var (
+		out  []journalRecord
+		line int
+	)
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line++
+		raw := bytes.TrimSpace(sc.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		var rec journalRecord
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			if !bytes.HasSuffix(b, []byte("\n")) && isLastLine(b, line) {
+				log.Printf("Dropping torn record at %s:%d", j.path, line)
+				// Cut the partial line so later appends start on a fresh one.
+				if err := os.Truncate(j.path, int64(bytes.LastIndexByte(b, '\n')+1)); err != nil {
+					return nil, err
+				}
+				break
+			}
+			return nil, fmt.Errorf("%w: %s:%d: %v", ErrCorrupt, j.path, line, err)
+		}
+		out = append(out, rec)
+	}
+	return out, sc.Err()
+}
+
+func isLastLine(b []byte, line int) bool {
+	return bytes.Count(b, []byte("\n"))+1 == line
+}
@@ -0,0 +1,556 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"avidlearner/internal/models"
+)
+
+// JSONStore keeps state in memory and writes whole JSON files on Flush. It
+// mirrors the original users.json/leaderboard.json layout so existing data
+// directories keep working.
+//
+// Snapshots are written atomically with rotating backups, and every user or
+// leaderboard mutation is appended to a journal next to its snapshot so that
+// changes made between flushes survive a crash.
+type JSONStore struct {
+	mu              sync.Mutex
+	usersPath       string
+	leaderboardPath string
+	sessionsPath    string
+	ledgerPath      string
+	recoverCorrupt  bool
+
+	users       map[string]storedUser
+	leaderboard []models.LeaderboardEntry
+	sessions    map[string]json.RawMessage
+
+	usersJournal       *journal
+	leaderboardJournal *journal
+
+	// The ledger is its own append-only log and is never compacted.
+	ledgerLog    *journal
+	ledger       map[string][]models.LedgerEntry
+	ledgerSeq    int64
+	ledgerLoaded bool
+
+	// Quiz attempts are an append-only log too.
+	attemptsPath   string
+	attemptsLog    *journal
+	attempts       map[string][]models.QuizAttempt
+	attemptSeq     int64
+	attemptsLoaded bool
+
+	usersDirty       bool
+	leaderboardDirty bool
+	sessionsDirty    bool
+
+	// Set when a snapshot failed to load; Flush then refuses to replace it.
+	usersBroken       error
+	leaderboardBroken error
+}
+
+// storedUser holds a user already encoded at PutUser time so later in-place
+// mutations by the caller cannot race with Flush.
+type storedUser struct {
+	createdAt time.Time
+	raw       json.RawMessage
+}
+
+// NewJSONStore creates a store backed by the given files. An empty
+// sessionsPath disables session persistence. The ledger and quiz attempts
+// are kept in ledger.jsonl and quiz_attempts.jsonl next to the users file.
+func NewJSONStore(usersPath, leaderboardPath, sessionsPath string) *JSONStore {
+	return &JSONStore{
+		usersPath:          usersPath,
+		leaderboardPath:    leaderboardPath,
+		sessionsPath:       sessionsPath,
+		ledgerPath:         filepath.Join(filepath.Dir(usersPath), "ledger.jsonl"),
+		attemptsPath:       filepath.Join(filepath.Dir(usersPath), "quiz_attempts.jsonl"),
+		users:              map[string]storedUser{},
+		sessions:           map[string]json.RawMessage{},
+		usersJournal:       newJournal(usersPath),
+		leaderboardJournal: newJournal(leaderboardPath),
+	}
+}
+
+func (s *JSONStore) Users() ([]models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []models.User
+	if err := s.loadSnapshot(s.usersPath, &list); err != nil {
+		s.usersBroken = err
+		return nil, err
+	}
+	records, err := s.usersJournal.records()
+	if err != nil {
+		s.usersBroken = err
+		return nil, err
+	}
+	s.usersBroken = nil
+
+	byID := make(map[string]models.User, len(list))
+	for _, u := range list {
+		byID[u.ID] = u
+	}
+	replayed := 0
+	for _, rec := range records {
+		if rec.Op == opPutUser && rec.User != nil {
+			byID[rec.User.ID] = *rec.User
+			replayed++
+		}
+	}
+	if replayed > 0 {
+		log.Printf("Replayed %d journaled user updates from %s", replayed, s.usersJournal.path)
+	}
+
+	list = list[:0]
+	s.users = make(map[string]storedUser, len(byID))
+	for _, u := range byID {
+		if err := s.putUserLocked(u); err != nil {
+			return nil, err
+		}
+		list = append(list, u)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	s.usersDirty = replayed > 0
+	return list, nil
+}
+
+func (s *JSONStore) PutUser(u models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.usersJournal.append(journalRecord{Op: opPutUser, User: &u}); err != nil {
+		return fmt.Errorf("journal user: %w", err)
+	}
+	if err := s.putUserLocked(u); err != nil {
+		return err
+	}
+	s.usersDirty = true
+	return nil
+}
+
+func (s *JSONStore) putUserLocked(u models.User) error {
+	raw, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	s.users[u.ID] = storedUser{createdAt: u.CreatedAt, raw: raw}
+	return nil
+}
+
+func (s *JSONStore) Leaderboard() ([]models.LeaderboardEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []models.LeaderboardEntry
+	if err := s.loadSnapshot(s.leaderboardPath, &entries); err != nil {
+		s.leaderboardBroken = err
+		return nil, err
+	}
+	records, err := s.leaderboardJournal.records()
+	if err != nil {
+		s.leaderboardBroken = err
+		return nil, err
+	}
+	s.leaderboardBroken = nil
+
+	replayed := 0
+	for _, rec := range records {
+		if rec.Op != opAddLeaderboard || rec.Entry == nil {
+			continue
+		}
+		// A crash after the snapshot was written but before the journal was
+		// truncated leaves entries that are already in the snapshot.
+		if containsEntry(entries, *rec.Entry) {
+			continue
+		}
+		entries = trimLeaderboard(append(entries, *rec.Entry), rec.Limit)
+		replayed++
+	}
+
+	s.leaderboard = entries
+	s.leaderboardDirty = replayed > 0
+	return append([]models.LeaderboardEntry(nil), entries...), nil
+}
+
+func (s *JSONStore) AddLeaderboardEntry(e models.LeaderboardEntry, limit int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.leaderboardJournal.append(journalRecord{Op: opAddLeaderboard, Entry: &e, Limit: limit}); err != nil {
+		return fmt.Errorf("journal leaderboard entry: %w", err)
+	}
+	s.leaderboard = trimLeaderboard(append(s.leaderboard, e), limit)
+	s.leaderboardDirty = true
+	return nil
+}
+
+func (s *JSONStore) AppendLedger(e models.LedgerEntry) (models.LedgerEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLedgerLocked(); err != nil {
+		return e, err
+	}
+	e.Seq = s.ledgerSeq + 1
+	if err := s.ledgerLog.append(journalRecord{Op: opLedger, Ledger: &e}); err != nil {
+		return e, fmt.Errorf("append ledger: %w", err)
+	}
+	s.ledgerSeq = e.Seq
+	s.ledger[e.UserID] = append(s.ledger[e.UserID], e)
+	return e, nil
+}
+
+func (s *JSONStore) Ledger(userID string, beforeSeq int64, limit int) ([]models.LedgerEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLedgerLocked(); err != nil {
+		return nil, err
+	}
+	entries := s.ledger[userID]
+	var out []models.LedgerEntry
+	for i := len(entries) - 1; i >= 0; i-- {
+		if beforeSeq > 0 && entries[i].Seq >= beforeSeq {
+			continue
+		}
+		out = append(out, entries[i])
+		if limit > 0 && len(out) == limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (s *JSONStore) loadLedgerLocked() error {
+	if s.ledgerLoaded {
+		return nil
+	}
+	if strings.TrimSpace(s.ledgerPath) == "" {
+		return errors.New("ledger path not set")
+	}
+	if err := os.MkdirAll(filepath.Dir(s.ledgerPath), 0o755); err != nil {
+		return err
+	}
+	s.ledgerLog = &journal{path: s.ledgerPath}
+	records, err := s.ledgerLog.records()
+	if err != nil {
+		return err
+	}
+	s.ledger = map[string][]models.LedgerEntry{}
+	for _, rec := range records {
+		if rec.Op != opLedger || rec.Ledger == nil {
+			continue
+		}
+		e := *rec.Ledger
+		s.ledger[e.UserID] = append(s.ledger[e.UserID], e)
+		if e.Seq > s.ledgerSeq {
+			s.ledgerSeq = e.Seq
+		}
+	}
+	s.ledgerLoaded = true
+	return nil
+}
+
+func (s *JSONStore) AppendQuizAttempt(a models.QuizAttempt) (models.QuizAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadAttemptsLocked(); err != nil {
+		return a, err
+	}
+	a.Seq = s.attemptSeq + 1
+	if err := s.attemptsLog.append(journalRecord{Op: opQuizAttempt, Quiz: &a}); err != nil {
+		return a, fmt.Errorf("append quiz attempt: %w", err)
+	}
+	s.attemptSeq = a.Seq
+	s.attempts[a.UserID] = append(s.attempts[a.UserID], a)
+	return a, nil
+}
+
+func (s *JSONStore) QuizAttempts(userID string, beforeSeq int64, limit int) ([]models.QuizAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadAttemptsLocked(); err != nil {
+		return nil, err
+	}
+	attempts := s.attempts[userID]
+	var out []models.QuizAttempt
+	for i := len(attempts) - 1; i >= 0; i-- {
+		if beforeSeq > 0 && attempts[i].Seq >= beforeSeq {
+			continue
+		}
+		out = append(out, attempts[i])
+		if limit > 0 && len(out) == limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (s *JSONStore) QuizAttempt(userID string, seq int64) (models.QuizAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadAttemptsLocked(); err != nil {
+		return models.QuizAttempt{}, err
+	}
+	for _, a := range s.attempts[userID] {
+		if a.Seq == seq {
+			return a, nil
+		}
+	}
+	return models.QuizAttempt{}, ErrNotFound
+}
+
+func (s *JSONStore) loadAttemptsLocked() error {
+	if s.attemptsLoaded {
+		return nil
+	}
+	if strings.TrimSpace(s.attemptsPath) == "" {
+		return errors.New("quiz attempts path not set")
+	}
+	if err := os.MkdirAll(filepath.Dir(s.attemptsPath), 0o755); err != nil {
+		return err
+	}
+	s.attemptsLog = &journal{path: s.attemptsPath}
+	records, err := s.attemptsLog.records()
+	if err != nil {
+		return err
+	}
+	s.attempts = map[string][]models.QuizAttempt{}
+	for _, rec := range records {
+		if rec.Op != opQuizAttempt || rec.Quiz == nil {
+			continue
+		}
+		a := *rec.Quiz
+		s.attempts[a.UserID] = append(s.attempts[a.UserID], a)
+		if a.Seq > s.attemptSeq {
+			s.attemptSeq = a.Seq
+		}
+	}
+	s.attemptsLoaded = true
+	return nil
+}
+
+func (s *JSONStore) Sessions() (map[string]models.Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := map[string]models.Profile{}
+	if s.sessionsPath == "" {
+		return out, nil
+	}
+	raw := map[string]json.RawMessage{}
+	if err := readJSONFile(s.sessionsPath, &raw); err != nil {
+		return nil, err
+	}
+	for id, b := range raw {
+		var p models.Profile
+		if err := json.Unmarshal(b, &p); err != nil {
+			return nil, err
+		}
+		out[id] = p
+	}
+	s.sessions = raw
+	s.sessionsDirty = false
+	return out, nil
+}
+
+func (s *JSONStore) PutSession(id string, p models.Profile) error {
+	if s.sessionsPath == "" {
+		return nil
+	}
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[id] = raw
+	s.sessionsDirty = true
+	return nil
+}
+
+func (s *JSONStore) DeleteSession(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[id]; ok {
+		delete(s.sessions, id)
+		s.sessionsDirty = true
+	}
+	return nil
+}
+
+func (s *JSONStore) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.usersDirty {
+		if s.usersBroken != nil {
+			return fmt.Errorf("refusing to overwrite %s: %w", s.usersPath, s.usersBroken)
+		}
+		list := make([]storedUser, 0, len(s.users))
+		for _, u := range s.users {
+			list = append(list, u)
+		}
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].createdAt.Before(list[j].createdAt)
+		})
+		raws := make([]json.RawMessage, len(list))
+		for i, u := range list {
+			raws[i] = u.raw
+		}
+		if err := writeJSONFile(s.usersPath, raws); err != nil {
+			return err
+		}
+		if err := s.usersJournal.truncate(); err != nil {
+			return err
+		}
+		s.usersDirty = false
+	}
+
+	if s.leaderboardDirty {
+		if s.leaderboardBroken != nil {
+			return fmt.Errorf("refusing to overwrite %s: %w", s.leaderboardPath, s.leaderboardBroken)
+		}
+		if err := writeJSONFile(s.leaderboardPath, s.leaderboard); err != nil {
+			return err
+		}
+		if err := s.leaderboardJournal.truncate(); err != nil {
+			return err
+		}
+		s.leaderboardDirty = false
+	}
+
+	if s.sessionsDirty && s.sessionsPath != "" {
+		if err := writeJSONFile(s.sessionsPath, s.sessions); err != nil {
+			return err
+		}
+		s.sessionsDirty = false
+	}
+	return nil
+}
+
+func (s *JSONStore) Close() error {
+	err := s.Flush()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cerr := s.usersJournal.close(); err == nil {
+		err = cerr
+	}
+	if cerr := s.leaderboardJournal.close(); err == nil {
+		err = cerr
+	}
+	if s.ledgerLog != nil {
+		if cerr := s.ledgerLog.close(); err == nil {
+			err = cerr
+		}
+	}
+	if s.attemptsLog != nil {
+		if cerr := s.attemptsLog.close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// loadSnapshot decodes path into v. A file that exists but cannot be decoded
+// yields ErrCorrupt unless recovery is enabled, in which case the corrupt file
+// is moved aside and the newest readable backup generation is used instead.
+func (s *JSONStore) loadSnapshot(path string, v any) error {
+	err := readJSONFile(path, v)
+	if err == nil || !errors.Is(err, ErrCorrupt) {
+		return err
+	}
+	if !s.recoverCorrupt {
+		return err
+	}
+
+	aside := fmt.Sprintf("%s.corrupt-%s", path, time.Now().UTC().Format("20060102T150405Z"))
+	if rerr := os.Rename(path, aside); rerr != nil {
+		return fmt.Errorf("move corrupt file aside: %w", rerr)
+	}
+	log.Printf("Recovery: moved corrupt %s to %s", path, aside)
+
+	for gen := 1; gen <= backupGenerations; gen++ {
+		candidate := backupPath(path, gen)
+		if _, statErr := os.Stat(candidate); statErr != nil {
+			continue
+		}
+		resetValue(v)
+		if berr := readJSONFile(candidate, v); berr == nil {
+			log.Printf("Recovery: restored %s from %s", path, candidate)
+			return nil
+		}
+	}
+	resetValue(v)
+	log.Printf("Recovery: no readable backup for %s, starting empty", path)
+	return nil
+}
+
+// resetValue zeroes the value v points to, discarding any partial decode.
+func resetValue(v any) {
+	rv := reflect.ValueOf(v).Elem()
+	rv.Set(reflect.Zero(rv.Type()))
+}
+
+func readJSONFile(path string, v any) error {
+	if strings.TrimSpace(path) == "" {
+		return errors.New("file path not set")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrCorrupt, path, err)
+	}
+	return nil
+}
+
+func writeJSONFile(path string, v any) error {
+	if strings.TrimSpace(path) == "" {
+		return errors.New("file path not set")
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, b)
+}
+
+func containsEntry(entries []models.LeaderboardEntry, e models.LeaderboardEntry) bool {
+	for _, cur := range entries {
+		if cur.Name == e.Name && cur.Score == e.Score && cur.Mode == e.Mode &&
+			cur.Category == e.Category && cur.Date.Equal(e.Date) {
+			return true
+		}
+	}
+	return false
+}
+
+// trimLeaderboard keeps the top limit entries by score. A non-positive limit
+// keeps everything.
+func trimLeaderboard(entries []models.LeaderboardEntry, limit int) []models.LeaderboardEntry {
+	if limit <= 0 || len(entries) <= limit {
+		return entries
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Score > entries[j].Score
+	})
+	return entries[:limit]
+}
@@ -0,0 +1,193 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"avidlearner/internal/models"
+)
+
+func newTestJSONStore(dir string) *JSONStore {
+	return NewJSONStore(filepath.Join(dir, "users.json"), filepath.Join(dir, "leaderboard.json"), "")
+}
+
+func TestJSONStoreReplaysJournalAfterCrash(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestJSONStore(dir)
+	if _, err := s.Users(); err != nil {
+		t.Fatalf("Users: %v", err)
+	}
+	if err := s.PutUser(models.User{ID: "u1", Username: "alice", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("PutUser: %v", err)
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if err := s.PutUser(models.User{ID: "u1", Username: "alice", Profile: models.UserProfile{Coins: 40}}); err != nil {
+		t.Fatalf("PutUser: %v", err)
+	}
+	if err := s.PutUser(models.User{ID: "u2", Username: "bob"}); err != nil {
+		t.Fatalf("PutUser: %v", err)
+	}
+	if err := s.AddLeaderboardEntry(models.LeaderboardEntry{Name: "bob", Score: 9, Mode: "quiz", Date: time.Now()}, 10); err != nil {
+		t.Fatalf("AddLeaderboardEntry: %v", err)
+	}
+	// Simulate a crash: no Flush, journals left behind.
+
+	restarted := newTestJSONStore(dir)
+	users, err := restarted.Users()
+	if err != nil {
+		t.Fatalf("Users after crash: %v", err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("expected 2 users after replay, got %d", len(users))
+	}
+	for _, u := range users {
+		if u.ID == "u1" && u.Profile.Coins != 40 {
+			t.Errorf("expected replayed coins 40, got %d", u.Profile.Coins)
+		}
+	}
+	entries, err := restarted.Leaderboard()
+	if err != nil {
+		t.Fatalf("Leaderboard after crash: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 replayed leaderboard entry, got %d", len(entries))
+	}
+
+	if err := restarted.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "users.json.journal")); !os.IsNotExist(err) {
+		t.Errorf("expected journal to be truncated after flush, stat err=%v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "users.json.1")); err != nil {
+		t.Errorf("expected a backup generation, got %v", err)
+	}
+}
+
+func TestJSONStoreLeaderboardReplayIsIdempotent(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestJSONStore(dir)
+	entry := models.LeaderboardEntry{Name: "carol", Score: 3, Mode: "typing", Date: time.Now()}
+	if err := s.AddLeaderboardEntry(entry, 0); err != nil {
+		t.Fatalf("AddLeaderboardEntry: %v", err)
+	}
+	// Snapshot written but journal not truncated, as if we crashed in between.
+	if err := writeJSONFile(s.leaderboardPath, []models.LeaderboardEntry{entry}); err != nil {
+		t.Fatalf("writeJSONFile: %v", err)
+	}
+
+	entries, err := newTestJSONStore(dir).Leaderboard()
+	if err != nil {
+		t.Fatalf("Leaderboard: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected replay to skip duplicate, got %d entries", len(entries))
+	}
+}
+
+func TestJSONStoreRefusesCorruptFile(t *testing.T) {
+	dir := t.TempDir()
+	usersPath := filepath.Join(dir, "users.json")
+	if err := os.WriteFile(usersPath, []byte(`[{"id":"u1","username":"al`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestJSONStore(dir)
+	if _, err := s.Users(); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("expected ErrCorrupt, got %v", err)
+	}
+	if err := s.PutUser(models.User{ID: "u2", Username: "bob"}); err != nil {
+		t.Fatalf("PutUser: %v", err)
+	}
+	if err := s.Flush(); err == nil {
+		t.Fatal("expected Flush to refuse overwriting a corrupt file")
+	}
+	b, _ := os.ReadFile(usersPath)
+	if !strings.Contains(string(b), `"al`) {
+		t.Fatal("corrupt file should be left untouched")
+	}
+}
+
+func TestJSONStoreRecoversFromBackup(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestJSONStore(dir)
+	if err := s.PutUser(models.User{ID: "u1", Username: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.PutUser(models.User{ID: "u2", Username: "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	usersPath := filepath.Join(dir, "users.json")
+	if err := os.WriteFile(usersPath, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	recovering := newTestJSONStore(dir)
+	recovering.recoverCorrupt = true
+	users, err := recovering.Users()
+	if err != nil {
+		t.Fatalf("Users with recovery: %v", err)
+	}
+	if len(users) != 1 || users[0].ID != "u1" {
+		t.Fatalf("expected users restored from newest backup, got %+v", users)
+	}
+	matches, _ := filepath.Glob(usersPath + ".corrupt-*")
+	if len(matches) != 1 {
+		t.Fatalf("expected corrupt file to be moved aside, got %v", matches)
+	}
+}
+
+func TestJournalDropsTornTail(t *testing.T) {
+	dir := t.TempDir()
+	j := newJournal(filepath.Join(dir, "users.json"))
+	if err := j.append(journalRecord{Op: opPutUser, User: &models.User{ID: "u1"}}); err != nil {
+		t.Fatal(err)
+	}
+	j.close()
+
+	f, err := os.OpenFile(j.path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"op":"putUser","user":{"id":"u2"`)
+	f.Close()
+
+	records, err := j.records()
+	if err != nil {
+		t.Fatalf("records: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected torn record to be dropped, got %d records", len(records))
+	}
+}
+
+func TestWriteFileAtomicRotatesBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	for i := 0; i < backupGenerations+2; i++ {
+		if err := writeFileAtomic(path, []byte{byte('0' + i)}); err != nil {
+			t.Fatalf("writeFileAtomic: %v", err)
+		}
+	}
+	b, _ := os.ReadFile(path)
+	if string(b) != "4" {
+		t.Fatalf("expected latest contents, got %q", b)
+	}
+	if b, _ := os.ReadFile(backupPath(path, 1)); string(b) != "3" {
+		t.Errorf("expected newest backup to hold previous version, got %q", b)
+	}
+	if _, err := os.Stat(backupPath(path, backupGenerations+1)); !os.IsNotExist(err) {
+		t.Errorf("expected at most %d backup generations", backupGenerations)
+	}
+}
@@ -0,0 +1,373 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"avidlearner/internal/models"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS users (
+	id                 TEXT PRIMARY KEY,
+	username           TEXT NOT NULL UNIQUE COLLATE NOCASE,
+	password_hash      TEXT NOT NULL,
+	created_at         TIMESTAMP NOT NULL,
+	leaderboard_opt_in INTEGER NOT NULL DEFAULT 0,
+	profile            TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS leaderboard (
+	id       INTEGER PRIMARY KEY AUTOINCREMENT,
+	name     TEXT NOT NULL,
+	score    INTEGER NOT NULL,
+	mode     TEXT NOT NULL,
+	category TEXT NOT NULL DEFAULT '',
+	date     TIMESTAMP NOT NULL,
+	level    INTEGER NOT NULL DEFAULT 0,
+	title    TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS leaderboard_score ON leaderboard (score DESC);
+CREATE TABLE IF NOT EXISTS ledger (
+	seq     INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id TEXT NOT NULL,
+	kind    TEXT NOT NULL,
+	at      TIMESTAMP NOT NULL,
+	data    TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS ledger_user_seq ON ledger (user_id, seq);
+CREATE TABLE IF NOT EXISTS quiz_attempts (
+	seq         INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id     TEXT NOT NULL,
+	finished_at TIMESTAMP NOT NULL,
+	data        TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS quiz_attempts_user_seq ON quiz_attempts (user_id, seq);
+CREATE TABLE IF NOT EXISTS sessions (
+	id         TEXT PRIMARY KEY,
+	profile    TEXT NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+);
+`
+
+// SQLiteStore persists every write immediately to an embedded SQLite
+// database, so no progress is lost between flushes.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens (creating if needed) the database at path and applies the
+// schema.
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("sqlite path not set")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// A single connection serialises writers and keeps pragmas consistent.
+	db.SetMaxOpenConns(1)
+
+	for _, pragma := range []string{
+		"PRAGMA journal_mode=WAL",
+		"PRAGMA synchronous=NORMAL",
+		"PRAGMA busy_timeout=5000",
+	} {
+		if _, err := db.Exec(pragma); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("%s: %w", pragma, err)
+		}
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("apply schema: %w", err)
+	}
+	// Columns added after a table was first created.
+	for _, c := range []struct{ table, column, decl string }{
+		{"leaderboard", "level", "INTEGER NOT NULL DEFAULT 0"},
+		{"leaderboard", "title", "TEXT NOT NULL DEFAULT ''"},
+	} {
+		if err := addColumnIfMissing(db, c.table, c.column, c.decl); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("migrate %s.%s: %w", c.table, c.column, err)
+		}
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func addColumnIfMissing(db *sql.DB, table, column, decl string) error {
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	_, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, decl))
+	return err
+}
+
+func (s *SQLiteStore) Users() ([]models.User, error) {
+	rows, err := s.db.Query(`SELECT id, username, password_hash, created_at, leaderboard_opt_in, profile FROM users ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.User
+	for rows.Next() {
+		var (
+			u       models.User
+			profile string
+		)
+		if err := rows.Scan(&u.ID, &u.Username, &u.PasswordHash, &u.CreatedAt, &u.LeaderboardOptIn, &profile); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(profile), &u.Profile); err != nil {
+			return nil, fmt.Errorf("decode profile for %s: %w", u.ID, err)
+		}
+		list = append(list, u)
+	}
+	return list, rows.Err()
+}
+
+func (s *SQLiteStore) PutUser(u models.User) error {
+	profile, err := json.Marshal(u.Profile)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO users (id, username, password_hash, created_at, leaderboard_opt_in, profile)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			username = excluded.username,
+			password_hash = excluded.password_hash,
+			leaderboard_opt_in = excluded.leaderboard_opt_in,
+			profile = excluded.profile`,
+		u.ID, u.Username, u.PasswordHash, u.CreatedAt.UTC(), u.LeaderboardOptIn, string(profile))
+	return err
+}
+
+func (s *SQLiteStore) Leaderboard() ([]models.LeaderboardEntry, error) {
+	rows, err := s.db.Query(`SELECT name, score, mode, category, date, level, title FROM leaderboard ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.LeaderboardEntry
+	for rows.Next() {
+		var e models.LeaderboardEntry
+		if err := rows.Scan(&e.Name, &e.Score, &e.Mode, &e.Category, &e.Date, &e.Level, &e.Title); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (s *SQLiteStore) AddLeaderboardEntry(e models.LeaderboardEntry, limit int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO leaderboard (name, score, mode, category, date, level, title) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		e.Name, e.Score, e.Mode, e.Category, e.Date.UTC(), e.Level, e.Title); err != nil {
+		return err
+	}
+	if limit > 0 {
+		if _, err := tx.Exec(`DELETE FROM leaderboard WHERE id NOT IN (SELECT id FROM leaderboard ORDER BY score DESC, id LIMIT ?)`, limit); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) AppendLedger(e models.LedgerEntry) (models.LedgerEntry, error) {
+	e.Seq = 0
+	data, err := json.Marshal(e)
+	if err != nil {
+		return e, err
+	}
+	res, err := s.db.Exec(`INSERT INTO ledger (user_id, kind, at, data) VALUES (?, ?, ?, ?)`,
+		e.UserID, e.Kind, e.At.UTC(), string(data))
+	if err != nil {
+		return e, err
+	}
+	e.Seq, err = res.LastInsertId()
+	return e, err
+}
+
+func (s *SQLiteStore) Ledger(userID string, beforeSeq int64, limit int) ([]models.LedgerEntry, error) {
+	query := `SELECT seq, data FROM ledger WHERE user_id = ?`
+	args := []any{userID}
+	if beforeSeq > 0 {
+		query += ` AND seq < ?`
+		args = append(args, beforeSeq)
+	}
+	query += ` ORDER BY seq DESC`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.LedgerEntry
+	for rows.Next() {
+		var (
+			seq  int64
+			data string
+			e    models.LedgerEntry
+		)
+		if err := rows.Scan(&seq, &data); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			return nil, fmt.Errorf("decode ledger entry %d: %w", seq, err)
+		}
+		e.Seq = seq
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func (s *SQLiteStore) AppendQuizAttempt(a models.QuizAttempt) (models.QuizAttempt, error) {
+	a.Seq = 0
+	data, err := json.Marshal(a)
+	if err != nil {
+		return a, err
+	}
+	res, err := s.db.Exec(`INSERT INTO quiz_attempts (user_id, finished_at, data) VALUES (?, ?, ?)`,
+		a.UserID, a.FinishedAt.UTC(), string(data))
+	if err != nil {
+		return a, err
+	}
+	a.Seq, err = res.LastInsertId()
+	return a, err
+}
+
+func (s *SQLiteStore) QuizAttempts(userID string, beforeSeq int64, limit int) ([]models.QuizAttempt, error) {
+	query := `SELECT seq, data FROM quiz_attempts WHERE user_id = ?`
+	args := []any{userID}
+	if beforeSeq > 0 {
+		query += ` AND seq < ?`
+		args = append(args, beforeSeq)
+	}
+	query += ` ORDER BY seq DESC`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.QuizAttempt
+	for rows.Next() {
+		var (
+			seq  int64
+			data string
+			a    models.QuizAttempt
+		)
+		if err := rows.Scan(&seq, &data); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(data), &a); err != nil {
+			return nil, fmt.Errorf("decode quiz attempt %d: %w", seq, err)
+		}
+		a.Seq = seq
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func (s *SQLiteStore) QuizAttempt(userID string, seq int64) (models.QuizAttempt, error) {
+	var (
+		data string
+		a    models.QuizAttempt
+	)
+	err := s.db.QueryRow(`SELECT data FROM quiz_attempts WHERE user_id = ? AND seq = ?`, userID, seq).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return a, ErrNotFound
+	}
+	if err != nil {
+		return a, err
+	}
+	if err := json.Unmarshal([]byte(data), &a); err != nil {
+		return a, fmt.Errorf("decode quiz attempt %d: %w", seq, err)
+	}
+	a.Seq = seq
+	return a, nil
+}
+
+func (s *SQLiteStore) Sessions() (map[string]models.Profile, error) {
+	rows, err := s.db.Query(`SELECT id, profile FROM sessions`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[string]models.Profile{}
+	for rows.Next() {
+		var (
+			id  string
+			raw string
+			p   models.Profile
+		)
+		if err := rows.Scan(&id, &raw); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(raw), &p); err != nil {
+			return nil, fmt.Errorf("decode session %s: %w", id, err)
+		}
+		out[id] = p
+	}
+	return out, rows.Err()
+}
+
+func (s *SQLiteStore) PutSession(id string, p models.Profile) error {
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO sessions (id, profile, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET profile = excluded.profile, updated_at = excluded.updated_at`,
+		id, string(raw), time.Now().UTC())
+	return err
+}
+
+func (s *SQLiteStore) DeleteSession(id string) error {
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE id = ?`, id)
+	return err
+}
+
+// Flush is a no-op; every write is committed as it happens.
+func (s *SQLiteStore) Flush() error {
+	return nil
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
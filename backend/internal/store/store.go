@@ -0,0 +1,139 @@
+package store
+
+import (
+	"errors"
+
+	"avidlearner/internal/models"
+)
+
+// ErrCorrupt is returned when a persisted file cannot be decoded. Stores
+// refuse to overwrite such files unless recovery was explicitly requested.
+var ErrCorrupt = errors.New("corrupt data file")
+
+// ErrNotFound is returned when a requested record does not exist.
+var ErrNotFound = errors.New("not found")
+
+// Store persists users (including their profiles), leaderboard entries and
+// anonymous sessions. Implementations must be safe for concurrent use.
+type Store interface {
+	// Users returns every stored user.
+	Users() ([]models.User, error)
+	// PutUser inserts or replaces a user and its profile.
+	PutUser(u models.User) error
+
+	// Leaderboard returns every stored leaderboard entry.
+	Leaderboard() ([]models.LeaderboardEntry, error)
+	// AddLeaderboardEntry appends an entry and keeps only the top limit
+	// entries by score when limit is positive.
+	AddLeaderboardEntry(e models.LeaderboardEntry, limit int) error
+
+	// AppendLedger durably appends a ledger entry, assigning its Seq.
+	AppendLedger(e models.LedgerEntry) (models.LedgerEntry, error)
+	// Ledger returns a user's entries newest first. Only entries with Seq
+	// below beforeSeq are returned when it is positive, and at most limit
+	// entries when limit is positive.
+	Ledger(userID string, beforeSeq int64, limit int) ([]models.LedgerEntry, error)
+
+	// AppendQuizAttempt durably appends a finished quiz, assigning its Seq.
+	AppendQuizAttempt(a models.QuizAttempt) (models.QuizAttempt, error)
+	// QuizAttempts pages through a user's attempts newest first, like Ledger.
+	QuizAttempts(userID string, beforeSeq int64, limit int) ([]models.QuizAttempt, error)
+	// QuizAttempt returns one of a user's attempts, or ErrNotFound.
+	QuizAttempt(userID string, seq int64) (models.QuizAttempt, error)
+
+	// Sessions returns every stored anonymous session keyed by session ID.
+	Sessions() (map[string]models.Profile, error)
+	// PutSession inserts or replaces an anonymous session.
+	PutSession(id string, p models.Profile) error
+	// DeleteSession removes an anonymous session. Deleting a missing session
+	// is not an error.
+	DeleteSession(id string) error
+
+	// Flush writes any buffered changes to durable storage.
+	Flush() error
+	// Close flushes and releases the underlying resources.
+	Close() error
+}
+
+const (
+	DriverJSON   = "json"
+	DriverSQLite = "sqlite"
+)
+
+// Options selects and configures a Store implementation.
+type Options struct {
+	Driver          string
+	UsersFile       string
+	LeaderboardFile string
+	SessionsFile    string
+	LedgerFile      string
+	AttemptsFile    string
+	SQLitePath      string
+	// RecoverCorrupt lets the JSON store fall back to the newest readable
+	// backup (or an empty state) when a data file is corrupt, instead of
+	// failing to load.
+	RecoverCorrupt bool
+}
+
+// Open returns the Store implementation selected by opts.Driver.
+func Open(opts Options) (Store, error) {
+	switch opts.Driver {
+	case "", DriverJSON:
+		s := NewJSONStore(opts.UsersFile, opts.LeaderboardFile, opts.SessionsFile)
+		if opts.LedgerFile != "" {
+			s.ledgerPath = opts.LedgerFile
+		}
+		if opts.AttemptsFile != "" {
+			s.attemptsPath = opts.AttemptsFile
+		}
+		s.recoverCorrupt = opts.RecoverCorrupt
+		return s, nil
+	case DriverSQLite:
+		return OpenSQLite(opts.SQLitePath)
+	default:
+		return nil, errors.New("unknown store driver: " + opts.Driver)
+	}
+}
+
+// Import copies users, their ledgers and quiz attempts, and leaderboard
+// entries from src into dst.
+func Import(dst, src Store) error {
+	users, err := src.Users()
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if err := dst.PutUser(u); err != nil {
+			return err
+		}
+		entries, err := src.Ledger(u.ID, 0, 0)
+		if err != nil {
+			return err
+		}
+		for i := len(entries) - 1; i >= 0; i-- {
+			if _, err := dst.AppendLedger(entries[i]); err != nil {
+				return err
+			}
+		}
+		attempts, err := src.QuizAttempts(u.ID, 0, 0)
+		if err != nil {
+			return err
+		}
+		for i := len(attempts) - 1; i >= 0; i-- {
+			if _, err := dst.AppendQuizAttempt(attempts[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	entries, err := src.Leaderboard()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := dst.AddLeaderboardEntry(e, 0); err != nil {
+			return err
+		}
+	}
+	return dst.Flush()
+}
@@ -0,0 +1,283 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"avidlearner/internal/models"
+)
+
+func openTestStores(t *testing.T) map[string]func() Store {
+	t.Helper()
+	dir := t.TempDir()
+	return map[string]func() Store{
+		DriverJSON: func() Store {
+			return NewJSONStore(
+				filepath.Join(dir, "users.json"),
+				filepath.Join(dir, "leaderboard.json"),
+				filepath.Join(dir, "sessions.json"),
+			)
+		},
+		DriverSQLite: func() Store {
+			s, err := OpenSQLite(filepath.Join(dir, "avidlearner.db"))
+			if err != nil {
+				t.Fatalf("OpenSQLite: %v", err)
+			}
+			return s
+		},
+	}
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	for name, open := range openTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			s := open()
+			now := time.Now().Truncate(time.Second)
+
+			user := models.User{
+				ID:           "u1",
+				Username:     "alice",
+				PasswordHash: "hash",
+				CreatedAt:    now,
+				Profile: models.UserProfile{
+					Coins:       10,
+					LessonsSeen: []string{"Circuit Breaker"},
+				},
+			}
+			if err := s.PutUser(user); err != nil {
+				t.Fatalf("PutUser: %v", err)
+			}
+			user.Profile.Coins = 25
+			if err := s.PutUser(user); err != nil {
+				t.Fatalf("PutUser update: %v", err)
+			}
+
+			for i, score := range []int{5, 50, 20} {
+				entry := models.LeaderboardEntry{Name: "alice", Score: score, Mode: "quiz", Date: now.Add(time.Duration(i) * time.Second), Level: i + 1, Title: "Novice"}
+				if err := s.AddLeaderboardEntry(entry, 2); err != nil {
+					t.Fatalf("AddLeaderboardEntry: %v", err)
+				}
+			}
+
+			profile := models.Profile{Coins: 7, HintIdx: map[string]int{"fan-in-fan-out": 2}}
+			if err := s.PutSession("sid-1", profile); err != nil {
+				t.Fatalf("PutSession: %v", err)
+			}
+			if err := s.PutSession("sid-2", profile); err != nil {
+				t.Fatalf("PutSession: %v", err)
+			}
+			if err := s.DeleteSession("sid-2"); err != nil {
+				t.Fatalf("DeleteSession: %v", err)
+			}
+			if err := s.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			reopened := open()
+			defer reopened.Close()
+
+			users, err := reopened.Users()
+			if err != nil {
+				t.Fatalf("Users: %v", err)
+			}
+			if len(users) != 1 {
+				t.Fatalf("expected 1 user, got %d", len(users))
+			}
+			if users[0].Profile.Coins != 25 {
+				t.Errorf("expected updated coins 25, got %d", users[0].Profile.Coins)
+			}
+			if len(users[0].Profile.LessonsSeen) != 1 {
+				t.Errorf("expected lessonsSeen to round-trip, got %v", users[0].Profile.LessonsSeen)
+			}
+
+			entries, err := reopened.Leaderboard()
+			if err != nil {
+				t.Fatalf("Leaderboard: %v", err)
+			}
+			if len(entries) != 2 {
+				t.Fatalf("expected leaderboard trimmed to 2, got %d", len(entries))
+			}
+			for _, e := range entries {
+				if e.Score == 5 {
+					t.Errorf("lowest score should have been trimmed")
+				}
+				if e.Level == 0 || e.Title != "Novice" {
+					t.Errorf("expected level and title to round-trip, got %+v", e)
+				}
+			}
+
+			sessions, err := reopened.Sessions()
+			if err != nil {
+				t.Fatalf("Sessions: %v", err)
+			}
+			if len(sessions) != 1 {
+				t.Fatalf("expected 1 session, got %d", len(sessions))
+			}
+			if got := sessions["sid-1"].HintIdx["fan-in-fan-out"]; got != 2 {
+				t.Errorf("expected hint index 2, got %d", got)
+			}
+		})
+	}
+}
+
+func TestLedgerPaging(t *testing.T) {
+	for name, open := range openTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			s := open()
+			for i := 1; i <= 5; i++ {
+				e := models.LedgerEntry{UserID: "u1", Kind: models.LedgerQuizAnswer, Coins: i, At: time.Now()}
+				if i == 3 {
+					e.UserID = "u2"
+				}
+				stored, err := s.AppendLedger(e)
+				if err != nil {
+					t.Fatalf("AppendLedger: %v", err)
+				}
+				if stored.Seq == 0 {
+					t.Fatal("expected a sequence number to be assigned")
+				}
+			}
+			if err := s.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			reopened := open()
+			defer reopened.Close()
+
+			page, err := reopened.Ledger("u1", 0, 2)
+			if err != nil {
+				t.Fatalf("Ledger: %v", err)
+			}
+			if len(page) != 2 || page[0].Coins != 5 || page[1].Coins != 4 {
+				t.Fatalf("expected newest two entries, got %+v", page)
+			}
+			rest, err := reopened.Ledger("u1", page[1].Seq, 0)
+			if err != nil {
+				t.Fatalf("Ledger: %v", err)
+			}
+			if len(rest) != 2 || rest[0].Coins != 2 || rest[1].Coins != 1 {
+				t.Fatalf("expected remaining u1 entries, got %+v", rest)
+			}
+		})
+	}
+}
+
+func TestQuizAttempts(t *testing.T) {
+	for name, open := range openTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			s := open()
+			var ids []int64
+			for i := 1; i <= 3; i++ {
+				a := models.QuizAttempt{UserID: "u1", Score: i, Total: 3, FinishedAt: time.Now(), Questions: []models.AttemptQuestion{
+					{LessonID: "caching", Question: "Which?", Options: []string{"a", "b"}, Chosen: []int{1}, Correct: []int{0}},
+				}}
+				if i == 2 {
+					a.UserID = "u2"
+				}
+				stored, err := s.AppendQuizAttempt(a)
+				if err != nil {
+					t.Fatalf("AppendQuizAttempt: %v", err)
+				}
+				ids = append(ids, stored.Seq)
+			}
+			if err := s.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			reopened := open()
+			defer reopened.Close()
+
+			list, err := reopened.QuizAttempts("u1", 0, 0)
+			if err != nil {
+				t.Fatalf("QuizAttempts: %v", err)
+			}
+			if len(list) != 2 || list[0].Score != 3 || list[1].Score != 1 {
+				t.Fatalf("expected u1 attempts newest first, got %+v", list)
+			}
+			if page, _ := reopened.QuizAttempts("u1", list[0].Seq, 1); len(page) != 1 || page[0].Seq != list[1].Seq {
+				t.Fatalf("expected paging before %d, got %+v", list[0].Seq, page)
+			}
+			got, err := reopened.QuizAttempt("u1", ids[0])
+			if err != nil || len(got.Questions) != 1 || got.Questions[0].Chosen[0] != 1 {
+				t.Fatalf("QuizAttempt: %+v, %v", got, err)
+			}
+			if _, err := reopened.QuizAttempt("u1", ids[1]); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("another user's attempt should be not found, got %v", err)
+			}
+		})
+	}
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	src := NewJSONStore(filepath.Join(dir, "users.json"), filepath.Join(dir, "leaderboard.json"), "")
+	if err := src.PutUser(models.User{ID: "u1", Username: "bob", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("PutUser: %v", err)
+	}
+	if err := src.AddLeaderboardEntry(models.LeaderboardEntry{Name: "bob", Score: 3, Mode: "typing", Date: time.Now()}, 0); err != nil {
+		t.Fatalf("AddLeaderboardEntry: %v", err)
+	}
+	if err := src.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	dst, err := OpenSQLite(filepath.Join(dir, "import.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer dst.Close()
+
+	if err := Import(dst, NewJSONStore(filepath.Join(dir, "users.json"), filepath.Join(dir, "leaderboard.json"), "")); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	users, err := dst.Users()
+	if err != nil || len(users) != 1 || users[0].Username != "bob" {
+		t.Fatalf("expected imported user bob, got %v (err %v)", users, err)
+	}
+	entries, err := dst.Leaderboard()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected 1 imported entry, got %d (err %v)", len(entries), err)
+	}
+}
+
+func TestOpenUnknownDriver(t *testing.T) {
+	if _, err := Open(Options{Driver: "postgres"}); err == nil {
+		t.Fatal("expected error for unknown driver")
+	}
+}
+
+func TestSQLiteAddsLeaderboardLevel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The leaderboard table as it was before levels.
+	if _, err := db.Exec(`CREATE TABLE leaderboard (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL, score INTEGER NOT NULL,
+		mode TEXT NOT NULL, category TEXT NOT NULL DEFAULT '', date TIMESTAMP NOT NULL);
+		INSERT INTO leaderboard (name, score, mode, date) VALUES ('old', 3, 'quiz', CURRENT_TIMESTAMP)`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	s, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer s.Close()
+	if err := s.AddLeaderboardEntry(models.LeaderboardEntry{Name: "new", Score: 4, Mode: "quiz", Date: time.Now(), Level: 3, Title: "Apprentice"}, 0); err != nil {
+		t.Fatalf("AddLeaderboardEntry: %v", err)
+	}
+	entries, err := s.Leaderboard()
+	if err != nil {
+		t.Fatalf("Leaderboard: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Level != 0 || entries[1].Level != 3 || entries[1].Title != "Apprentice" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+}
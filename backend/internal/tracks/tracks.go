@@ -0,0 +1,210 @@
+// Package tracks loads curated learning tracks: ordered lessons and pro
+// challenges where a step stays locked until the steps it requires are done.
+package tracks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Step kinds.
+const (
+	KindLesson    = "lesson"
+	KindChallenge = "challenge"
+)
+
+// Step states, as reported for a user's progress.
+const (
+	StatusCompleted = "completed"
+	StatusAvailable = "available"
+	StatusLocked    = "locked"
+)
+
+// Step is one lesson or pro challenge in a track. ID names the step within
+// the track and defaults to the lesson or challenge ID; Requires lists the
+// IDs of earlier steps that must be completed first.
+type Step struct {
+	ID        string   `json:"id"`
+	Lesson    string   `json:"lesson,omitempty"`
+	Challenge string   `json:"challenge,omitempty"`
+	Requires  []string `json:"requires,omitempty"`
+}
+
+// Kind is KindLesson or KindChallenge.
+func (s Step) Kind() string {
+	if s.Challenge != "" {
+		return KindChallenge
+	}
+	return KindLesson
+}
+
+// Ref is the lesson or challenge ID the step points at.
+func (s Step) Ref() string {
+	if s.Challenge != "" {
+		return s.Challenge
+	}
+	return s.Lesson
+}
+
+// Track is an ordered curriculum.
+type Track struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Steps       []Step `json:"steps"`
+}
+
+// StepState is a step with its status for one user.
+type StepState struct {
+	Step
+	Kind        string     `json:"kind"`
+	Title       string     `json:"title,omitempty"` // set by the caller from the catalog
+	Status      string     `json:"status"`
+	Missing     []string   `json:"missing,omitempty"` // unmet prerequisites
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+type tracksFile struct {
+	Tracks []Track `json:"tracks"`
+}
+
+// Load reads a tracks file. A missing file has no tracks. Step IDs are
+// filled in from their lesson or challenge.
+func Load(path string) ([]Track, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var file tracksFile
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, err
+	}
+	for i := range file.Tracks {
+		for j := range file.Tracks[i].Steps {
+			if s := &file.Tracks[i].Steps[j]; s.ID == "" {
+				s.ID = s.Ref()
+			}
+		}
+	}
+	if err := Validate(file.Tracks); err != nil {
+		return nil, err
+	}
+	return file.Tracks, nil
+}
+
+// Validate checks track and step IDs and prerequisite edges. A step may
+// only require steps listed before it, which keeps the graph acyclic and the
+// listed order a valid study order.
+func Validate(list []Track) error {
+	ids := map[string]bool{}
+	for i, t := range list {
+		if strings.TrimSpace(t.ID) == "" {
+			return fmt.Errorf("track %d: id is required", i)
+		}
+		if strings.ContainsAny(t.ID, "/ ") {
+			return fmt.Errorf("track %q: id must not contain slashes or spaces", t.ID)
+		}
+		if ids[t.ID] {
+			return fmt.Errorf("track %q: duplicate id", t.ID)
+		}
+		ids[t.ID] = true
+		if strings.TrimSpace(t.Title) == "" {
+			return fmt.Errorf("track %q: title is required", t.ID)
+		}
+		if len(t.Steps) == 0 {
+			return fmt.Errorf("track %q: no steps", t.ID)
+		}
+		seen := map[string]bool{}
+		for j, s := range t.Steps {
+			if (s.Lesson == "") == (s.Challenge == "") {
+				return fmt.Errorf("track %q: step %d: set exactly one of lesson or challenge", t.ID, j)
+			}
+			if s.ID == "" {
+				return fmt.Errorf("track %q: step %d: id is required", t.ID, j)
+			}
+			if seen[s.ID] {
+				return fmt.Errorf("track %q: step %q: duplicate id", t.ID, s.ID)
+			}
+			for _, req := range s.Requires {
+				if !seen[req] {
+					return fmt.Errorf("track %q: step %q: requires %q, which is not an earlier step", t.ID, s.ID, req)
+				}
+			}
+			seen[s.ID] = true
+		}
+	}
+	return nil
+}
+
+// Step returns the step with the given ID.
+func (t Track) Step(id string) (Step, bool) {
+	for _, s := range t.Steps {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return Step{}, false
+}
+
+// Missing returns the prerequisites of s not in completed.
+func (t Track) Missing(s Step, completed map[string]time.Time) []string {
+	var missing []string
+	for _, req := range s.Requires {
+		if _, ok := completed[req]; !ok {
+			missing = append(missing, req)
+		}
+	}
+	return missing
+}
+
+// States reports every step's status in track order.
+func (t Track) States(completed map[string]time.Time) []StepState {
+	out := make([]StepState, 0, len(t.Steps))
+	for _, s := range t.Steps {
+		st := StepState{Step: s, Kind: s.Kind()}
+		if at, ok := completed[s.ID]; ok {
+			st.Status = StatusCompleted
+			st.CompletedAt = &at
+		} else if st.Missing = t.Missing(s, completed); len(st.Missing) > 0 {
+			st.Status = StatusLocked
+		} else {
+			st.Status = StatusAvailable
+		}
+		out = append(out, st)
+	}
+	return out
+}
+
+// Next returns the first available step in track order, or false when every
+// step is completed.
+func (t Track) Next(completed map[string]time.Time) (Step, bool) {
+	for _, s := range t.Steps {
+		if _, ok := completed[s.ID]; ok {
+			continue
+		}
+		if len(t.Missing(s, completed)) == 0 {
+			return s, true
+		}
+	}
+	return Step{}, false
+}
+
+// Done reports whether every step is completed.
+func (t Track) Done(completed map[string]time.Time) bool {
+	for _, s := range t.Steps {
+		if _, ok := completed[s.ID]; !ok {
+			return false
+		}
+	}
+	return true
+}
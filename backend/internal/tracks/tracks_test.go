@@ -0,0 +1,68 @@
+package tracks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadValidates(t *testing.T) {
+	dir := t.TempDir()
+	cases := []struct {
+		name, body, want string
+	}{
+		{"no steps", `{"tracks":[{"id":"a","title":"A","steps":[]}]}`, "no steps"},
+		{"both refs", `{"tracks":[{"id":"a","title":"A","steps":[{"lesson":"l","challenge":"c"}]}]}`, "exactly one"},
+		{"later prerequisite", `{"tracks":[{"id":"a","title":"A","steps":[{"lesson":"l","requires":["c"]},{"challenge":"c"}]}]}`, "not an earlier step"},
+		{"duplicate step", `{"tracks":[{"id":"a","title":"A","steps":[{"lesson":"l"},{"id":"l","challenge":"c"}]}]}`, "duplicate id"},
+		{"duplicate track", `{"tracks":[{"id":"a","title":"A","steps":[{"lesson":"l"}]},{"id":"a","title":"B","steps":[{"lesson":"l"}]}]}`, "duplicate id"},
+		{"unknown field", `{"tracks":[{"id":"a","title":"A","steps":[{"lesson":"l","after":["x"]}]}]}`, "unknown field"},
+	}
+	for _, c := range cases {
+		path := filepath.Join(dir, strings.ReplaceAll(c.name, " ", "-")+".json")
+		if err := os.WriteFile(path, []byte(c.body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: got %v, want error containing %q", c.name, err, c.want)
+		}
+	}
+
+	if list, err := Load(filepath.Join(dir, "missing.json")); err != nil || list != nil {
+		t.Fatalf("missing file should have no tracks, got %v, %v", list, err)
+	}
+}
+
+func TestTrackProgress(t *testing.T) {
+	track := Track{ID: "go", Title: "Go", Steps: []Step{
+		{ID: "goroutines", Lesson: "l1"},
+		{ID: "context", Lesson: "l2"},
+		{ID: "pool", Challenge: "worker-pool", Requires: []string{"goroutines", "context"}},
+	}}
+	if err := Validate([]Track{track}); err != nil {
+		t.Fatal(err)
+	}
+	done := map[string]time.Time{"context": time.Now()}
+
+	states := track.States(done)
+	if states[0].Status != StatusAvailable || states[1].Status != StatusCompleted || states[2].Status != StatusLocked {
+		t.Fatalf("unexpected states %+v", states)
+	}
+	if len(states[2].Missing) != 1 || states[2].Missing[0] != "goroutines" || states[2].Kind != KindChallenge {
+		t.Fatalf("locked step should name its missing prerequisite, got %+v", states[2])
+	}
+	if next, ok := track.Next(done); !ok || next.ID != "goroutines" {
+		t.Fatalf("expected goroutines next, got %+v", next)
+	}
+
+	done["goroutines"] = time.Now()
+	if next, _ := track.Next(done); next.ID != "pool" {
+		t.Fatalf("challenge should unlock once its prerequisites are done, got %+v", next)
+	}
+	done["pool"] = time.Now()
+	if _, ok := track.Next(done); ok || !track.Done(done) {
+		t.Fatal("track should be done")
+	}
+}
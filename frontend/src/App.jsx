@@ -17,7 +17,10 @@ import {
   getReadingLesson,
   addLessonToQuiz,
   startQuiz,
+  startDailyQuiz,
+  getDaily,
   answerQuiz,
+  getQuizAttempt,
   getAIConfig,
   getCachedUser,
   getAuthToken,
@@ -51,8 +54,11 @@ export default function App() {
   const [selectedSource, setSelectedSource] = useState('all');
   const sourceRef = useRef('all');
   const [currentLesson, setCurrentLesson] = useState(null);
-  const [quizQuestion, setQuizQuestion] = useState(null); // {question,options,index,total}
-  const [result, setResult] = useState(null);             // {correct,earned,total,message}
+  const [quizQuestion, setQuizQuestion] = useState(null); // {question,type,options,index,total,feedback,lastCorrect}
+  const [result, setResult] = useState(null);             // {correct,earned,total,message,misses,timed}
+  const [levelUp, setLevelUp] = useState(null);           // {from,to,title,titleChanged} from the last award
+  const [daily, setDaily] = useState(null);               // today's daily quiz, from /api/daily
+  const [dailyChallenge, setDailyChallenge] = useState(false);
 
   useEffect(() => {
     getLessons()
@@ -172,8 +178,8 @@ export default function App() {
   
   async function nextConcept() {
     try {
-      if (currentLesson?.title) {
-        await addLessonToQuiz(currentLesson.title);
+      if (currentLesson?.id && currentLesson.source !== 'ai') {
+        await addLessonToQuiz(currentLesson.id);
       }
       const s = await getReadingLesson(resolveCategory(), sourceRef.current);
       setCurrentLesson(s.lesson);
@@ -185,13 +191,33 @@ export default function App() {
       }
     }
   }
-  async function beginQuiz() {
+  useEffect(() => {
+    if (mode !== 'dashboard') return;
+    getDaily().then(setDaily).catch(err => console.warn('Failed to load the daily quiz:', err));
+  }, [mode, user]);
+
+  async function beginDailyQuiz() {
+    try {
+      const q = await startDailyQuiz();
+      setQuizQuestion({ question: q.question, type: q.questionType, options: q.options, index: q.index, total: q.total });
+      if (typeof q.xpTotal === 'number') setXp(q.xpTotal);
+      setMode('quiz');
+    } catch (err) {
+      console.error('Failed to start the daily quiz:', err);
+      alert('Failed to start the daily quiz. Please try again.');
+    }
+  }
+
+  async function beginQuiz(opts) {
     try {
-      if (currentLesson?.title) {
-        await addLessonToQuiz(currentLesson.title);
+      if (currentLesson?.id && currentLesson.source !== 'ai') {
+        await addLessonToQuiz(currentLesson.id);
       }
-      const q = await startQuiz();
-      setQuizQuestion({ question: q.question, options: q.options, index: q.index, total: q.total });
+      const q = await startQuiz(opts);
+      setQuizQuestion({
+        question: q.question, type: q.questionType, options: q.options, index: q.index, total: q.total,
+        questionTimeLeftMs: q.questionTimeLeftMs, quizTimeLeftMs: q.quizTimeLeftMs
+      });
       if (typeof q.xpTotal === 'number') setXp(q.xpTotal);
       setMode('quiz');
     } catch (err) {
@@ -205,11 +231,16 @@ export default function App() {
   }
 
   // ---------- Quiz flow ----------
-  async function answer(i) {
-    const s = await answerQuiz(i);
+  async function answer(a) {
+    const s = await answerQuiz(a);
+    if (s.levelUp) setLevelUp(s.levelUp);
     if (s.stage === 'quiz') {
       // server already advanced us to the next question
-      setQuizQuestion({ question: s.question, options: s.options, index: s.index, total: s.total });
+      setQuizQuestion({
+        question: s.question, type: s.questionType, options: s.options, index: s.index, total: s.total,
+        feedback: s.feedback, lastCorrect: s.correct,
+        questionTimeLeftMs: s.questionTimeLeftMs, quizTimeLeftMs: s.quizTimeLeftMs
+      });
       // show a tiny toast? for correctness; coins are cumulative
       if (s.correct) setQuizStreak(x => x + 1); else setQuizStreak(0);
       if (typeof s.coinsTotal === 'number') setCoins(s.coinsTotal);
@@ -217,7 +248,12 @@ export default function App() {
       return;
     }
     // end of quiz
-    setResult({ correct: s.correct, earned: s.coinsEarned, total: s.coinsTotal, message: s.message });
+    setResult({ correct: s.correct, earned: s.coinsEarned, total: s.coinsTotal, message: s.message, timed: Boolean(s.timed) });
+    if (s.attemptId) {
+      getQuizAttempt(s.attemptId)
+        .then(review => setResult(r => r && { ...r, misses: review.misses }))
+        .catch(err => console.warn('Failed to load quiz review:', err));
+    }
     if (s.correct) setQuizStreak(x => x + 1); else setQuizStreak(0);
     if (typeof s.coinsTotal === 'number') setCoins(s.coinsTotal);
     if (typeof s.xpTotal === 'number') setXp(s.xpTotal);
@@ -257,10 +293,10 @@ export default function App() {
 
   function isLessonSaved(lesson) {
     if (!user?.profile?.savedLessons || !lesson) return false;
-    return user.profile.savedLessons.some(
-      (saved) =>
-        saved.title === lesson.title &&
-        saved.category === lesson.category
+    return user.profile.savedLessons.some((saved) =>
+      saved.id && lesson.id
+        ? saved.id === lesson.id
+        : saved.title === lesson.title && saved.category === lesson.category
     );
   }
 
@@ -272,6 +308,7 @@ export default function App() {
     }
     try {
       const updated = await saveLesson({
+        id: currentLesson.id,
         title: currentLesson.title,
         category: currentLesson.category,
         source: currentLesson.source
@@ -287,6 +324,7 @@ export default function App() {
     if (!lesson) return;
     try {
       const updated = await removeSavedLesson({
+        id: lesson.id,
         title: lesson.title,
         category: lesson.category
       });
@@ -324,12 +362,11 @@ export default function App() {
     if (typeof best === 'number') {
       setTypingBest(prev => Math.max(prev, best));
     }
-    if (user) {
+    // Typing best is recorded server-side from /api/typing/score; only the
+    // streak is client-owned.
+    if (user && typeof streak === 'number') {
       try {
-        const updated = await updateProfile({
-          typingStreak: typeof streak === 'number' ? streak : undefined,
-          typingBest: typeof best === 'number' ? best : undefined
-        });
+        const updated = await updateProfile({ typingStreak: streak });
         setUser(updated);
       } catch (err) {
         console.error(err);
@@ -369,6 +406,12 @@ export default function App() {
       </header>
 
       <div className="container">
+        {levelUp && (
+          <div className="badge" style={{display:'block', marginBottom:12}}>
+            🎉 Level {levelUp.to}{levelUp.titleChanged ? ` · ${levelUp.title}` : ''}!{' '}
+            <button className="ghost" onClick={()=>setLevelUp(null)}>Dismiss</button>
+          </div>
+        )}
         {mode === 'dashboard' && (
           <Dashboard
             coins={coins}
@@ -382,8 +425,11 @@ export default function App() {
             onStartLearn={startReading}
             onStartAI={aiEnabled ? startAIGenerate : null}
             onStartTyping={()=>setMode('typing')}
-            onStartProMode={()=>setMode('promode')}
+            onStartProMode={()=>{ setDailyChallenge(false); setMode('promode'); }}
             onOpenLeaderboard={() => setShowLeaderboard(true)}
+            daily={daily}
+            onStartDaily={beginDailyQuiz}
+            onOpenDailyChallenge={() => { setDailyChallenge(true); setMode('promode'); }}
           />
         )}
 
@@ -401,9 +447,10 @@ export default function App() {
             earned={result.earned}
             total={result.total}
             message={result.message}
+            misses={result.misses}
             onContinue={doneResult}
             onExit={()=>setMode('dashboard')}
-            onSubmitToLeaderboard={() => promptLeaderboardSubmit(quizStreak, 'quiz')}
+            onSubmitToLeaderboard={() => promptLeaderboardSubmit(quizStreak, result.timed ? 'quiz-timed' : 'quiz')}
           />
         )}
 
@@ -431,7 +478,12 @@ export default function App() {
             question={quizQuestion.question}
             index={quizQuestion.index}
             total={quizQuestion.total}
+            type={quizQuestion.type}
             options={quizQuestion.options}
+            feedback={quizQuestion.feedback}
+            lastCorrect={quizQuestion.lastCorrect}
+            questionTimeLeftMs={quizQuestion.questionTimeLeftMs}
+            quizTimeLeftMs={quizQuestion.quizTimeLeftMs}
             onAnswer={answer}
             onExit={()=>setMode('dashboard')}
           />
@@ -443,8 +495,10 @@ export default function App() {
             xp={xp}
             onCoinsChange={setCoins}
             onXpChange={setXp}
+            onLevelUp={setLevelUp}
+            daily={dailyChallenge}
             onSubmitToLeaderboard={(score) => promptLeaderboardSubmit(score, 'coding')}
-            onExit={()=>setMode('dashboard')}
+            onExit={()=>{ setDailyChallenge(false); setMode('dashboard'); }}
           />
         )}
 
@@ -456,6 +510,8 @@ export default function App() {
             onSelectCategory={handleSelectCategory}
             typingBest={typingBest}
             onTypingStats={handleTypingStats}
+            onXpChange={setXp}
+            onLevelUp={setLevelUp}
             onSubmitToLeaderboard={(wpm) => promptLeaderboardSubmit(wpm, 'typing')}
             onExit={()=>setMode('dashboard')}
           />
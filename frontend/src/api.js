@@ -239,19 +239,21 @@ export async function getReadingLesson(category = 'any', source = 'all') {
 }
 
 // Add current lesson to study list (server-side)
-export async function addLessonToQuiz(title) {
+export async function addLessonToQuiz(id) {
   const res = await apiFetch('/api/session?stage=add', {
     method: 'POST',
     headers: { 'Content-Type': 'application/json' },
-    body: JSON.stringify({ title })
+    body: JSON.stringify({ id })
   });
   if (!res.ok) throw new Error('Failed to add lesson');
   return res.json();
 }
 
 // Build quiz from lessons seen (or all if none)
-export async function startQuiz() {
-  const res = await apiFetch('/api/session?stage=startQuiz', { method: 'POST' });
+// A timed quiz gets server-enforced deadlines and speed-weighted points.
+export async function startQuiz({ timed = false } = {}) {
+  const url = timed ? '/api/session?stage=startQuiz&timed=true' : '/api/session?stage=startQuiz';
+  const res = await apiFetch(url, { method: 'POST' });
   if (!res.ok) throw new Error('Failed to start quiz');
   return res.json();
 }
@@ -264,34 +266,136 @@ export async function getCurrentQuiz() {
 }
 
 // Submit answer; server returns either the next question or end-of-quiz result
-export async function answerQuiz(answerIndex) {
+// answer is an option index, or for authored questions one of
+// { answerIndexes }, { order } or { answerText }.
+export async function answerQuiz(answer) {
+  const body = typeof answer === 'number' ? { answerIndex: answer } : answer;
   const res = await apiFetch('/api/session?stage=answer', {
     method: 'POST',
     headers: { 'Content-Type': 'application/json' },
-    body: JSON.stringify({ answerIndex })
+    body: JSON.stringify(body)
   });
   if (!res.ok) throw new Error('Answer failed');
   return res.json();
 }
 
-export async function getProChallenge({ topic, difficulty } = {}) {
+export async function getQuizAttempts({ limit, before } = {}) {
+  const params = new URLSearchParams();
+  if (limit) params.set('limit', String(limit));
+  if (before) params.set('before', String(before));
+  const qs = params.toString();
+  const res = await apiFetch(`/api/quiz/attempts${qs ? `?${qs}` : ''}`);
+  const data = await res.json().catch(() => ({}));
+  if (!res.ok) throw new Error(data.error || 'Failed to load quiz history');
+  return data;
+}
+
+export async function getQuizAttempt(id) {
+  const res = await apiFetch(`/api/quiz/attempts/${encodeURIComponent(id)}`);
+  const data = await res.json().catch(() => ({}));
+  if (!res.ok) throw new Error(data.error || 'Failed to load quiz review');
+  return data;
+}
+
+// trackLockedError turns a 403 for content locked by the user's learning
+// tracks into an error naming the steps to complete first.
+async function trackLockedError(res) {
+  const data = await res.json().catch(() => ({}));
+  const steps = (data.locked || []).map((l) => `${l.missing.join(', ')} in ${l.track}`);
+  const error = new Error(steps.length
+    ? `Locked: complete ${steps.join('; ')} first.`
+    : data.error || 'Locked by your learning tracks.');
+  error.locked = data.locked || [];
+  return error;
+}
+
+export async function getProChallenge({ topic, difficulty, daily } = {}) {
   const params = new URLSearchParams();
+  if (daily) params.set('daily', 'true');
   if (topic) params.set('topic', topic);
   if (difficulty) params.set('difficulty', difficulty);
   const qs = params.toString();
   const res = await apiFetch(`/api/prochallenge${qs ? `?${qs}` : ''}`);
+  if (res.status === 403) throw await trackLockedError(res);
   if (!res.ok) throw new Error('Unable to fetch challenge');
   return res.json();
 }
 
-export async function submitProChallenge({ id, code }) {
+const GRADING_POLL_MS = 1000;
+
+// submitProChallenge queues the code for grading and resolves with the
+// result once the job is done. onOutput receives test output as it arrives
+// and onStatus each status change (queued, running, done, error).
+export async function submitProChallenge({ id, code, onOutput, onStatus }) {
   const res = await apiFetch('/api/prochallenge/submit', {
     method: 'POST',
     headers: { 'Content-Type': 'application/json' },
     body: JSON.stringify({ id, code })
   });
+  if (res.status === 429) {
+    const wait = Number(res.headers?.get('Retry-After')) || 0;
+    const error = new Error(wait
+      ? `The grader is busy. Try again in ${wait}s.`
+      : 'The grader is busy. Try again shortly.');
+    error.retryAfter = wait;
+    throw error;
+  }
+  if (res.status === 403) throw await trackLockedError(res);
   if (!res.ok) throw new Error('Submission failed');
-  return res.json();
+  const job = await res.json();
+  onStatus?.(job.status, job);
+  const handlers = { onOutput, onStatus };
+  const done = typeof EventSource === 'function'
+    ? await streamGradingJob(job, handlers)
+    : await pollGradingJob(job, handlers, 0);
+  if (done.status !== 'done') throw new Error(done.error || 'Grading failed');
+  return done.result;
+}
+
+// streamGradingJob follows the job's event stream, falling back to polling
+// from the output already received when the stream fails.
+function streamGradingJob(job, handlers) {
+  return new Promise((resolve, reject) => {
+    const source = new EventSource(job.eventsUrl);
+    let received = 0;
+    source.addEventListener('status', (event) => {
+      const { status } = JSON.parse(event.data);
+      handlers.onStatus?.(status);
+    });
+    source.addEventListener('output', (event) => {
+      const { text } = JSON.parse(event.data);
+      received += text.length;
+      handlers.onOutput?.(text);
+    });
+    source.addEventListener('done', (event) => {
+      source.close();
+      resolve(JSON.parse(event.data));
+    });
+    source.onerror = () => {
+      source.close();
+      pollGradingJob(job, handlers, received).then(resolve, reject);
+    };
+  });
+}
+
+async function pollGradingJob(job, handlers, seen) {
+  let status = job.status;
+  for (;;) {
+    const res = await apiFetch(job.statusUrl);
+    if (!res.ok) throw new Error('Unable to fetch grading status');
+    const data = await res.json();
+    const output = data.output || '';
+    if (output.length > seen) {
+      handlers.onOutput?.(output.slice(seen));
+      seen = output.length;
+    }
+    if (data.status !== status) {
+      status = data.status;
+      handlers.onStatus?.(status, data);
+    }
+    if (status === 'done' || status === 'error') return data;
+    await new Promise((resolve) => setTimeout(resolve, GRADING_POLL_MS));
+  }
 }
 
 export async function requestProHint(id) {
@@ -300,12 +404,37 @@ export async function requestProHint(id) {
     headers: { 'Content-Type': 'application/json' },
     body: JSON.stringify({ id })
   });
+  if (res.status === 403) throw await trackLockedError(res);
   if (!res.ok) throw new Error('Hint unavailable');
   return res.json();
 }
 
 // ---------- Leaderboard ----------
 
+// Today's daily quiz and challenge, with the signed-in user's streak and
+// whether today's scored attempt is used.
+export async function getDaily() {
+  const res = await apiFetch('/api/daily');
+  if (!res.ok) throw new Error('Failed to load the daily quiz');
+  return res.json();
+}
+
+// Start today's daily quiz; only a signed-in user's first start is scored.
+export async function startDailyQuiz() {
+  const res = await apiFetch('/api/session?stage=daily', { method: 'POST' });
+  if (!res.ok) throw new Error('Failed to start the daily quiz');
+  return res.json();
+}
+
+// kind is 'quiz' or 'challenge'.
+export async function getDailyLeaderboard(date = '', kind = 'quiz') {
+  const params = new URLSearchParams({ kind });
+  if (date) params.set('date', date);
+  const res = await apiFetch(`/api/daily/leaderboard?${params}`);
+  if (!res.ok) throw new Error('Failed to get the daily leaderboard');
+  return res.json();
+}
+
 export async function getLeaderboard(mode = '') {
   const url = mode ? `/api/leaderboard?mode=${encodeURIComponent(mode)}` : '/api/leaderboard';
   const res = await apiFetch(url);
@@ -325,12 +454,23 @@ export async function submitToLeaderboard(name, score, mode, category = '') {
   return res.json();
 }
 
-// Update typing score server-side
-export async function updateTypingScore(score) {
+// Start a typing session on a lesson; the server times it from here
+export async function startTypingSession(lessonId) {
+  const res = await apiFetch('/api/typing/start', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ lessonId })
+  });
+  if (!res.ok) throw new Error('Failed to start typing session');
+  return res.json();
+}
+
+// Update typing score server-side; chars is the correctly typed characters
+export async function updateTypingScore(score, chars) {
   const res = await apiFetch('/api/typing/score', {
     method: 'POST',
     headers: { 'Content-Type': 'application/json' },
-    body: JSON.stringify({ score })
+    body: JSON.stringify({ score, chars })
   });
   if (!res.ok) throw new Error('Failed to update typing score');
   return res.json();
@@ -7,6 +7,8 @@ import {
   getReadingLesson,
   addLessonToQuiz,
   startQuiz,
+  startDailyQuiz,
+  getDailyLeaderboard,
   getCurrentQuiz,
   answerQuiz,
   getProChallenge,
@@ -233,6 +235,46 @@ describe('API Module', () => {
       })
       expect(result).toEqual(mockResponse)
     })
+
+    it('asks for a timed quiz', async () => {
+      global.fetch.mockResolvedValueOnce({
+        ok: true,
+        json: async () => ({ stage: 'quiz', timed: true, questionTimeLeftMs: 20000 })
+      })
+
+      await startQuiz({ timed: true })
+
+      expect(global.fetch).toHaveBeenCalledWith('/api/session?stage=startQuiz&timed=true', {
+        method: 'POST'
+      })
+    })
+  })
+
+  describe('daily quiz', () => {
+    it('starts the daily quiz', async () => {
+      global.fetch.mockResolvedValueOnce({
+        ok: true,
+        json: async () => ({ stage: 'quiz', daily: '2026-03-14', dailyScored: true })
+      })
+
+      const result = await startDailyQuiz()
+
+      expect(global.fetch).toHaveBeenCalledWith('/api/session?stage=daily', {
+        method: 'POST'
+      })
+      expect(result.dailyScored).toBe(true)
+    })
+
+    it('fetches a day\'s leaderboard', async () => {
+      global.fetch.mockResolvedValueOnce({
+        ok: true,
+        json: async () => ({ date: '2026-03-14', entries: [] })
+      })
+
+      await getDailyLeaderboard('2026-03-14')
+
+      expect(global.fetch).toHaveBeenCalledWith('/api/daily/leaderboard?date=2026-03-14')
+    })
   })
 
   describe('getCurrentQuiz', () => {
@@ -278,6 +320,21 @@ describe('API Module', () => {
       })
       expect(result).toEqual(mockResponse)
     })
+
+    it('submits an authored answer as given', async () => {
+      global.fetch.mockResolvedValueOnce({
+        ok: true,
+        json: async () => ({ correct: true })
+      })
+
+      await answerQuiz({ order: [2, 0, 1] })
+
+      expect(global.fetch).toHaveBeenCalledWith('/api/session?stage=answer', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ order: [2, 0, 1] })
+      })
+    })
   })
 
   describe('getProChallenge', () => {
@@ -320,31 +377,75 @@ describe('API Module', () => {
   })
 
   describe('submitProChallenge', () => {
-    it('submits challenge code successfully', async () => {
+    const job = {
+      jobId: 'job1',
+      status: 'queued',
+      position: 1,
+      statusUrl: '/api/prochallenge/jobs/job1',
+      eventsUrl: '/api/prochallenge/jobs/job1/events'
+    }
+
+    it('submits challenge code and follows the job to its result', async () => {
       const mockResult = {
         passed: true,
         xpEarned: 100,
         coinsEarned: 50
       }
 
-      global.fetch.mockResolvedValueOnce({
-        ok: true,
-        json: async () => mockResult
-      })
-
+      global.fetch
+        .mockResolvedValueOnce({
+          ok: true,
+          status: 202,
+          json: async () => job
+        })
+        .mockResolvedValueOnce({
+          ok: true,
+          json: async () => ({ id: 'job1', status: 'done', output: '=== RUN   TestX\n', result: mockResult })
+        })
+
+      const onOutput = vi.fn()
+      const onStatus = vi.fn()
       const result = await submitProChallenge({
         id: 'challenge1',
-        code: 'package main\nfunc main() {}'
+        code: 'package main\nfunc main() {}',
+        onOutput,
+        onStatus
       })
 
-      expect(global.fetch).toHaveBeenCalledWith('/api/prochallenge/submit', {
+      expect(global.fetch).toHaveBeenNthCalledWith(1, '/api/prochallenge/submit', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ id: 'challenge1', code: 'package main\nfunc main() {}' })
       })
+      expect(global.fetch).toHaveBeenNthCalledWith(2, '/api/prochallenge/jobs/job1')
+      expect(onOutput).toHaveBeenCalledWith('=== RUN   TestX\n')
+      expect(onStatus).toHaveBeenLastCalledWith('done', expect.objectContaining({ status: 'done' }))
       expect(result).toEqual(mockResult)
     })
 
+    it('throws the job error when grading fails', async () => {
+      global.fetch
+        .mockResolvedValueOnce({ ok: true, status: 202, json: async () => job })
+        .mockResolvedValueOnce({
+          ok: true,
+          json: async () => ({ id: 'job1', status: 'error', error: 'code execution sandbox unavailable' })
+        })
+
+      await expect(submitProChallenge({ id: 'test', code: 'code' }))
+        .rejects.toThrow('code execution sandbox unavailable')
+    })
+
+    it('reports when to retry a full queue', async () => {
+      global.fetch.mockResolvedValueOnce({
+        ok: false,
+        status: 429,
+        headers: { get: (name) => (name === 'Retry-After' ? '3' : null) }
+      })
+
+      await expect(submitProChallenge({ id: 'test', code: 'code' }))
+        .rejects.toThrow('Try again in 3s')
+    })
+
     it('throws error when submission fails', async () => {
       global.fetch.mockResolvedValueOnce({
         ok: false,
@@ -21,7 +21,10 @@ export default function Dashboard({
   onStartAI,
   onStartTyping,
   onStartProMode,
-  onOpenLeaderboard
+  onOpenLeaderboard,
+  daily = null,
+  onStartDaily = null,
+  onOpenDailyChallenge = null
 }) {
   const learnBadges = [
     { label: 'Coins', value: coins },
@@ -147,6 +150,35 @@ export default function Dashboard({
         </div>
       </div>
 
+      {daily && onStartDaily && (
+        <div className="leaderboard-section">
+          <div className="leaderboard-banner" onClick={onStartDaily}>
+            <div className="leaderboard-banner-content">
+              <h3>Daily Quiz · {daily.date}</h3>
+              <p>
+                The same {daily.total} questions for everyone today.{' '}
+                {daily.completed
+                  ? `Done: ${daily.score.score}/${daily.score.total}. Replays are practice.`
+                  : 'Your first attempt is the one that counts.'}
+                {daily.streak > 0 && ` Streak: ${daily.streak} day${daily.streak === 1 ? '' : 's'}.`}
+              </p>
+              {daily.challenge && onOpenDailyChallenge && (
+                <p>
+                  Challenge of the day:{' '}
+                  <button className="ghost" onClick={(e) => { e.stopPropagation(); onOpenDailyChallenge(); }}>
+                    {daily.challenge.title}
+                  </button>
+                  {daily.challengeCompleted
+                    ? ` Done: ${daily.challengeScore.score}/${daily.challengeScore.total} tests.`
+                    : ' Your first graded submission counts.'}
+                </p>
+              )}
+            </div>
+            <button className="leaderboard-view-btn">{daily.completed ? 'Practice' : 'Play'}</button>
+          </div>
+        </div>
+      )}
+
       <div className="leaderboard-section">
         <div className="leaderboard-banner" onClick={onOpenLeaderboard}>
           <div className="leaderboard-banner-content">
@@ -1,5 +1,5 @@
 import React, { useEffect, useState } from 'react';
-import { getLeaderboard } from '../api';
+import { getDailyLeaderboard, getLeaderboard } from '../api';
 
 export default function Leaderboard({ onClose }) {
   const [selectedMode, setSelectedMode] = useState('quiz');
@@ -13,8 +13,13 @@ export default function Leaderboard({ onClose }) {
   async function loadLeaderboard() {
     setLoading(true);
     try {
-      const data = await getLeaderboard(selectedMode);
-      setEntries(data || []);
+      if (selectedMode === 'daily' || selectedMode === 'daily-challenge') {
+        const data = await getDailyLeaderboard('', selectedMode === 'daily' ? 'quiz' : 'challenge');
+        setEntries(data?.entries || []);
+      } else {
+        const data = await getLeaderboard(selectedMode);
+        setEntries(data || []);
+      }
     } catch (error) {
       console.error('Failed to load leaderboard:', error);
       setEntries([]);
@@ -44,6 +49,12 @@ export default function Leaderboard({ onClose }) {
           >
             Quiz Mode
           </button>
+          <button
+            className={`tab-btn ${selectedMode === 'quiz-timed' ? 'active' : ''}`}
+            onClick={() => setSelectedMode('quiz-timed')}
+          >
+            Timed Quiz
+          </button>
           <button
             className={`tab-btn ${selectedMode === 'coding' ? 'active' : ''}`}
             onClick={() => setSelectedMode('coding')}
@@ -56,6 +67,18 @@ export default function Leaderboard({ onClose }) {
           >
             Typing Mode
           </button>
+          <button
+            className={`tab-btn ${selectedMode === 'daily' ? 'active' : ''}`}
+            onClick={() => setSelectedMode('daily')}
+          >
+            Today's Daily
+          </button>
+          <button
+            className={`tab-btn ${selectedMode === 'daily-challenge' ? 'active' : ''}`}
+            onClick={() => setSelectedMode('daily-challenge')}
+          >
+            Today's Challenge
+          </button>
         </div>
 
         <div className="leaderboard-content">
@@ -75,8 +98,17 @@ export default function Leaderboard({ onClose }) {
                   <div className={`rank ${index < 3 ? 'top-rank' : ''}`}>
                     {getRankDisplay(index)}
                   </div>
-                  <div className="player-name">{entry.name}</div>
-                  <div className="score">{entry.score.toLocaleString()}</div>
+                  <div className="player-name">
+                    {entry.name}
+                    {entry.level > 0 && (
+                      <span className="badge" title={entry.title} style={{marginLeft:8}}>Lv {entry.level}</span>
+                    )}
+                  </div>
+                  <div className="score">
+                    {entry.score.toLocaleString()}
+                    {entry.total ? ` / ${entry.total}` : ''}
+                    {entry.streak > 1 ? ` · 🔥 ${entry.streak}` : ''}
+                  </div>
                   <div className="date">
                     {new Date(entry.date).toLocaleDateString()}
                   </div>
@@ -119,7 +119,8 @@ export default function LessonView({
             {isSaved ? 'Saved' : saveRequiresAuth ? 'Sign in to save' : 'Save lesson'}
           </button>
         )}
-        <button className="primary" onClick={onStartQuiz}>Start quiz</button>
+        <button className="primary" onClick={()=>onStartQuiz()}>Start quiz</button>
+        <button onClick={()=>onStartQuiz({ timed: true })}>Timed quiz</button>
       </div>
 
       <div className="footer">You'll only be quizzed on concepts you read. Don't chew what you can't swallow.</div>
@@ -27,11 +27,30 @@ function readableTopics(topics = []) {
   return topics.map((t) => t.replace(/[-_]/g, ' ')).join(', ');
 }
 
+const TEST_MARKS = { pass: '✓', fail: '✗', skip: '–' };
+
+function TestTree({ tests, parent = '' }) {
+  return (
+    <ul className="test-tree">
+      {tests.map((tc) => (
+        <li key={tc.name} className={`test-${tc.status}`}>
+          {TEST_MARKS[tc.status] || '•'} {parent ? tc.name.slice(parent.length + 1) : tc.name}
+          <span className="muted"> {(tc.elapsed || 0).toFixed(2)}s</span>
+          {tc.status === 'fail' && tc.output && <pre>{tc.output}</pre>}
+          {tc.subtests?.length > 0 && <TestTree tests={tc.subtests} parent={tc.name} />}
+        </li>
+      ))}
+    </ul>
+  );
+}
+
 export default function ProModeView({
   coins,
   xp,
   onCoinsChange = () => {},
   onXpChange = () => {},
+  onLevelUp = () => {},
+  daily = false,
   onSubmitToLeaderboard,
   onExit = () => {},
 }) {
@@ -41,10 +60,14 @@ export default function ProModeView({
   const [code, setCode] = useState('');
   const [loading, setLoading] = useState(false);
   const [running, setRunning] = useState(false);
+  const [jobStatus, setJobStatus] = useState('');
   const [hintBusy, setHintBusy] = useState(false);
   const [banner, setBanner] = useState(null);
   const [output, setOutput] = useState('');
   const [failures, setFailures] = useState([]);
+  const [tests, setTests] = useState([]);
+  const [diagnostics, setDiagnostics] = useState([]);
+  const [timing, setTiming] = useState(null);
   const [hints, setHints] = useState([]);
   const [error, setError] = useState('');
 
@@ -59,11 +82,11 @@ export default function ProModeView({
   }, [difficulty]);
 
   useEffect(() => {
-    loadChallenge('', 'advanced');
+    loadChallenge('', 'advanced', daily);
     // eslint-disable-next-line react-hooks/exhaustive-deps
   }, []);
 
-  async function loadChallenge(nextTopic = topic, nextDifficulty = difficulty) {
+  async function loadChallenge(nextTopic = topic, nextDifficulty = difficulty, dailyPick = false) {
     const resolvedTopic = nextTopic ?? '';
     const resolvedDifficulty = nextDifficulty ?? 'advanced';
     setLoading(true);
@@ -78,6 +101,7 @@ export default function ProModeView({
       const data = await getProChallenge({
         topic: resolvedTopic || undefined,
         difficulty: resolvedDifficulty === 'any' ? undefined : resolvedDifficulty,
+        daily: dailyPick,
       });
       setChallenge(data);
       setCode(data?.starter?.code || '');
@@ -95,11 +119,26 @@ export default function ProModeView({
     if (!challenge || running) return;
     setRunning(true);
     setBanner(null);
+    setOutput('');
+    setFailures([]);
+    setTests([]);
+    setDiagnostics([]);
+    setTiming(null);
     try {
-      const res = await submitProChallenge({ id: challenge.id, code });
+      const res = await submitProChallenge({
+        id: challenge.id,
+        code,
+        onStatus: setJobStatus,
+        onOutput: (text) => setOutput((prev) => prev + text),
+      });
       const combined = [res.stdout, res.stderr].filter(Boolean).join('\n\n').trim();
       setOutput(combined);
       setFailures(res.failures || []);
+      setTests(res.tests || []);
+      setDiagnostics(res.diagnostics || []);
+      if (typeof res.compileMs === 'number') {
+        setTiming({ compileMs: res.compileMs, runMs: res.runMs || 0 });
+      }
       if (res.passed) {
         setBanner({
           type: 'ok',
@@ -112,6 +151,7 @@ export default function ProModeView({
         if (typeof res.xpTotal === 'number' && onXpChange) {
           onXpChange(res.xpTotal);
         }
+        if (res.levelUp) onLevelUp(res.levelUp);
       } else {
         setBanner({
           type: 'bad',
@@ -125,6 +165,7 @@ export default function ProModeView({
       });
     } finally {
       setRunning(false);
+      setJobStatus('');
     }
   }
 
@@ -220,7 +261,7 @@ export default function ProModeView({
 
           <div className="run-actions">
             <button className="badge badge-button" onClick={handleRunTests} disabled={!canRun}>
-              {running ? 'Running...' : 'Run Tests'}
+              {running ? (jobStatus === 'queued' ? 'Queued...' : 'Running...') : 'Run Tests'}
             </button>
             <button
               className="badge badge-button"
@@ -257,7 +298,25 @@ export default function ProModeView({
           )}
 
           <div className="console">
-            {failures.length > 0 ? (
+            {diagnostics.length > 0 ? (
+              <div className="console-section">
+                <strong>Build errors</strong>
+                {diagnostics.map((d, idx) => (
+                  <div key={`${d.file}-${d.line}-${idx}`}>
+                    {d.file}:{d.line}{d.column ? `:${d.column}` : ''}: {d.message}
+                  </div>
+                ))}
+              </div>
+            ) : tests.length > 0 ? (
+              <div className="console-section">
+                {failures
+                  .filter((f) => f.name === 'timeout')
+                  .map((f) => (
+                    <strong key={f.name}>{f.output}</strong>
+                  ))}
+                <TestTree tests={tests} />
+              </div>
+            ) : failures.length > 0 ? (
               failures.map((f, idx) => (
                 <div key={`${f.name || 'failure'}-${idx}`} className="console-section">
                   <strong>FAIL {f.name || 'Test'}</strong>
@@ -269,6 +328,12 @@ export default function ProModeView({
             ) : (
               <span className="muted">Run tests to view output. stdout/stderr will appear here.</span>
             )}
+            {timing && (
+              <div className="muted">
+                Compiled in {timing.compileMs} ms
+                {diagnostics.length === 0 && ` · tests ran in ${timing.runMs} ms`}
+              </div>
+            )}
           </div>
 
           <div className="hints-block">
@@ -25,9 +25,14 @@ export default function ProfileModal({ user, onClose, onLogout, onRemoveLesson }
             <span>Coins</span>
             <strong>{statLabel(profile.coins)}</strong>
           </div>
+          <div className="profile-badge">
+            <span>Level</span>
+            <strong>{profile.level ? `${profile.level.level} · ${profile.level.title}` : '—'}</strong>
+          </div>
           <div className="profile-badge">
             <span>XP</span>
             <strong>{statLabel(profile.xp)}</strong>
+            {profile.level && <small>{profile.level.nextLevelXp - profile.xp} to next level</small>}
           </div>
           <div className="profile-badge">
             <span>Quiz Streak</span>
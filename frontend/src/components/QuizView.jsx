@@ -1,21 +1,130 @@
-import React from 'react';
+import React, { useEffect, useState } from 'react';
+
+// Feedback on the previous answer: the question's explanation plus the
+// explanation of each option that was chosen or correct.
+function Feedback({ feedback, correct }) {
+  if (!feedback) return null;
+  const notes = (feedback.options || []).filter(o => o.explain && (o.chosen || o.correct));
+  if (!feedback.explain && notes.length === 0 && !feedback.accepted) return null;
+  return (
+    <div className="badge" style={{display:'block', marginTop:8, whiteSpace:'normal'}}>
+      <strong>{correct ? 'Correct.' : 'Not quite.'}</strong>{' '}
+      {feedback.accepted && !correct && <span>Accepted: {feedback.accepted.join(', ')}. </span>}
+      {feedback.explain}
+      {notes.map((o,i)=>(
+        <div key={i} style={{marginTop:4}}>{o.chosen ? '→' : '✓'} {o.text}: {o.explain}</div>
+      ))}
+    </div>
+  );
+}
+
+// Countdown for a timed quiz. The server sends the time left with each
+// question and enforces the deadline itself, so this only counts down from
+// when the question arrived; pausing the page cannot buy more time.
+function useCountdown(leftMs, key) {
+  const [now, setNow] = useState(Date.now());
+  const [start, setStart] = useState(Date.now());
+  useEffect(() => {
+    setStart(Date.now());
+    setNow(Date.now());
+    if (!leftMs) return undefined;
+    const id = setInterval(() => setNow(Date.now()), 250);
+    return () => clearInterval(id);
+  }, [leftMs, key]);
+  if (!leftMs) return null;
+  return Math.max(0, leftMs - (now - start));
+}
+
+export default function QuizView({ question, type, options, index, total, feedback, lastCorrect, questionTimeLeftMs, quizTimeLeftMs, onAnswer, onExit }) {
+  const [picked, setPicked] = useState([]);
+  const [order, setOrder] = useState([]);
+  const [text, setText] = useState('');
+  const questionLeft = useCountdown(questionTimeLeftMs, index);
+  const quizLeft = useCountdown(quizTimeLeftMs, index);
+
+  useEffect(() => {
+    setPicked([]);
+    setOrder((options || []).map((_, i) => i));
+    setText('');
+  }, [question, options]);
+
+  // Out of time: send an empty answer so the server scores it and moves on.
+  const expired = questionLeft === 0;
+  useEffect(() => {
+    if (expired) onAnswer({ answerIndex: -1 });
+  }, [expired]); // eslint-disable-line react-hooks/exhaustive-deps
+
+  function move(pos, delta) {
+    const next = [...order];
+    const to = pos + delta;
+    if (to < 0 || to >= next.length) return;
+    [next[pos], next[to]] = [next[to], next[pos]];
+    setOrder(next);
+  }
+
+  let body;
+  if (type === 'multi-select') {
+    body = (
+      <>
+        {options?.map((o,i)=>(
+          <label key={i} className="option" style={{display:'block'}}>
+            <input
+              type="checkbox"
+              checked={picked.includes(i)}
+              onChange={()=>setPicked(p => p.includes(i) ? p.filter(x => x !== i) : [...p, i])}
+            />{' '}
+            {String.fromCharCode(65+i)}. {o}
+          </label>
+        ))}
+        <button className="primary" onClick={()=>onAnswer({ answerIndexes: picked })}>Submit</button>
+      </>
+    );
+  } else if (type === 'ordering') {
+    body = (
+      <>
+        {order.map((i,pos)=>(
+          <div key={i} className="option" style={{display:'flex', alignItems:'center', gap:8}}>
+            <span style={{flex:1}}>{pos + 1}. {options[i]}</span>
+            <button onClick={()=>move(pos, -1)} disabled={pos === 0}>↑</button>
+            <button onClick={()=>move(pos, 1)} disabled={pos === order.length - 1}>↓</button>
+          </div>
+        ))}
+        <button className="primary" onClick={()=>onAnswer({ order })}>Submit</button>
+      </>
+    );
+  } else if (type === 'fill-blank') {
+    body = (
+      <form onSubmit={e=>{ e.preventDefault(); onAnswer({ answerText: text }); }}>
+        <input className="option" value={text} onChange={e=>setText(e.target.value)} placeholder="Your answer" autoFocus />
+        <button className="primary" type="submit" disabled={!text.trim()}>Submit</button>
+      </form>
+    );
+  } else {
+    body = options?.map((o,i)=>(
+      <button key={i} className="option" onClick={()=>onAnswer(i)}>
+        {String.fromCharCode(65+i)}. {o}
+      </button>
+    ));
+  }
 
-export default function QuizView({ question, options, index, total, onAnswer, onExit }) {
   return (
     <div className="card">
       <div className="nav">
         <button className="home" onClick={()=>onExit && onExit()}>Go Home</button>
       </div>
 
-      <div style={{color:'#7d89b0', fontWeight:600}}>🧩 Quiz {index} / {total}</div>
-      <div className="prompt" style={{marginTop:8}}>{question}</div>
-      <div style={{marginTop:10}}>
-        {options?.map((o,i)=>(
-          <button key={i} className="option" onClick={()=>onAnswer(i)}>
-            {String.fromCharCode(65+i)}. {o}
-          </button>
-        ))}
+      <Feedback feedback={feedback} correct={lastCorrect} />
+      <div style={{color:'#7d89b0', fontWeight:600, marginTop:8}}>
+        🧩 Quiz {index} / {total}
+        {questionLeft !== null && (
+          <span style={{marginLeft:12, color: questionLeft < 5000 ? '#e5484d' : undefined}}>
+            ⏱ {Math.ceil(questionLeft / 1000)}s
+            {quizLeft !== null && <> · {Math.ceil(quizLeft / 1000)}s total</>}
+          </span>
+        )}
       </div>
+      <div className="prompt" style={{marginTop:8}}>{question}</div>
+      <div style={{marginTop:10}}>{body}</div>
     </div>
   );
 }
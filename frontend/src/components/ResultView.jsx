@@ -1,6 +1,19 @@
 import React from 'react'
 
-export default function ResultView({ correct, earned, total, message, onContinue, onExit, onSubmitToLeaderboard }) {
+// answerText renders what was submitted for a missed question.
+function answerText(m) {
+  if (m.type === 'fill-blank') return m.answerText || '—';
+  if (m.type === 'ordering') return (m.chosen || []).map(i => m.options[i]).join(' → ');
+  return (m.chosen || []).map(i => m.options?.[i]).filter(Boolean).join(', ') || '—';
+}
+
+function rightText(m) {
+  if (m.type === 'fill-blank') return (m.accepted || []).join(' / ');
+  const sep = m.type === 'ordering' ? ' → ' : ', ';
+  return (m.correct || []).map(i => m.options?.[i]).join(sep);
+}
+
+export default function ResultView({ correct, earned, total, message, misses, onContinue, onExit, onSubmitToLeaderboard }) {
   return (
     <div className="card">
       <div className="nav">
@@ -13,6 +26,29 @@ export default function ResultView({ correct, earned, total, message, onContinue
         <span className="badge">+{earned} coins</span>
         <span className="badge">Total: {total}</span>
       </div>
+      {misses?.length > 0 && (
+        <div style={{marginTop:12}}>
+          <div style={{color:'#7d89b0', fontWeight:600}}>Review your misses</div>
+          {misses.map(m => (
+            <div key={m.index} className="option" style={{marginTop:8, cursor:'default'}}>
+              <div><strong>{m.question}</strong></div>
+              <div>Your answer: {answerText(m)}</div>
+              <div>Correct: {rightText(m)}</div>
+              {m.explain && <div>{m.explain}</div>}
+              {m.lesson && (
+                <div style={{marginTop:4}}>
+                  <em>{m.lesson.title}:</em> {m.lesson.explain}
+                  {m.lesson.tips?.length > 0 && (
+                    <ul style={{margin:'4px 0 0 16px'}}>
+                      {m.lesson.tips.map((tip, i) => <li key={i}>{tip}</li>)}
+                    </ul>
+                  )}
+                </div>
+              )}
+            </div>
+          ))}
+        </div>
+      )}
       <div style={{marginTop:12, display:'flex', gap:12, flexWrap:'wrap'}}>
         <button className="primary" onClick={onContinue}>Continue</button>
         {onSubmitToLeaderboard && correct && (
@@ -1,5 +1,5 @@
 import React, { useEffect, useRef, useState } from 'react';
-import { randomLesson, updateTypingScore } from '../api';
+import { randomLesson, startTypingSession, updateTypingScore } from '../api';
 
 function categoryLabel(value) {
   if (value === 'any') return 'Any';
@@ -14,6 +14,8 @@ export default function TypingView({
   selectedCategory = 'any',
   onSelectCategory,
   onTypingStats,
+  onXpChange = () => {},
+  onLevelUp = () => {},
   typingBest = 0,
   onSubmitToLeaderboard,
 }) {
@@ -52,6 +54,11 @@ export default function TypingView({
     statsRef.current = resetStats;
     onTypingStats && onTypingStats({ streak: 0, best: typingBest });
     const l = await randomLesson(pickCategory());
+    try {
+      await startTypingSession(l.id);
+    } catch (err) {
+      console.error('Failed to start typing session:', err);
+    }
     setLesson(l);
     setText(l.text);
     setTyped('');
@@ -104,11 +111,17 @@ export default function TypingView({
     if (!running && lesson && typed) {
       onTypingStats && onTypingStats({ streak: statsRef.current.streak, best: statsRef.current.best });
       // Update server-side typing score
-      updateTypingScore(statsRef.current.wpm).catch(err => {
-        console.error('Failed to update typing score:', err);
-      });
+      const correct = typed.split('').filter((ch, i) => ch === text[i]).length;
+      updateTypingScore(statsRef.current.wpm, correct)
+        .then(res => {
+          if (typeof res.xpTotal === 'number') onXpChange(res.xpTotal);
+          if (res.levelUp) onLevelUp(res.levelUp);
+        })
+        .catch(err => {
+          console.error('Failed to update typing score:', err);
+        });
     }
-  }, [running, lesson, typed, onTypingStats]);
+  }, [running, lesson, typed, text, onTypingStats, onXpChange, onLevelUp]);
 
   function renderText() {
     return text.split('').map((ch, i) => {